@@ -0,0 +1,90 @@
+// Command auditadmin содержит служебные команды для работы с журналом аудита.
+//
+// Использование:
+//
+//	auditadmin verify -file /var/log/url-shorter/audit.log
+//	auditadmin verify audit.log.20250101T000000.000000000.gz audit.log
+//
+// Ключ HMAC задается флагом -key или переменной окружения AUDIT_FILE_CHAIN_KEY.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+)
+
+const chainKeyEnv = "AUDIT_FILE_CHAIN_KEY"
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "verify":
+		os.Exit(verify(os.Args[2:]))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: auditadmin verify [-key secret] [-file audit.log | files...]")
+}
+
+// verify проверяет цепочку записей и возвращает код завершения.
+func verify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	key := fs.String("key", os.Getenv(chainKeyEnv), "HMAC secret used to write the audit log")
+	file := fs.String("file", "", "audit log path; rotated segments are discovered automatically")
+	fs.Parse(args)
+
+	if *key == "" {
+		fmt.Fprintln(os.Stderr, "HMAC key is required")
+
+		return 2
+	}
+
+	files := fs.Args()
+	if *file != "" {
+		discovered, err := audit.ChainFiles(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to list audit files: %v\n", err)
+
+			return 2
+		}
+
+		files = append(discovered, files...)
+	}
+
+	if len(files) == 0 {
+		usage()
+
+		return 2
+	}
+
+	result, err := audit.VerifyChain([]byte(*key), files)
+
+	var chainErr *audit.ChainError
+	switch {
+	case errors.As(err, &chainErr):
+		fmt.Printf("BROKEN: %s\n", chainErr)
+
+		return 1
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "verification failed: %v\n", err)
+
+		return 2
+	}
+
+	fmt.Printf("OK: %d entries in %d files, seq %d..%d\n",
+		result.Entries, result.Files, result.FirstSeq, result.LastSeq)
+
+	return 0
+}
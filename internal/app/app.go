@@ -5,18 +5,27 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/config"
+	"github.com/MarkelovSergey/url-shorter/internal/enumguard"
 	"github.com/MarkelovSergey/url-shorter/internal/handler"
 	"github.com/MarkelovSergey/url-shorter/internal/middleware"
 	"github.com/MarkelovSergey/url-shorter/internal/migration"
+	"github.com/MarkelovSergey/url-shorter/internal/ratelimit"
+	"github.com/MarkelovSergey/url-shorter/internal/repository/campaignrepository"
 	"github.com/MarkelovSergey/url-shorter/internal/repository/healthrepository"
 	"github.com/MarkelovSergey/url-shorter/internal/repository/urlshorterrepository"
+	"github.com/MarkelovSergey/url-shorter/internal/service/campaignservice"
+	"github.com/MarkelovSergey/url-shorter/internal/service/domainlist"
 	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlnorm"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlpolicy"
 	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
 	"github.com/MarkelovSergey/url-shorter/internal/storage"
 	"github.com/MarkelovSergey/url-shorter/internal/storage/filestorage"
@@ -35,6 +44,9 @@ type App struct {
 	dbPool         *pgxpool.Pool
 	logger         *zap.Logger
 	auditPublisher *audit.AuditPublisher
+	auditFile      *audit.FileObserver
+	closeLimiter   func() error
+	domainLists    *domainlist.Lists
 }
 
 // New создает новый экземпляр приложения с заданной конфигурацией.
@@ -42,9 +54,10 @@ type App struct {
 // Выполняет миграции базы данных и настраивает систему аудита.
 func New(cfg config.Config) *App {
 	var (
-		pool       *pgxpool.Pool
-		urlStorage storage.Storage
-		err        error
+		pool            *pgxpool.Pool
+		urlStorage      storage.Storage
+		campaignStorage storage.CampaignStorage
+		err             error
 	)
 
 	logger, err := zap.NewProduction()
@@ -62,58 +75,202 @@ func New(cfg config.Config) *App {
 			log.Fatalf("Warning: Failed to connect to database: %v", err)
 		}
 
-		urlStorage = postgresstorage.New(pool)
+		postgresStorage := postgresstorage.New(pool)
+		urlStorage, campaignStorage = postgresStorage, postgresStorage
 		log.Println("Using PostgreSQL storage")
 	}
 
 	if urlStorage == nil && cfg.Storage.FilePath != "" {
-		urlStorage = filestorage.New(cfg.Storage.FilePath)
+		fileStorage := filestorage.New(cfg.Storage.FilePath)
+		urlStorage, campaignStorage = fileStorage, fileStorage
 		log.Printf("Using file storage: %s", cfg.Storage.FilePath)
 	}
 
 	if urlStorage == nil {
-		urlStorage = memorystorage.New()
+		memoryStorage := memorystorage.New()
+		urlStorage, campaignStorage = memoryStorage, memoryStorage
 		log.Println("Using memory storage")
 	}
 
-	urlShorterRepo := urlshorterrepository.New(urlStorage)
+	dedupScope, err := urlshorterrepository.ParseDedupScope(cfg.Storage.DedupScope)
+	if err != nil {
+		log.Fatalf("Invalid dedup scope: %v", err)
+	}
+
+	if !urlshorterservice.IsRedirectStatus(cfg.Redirect.Status) {
+		log.Fatalf("Invalid redirect status: %d", cfg.Redirect.Status)
+	}
+
+	urlShorterRepo := urlshorterrepository.New(urlStorage, urlshorterrepository.WithDedupScope(dedupScope))
+	campaignRepo := campaignrepository.New(campaignStorage)
 	healthRepo := healthrepository.New(pool)
 
 	healthService := healthservice.New(healthRepo)
-	urlShorterService := urlshorterservice.New(urlShorterRepo, healthRepo, logger)
+	urlPolicy := newURLPolicy(cfg)
+	clock := urlshorterservice.SystemClock{}
+	serviceOptions := []urlshorterservice.Option{urlshorterservice.WithClock(clock)}
+
+	domainLists := newDomainLists(cfg, logger)
+	if domainLists != nil {
+		urlPolicy = urlpolicy.Chain(urlPolicy, domainLists)
+		if cfg.DomainList.CheckOnRedirect {
+			serviceOptions = append(serviceOptions, urlshorterservice.WithRedirectPolicy(domainLists))
+		}
+	}
+
+	serviceOptions = append(serviceOptions,
+		urlshorterservice.WithURLPolicy(urlPolicy),
+		urlshorterservice.WithCampaigns(campaignRepo),
+		urlshorterservice.WithNormalizer(urlnorm.New(urlnorm.Options{
+			SortQuery:      cfg.URLNormalize.SortQuery,
+			StripTracking:  cfg.URLNormalize.StripTracking,
+			TrackingParams: splitList(cfg.URLNormalize.TrackingParams),
+		})),
+	)
+	urlShorterService := urlshorterservice.New(urlShorterRepo, healthRepo, logger, serviceOptions...)
 
 	// Инициализация системы аудита
-	auditPublisher := audit.NewPublisher(logger)
+	auditPublisher := audit.NewPublisherWithQueue(cfg.Audit.QueueSize, logger)
 
+	var auditFile *audit.FileObserver
 	if cfg.Audit.FilePath != "" {
-		fileObserver, err := audit.NewFileObserver(cfg.Audit.FilePath, logger)
+		fileOptions := audit.FileOptions{
+			MaxSize:        cfg.Audit.File.MaxSize,
+			RotateInterval: cfg.Audit.File.RotateInterval,
+			Compress:       cfg.Audit.File.Compress,
+			MaxFiles:       cfg.Audit.File.MaxFiles,
+			MaxAge:         cfg.Audit.File.MaxAge,
+			FsyncInterval:  cfg.Audit.File.FsyncInterval,
+			ChainKey:       []byte(cfg.Audit.File.ChainKey),
+		}
+
+		fileObserver, err := audit.NewFileObserver(cfg.Audit.FilePath, fileOptions, logger)
 		if err != nil {
 			log.Printf("Warning: Failed to create file audit observer: %v", err)
 		} else {
-			auditPublisher.Subscribe(fileObserver)
+			subscribeAudit(auditPublisher, fileObserver, cfg.Audit.File.Filter)
+			auditFile = fileObserver
 			log.Printf("Audit file observer enabled: %s", cfg.Audit.FilePath)
 		}
 	}
 
 	if cfg.Audit.URL != "" {
-		httpObserver := audit.NewHTTPObserver(cfg.Audit.URL, logger)
-		auditPublisher.Subscribe(httpObserver)
-		log.Printf("Audit HTTP observer enabled: %s", cfg.Audit.URL)
+		httpOptions := audit.DefaultHTTPOptions()
+		httpOptions.QueueSize = cfg.Audit.QueueSize
+		httpOptions.BatchSize = cfg.Audit.HTTP.BatchSize
+		httpOptions.FlushInterval = cfg.Audit.HTTP.FlushInterval
+		httpOptions.MaxRetries = cfg.Audit.HTTP.MaxRetries
+		httpOptions.DeadLetterPath = cfg.Audit.HTTP.DeadLetterPath
+		httpOptions.CloseTimeout = cfg.Audit.HTTP.CloseTimeout
+
+		httpObserver, err := audit.NewHTTPObserver(cfg.Audit.URL, httpOptions, logger)
+		if err != nil {
+			log.Printf("Warning: Failed to create HTTP audit observer: %v", err)
+		} else {
+			subscribeAudit(auditPublisher, httpObserver, cfg.Audit.HTTP.Filter)
+			log.Printf("Audit HTTP observer enabled: %s", cfg.Audit.URL)
+		}
+	}
+
+	if cfg.Audit.Syslog.Address != "" {
+		syslogObserver, err := audit.NewSyslogObserver(audit.SyslogOptions{
+			Network: cfg.Audit.Syslog.Network,
+			Address: cfg.Audit.Syslog.Address,
+			AppName: cfg.Audit.Syslog.AppName,
+		}, logger)
+		if err != nil {
+			log.Printf("Warning: Failed to create syslog audit observer: %v", err)
+		} else {
+			subscribeAudit(auditPublisher, syslogObserver, cfg.Audit.Syslog.Filter)
+			log.Printf("Audit syslog observer enabled: %s://%s", cfg.Audit.Syslog.Network, cfg.Audit.Syslog.Address)
+		}
+	}
+
+	handlerOptions := []handler.Option{
+		handler.WithCampaignService(campaignservice.New(campaignRepo, urlShorterRepo)),
+		handler.WithClock(clock),
+	}
+
+	if auditStore := newAuditStore(cfg, pool, logger); auditStore != nil {
+		auditPublisher.Subscribe(auditStore)
+		handlerOptions = append(handlerOptions, handler.WithAuditStore(auditStore, cfg.Admin.Token))
+		log.Printf("Audit store enabled: %s", cfg.Audit.Store)
 	}
 
-	handler := handler.New(cfg, urlShorterService, healthService, logger, auditPublisher)
+	if cfg.EnumGuard.Enabled {
+		handlerOptions = append(handlerOptions, handler.WithEnumerationGuard(enumguard.New(enumguard.Options{
+			Window:        cfg.EnumGuard.Window,
+			MinRequests:   cfg.EnumGuard.MinRequests,
+			DelayRatio:    cfg.EnumGuard.DelayRatio,
+			BlockRatio:    cfg.EnumGuard.BlockRatio,
+			MaxDelay:      cfg.EnumGuard.MaxDelay,
+			BlockDuration: cfg.EnumGuard.BlockDuration,
+		})))
+		log.Println("Short code enumeration guard enabled")
+	}
+
+	if cfg.Redirect.ComingSoonPage != "" {
+		page, err := os.ReadFile(cfg.Redirect.ComingSoonPage)
+		if err != nil {
+			log.Fatalf("Failed to read coming soon page: %v", err)
+		}
+		handlerOptions = append(handlerOptions, handler.WithComingSoonPage(page))
+	}
+
+	limiter, closeLimiter := newRateLimiter(cfg, pool, logger)
+	handlerOptions = append(handlerOptions, handler.WithPasswordAttemptLimiter(limiter))
+
+	handler := handler.New(cfg, urlShorterService, healthService, logger, auditPublisher, handlerOptions...)
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logging(logger))
 	r.Use(middleware.Gzipping)
-	r.Use(middleware.Auth)
-
-	r.Post("/", handler.CreateHandler)
-	r.Get("/{id}", handler.ReadHandler)
-	r.Post("/api/shorten", handler.CreateAPIHandler)
-	r.Post("/api/shorten/batch", handler.CreateBatchHandler)
-	r.Get("/api/user/urls", handler.GetUserURLsHandler)
-	r.Delete("/api/user/urls", handler.DeleteURLsHandler)
+
+	// Событие auth_issue публикуется только для маршрутов, которым нужен пользователь:
+	// анонимные переходы по ссылкам получают токен без события, иначе каждый
+	// посетитель порождал бы событие аудита.
+	userAuth := middleware.AuthWithAudit(auditPublisher)
+
+	keyFunc := rateLimitKeyFunc(cfg.RateLimit)
+	rateLimit := func(group string, rule config.RateLimitRule) func(http.Handler) http.Handler {
+		limit := ratelimit.Limit{Rate: rule.Rate, Burst: rule.Burst}
+
+		return middleware.RateLimit(limiter, group, limit, keyFunc, logger)
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(userAuth)
+		r.Use(rateLimit("create", cfg.RateLimit.Create))
+		r.Post("/", handler.CreateHandler)
+		r.Post("/api/shorten", handler.CreateAPIHandler)
+		r.Post("/api/shorten/batch", handler.CreateBatchHandler)
+		r.Delete("/api/user/urls", handler.DeleteURLsHandler)
+		r.Patch("/api/user/urls/{id}", handler.UpdateUserURLHandler)
+		r.Post("/api/user/campaigns", handler.CreateCampaignHandler)
+		r.Patch("/api/user/campaigns/{id}", handler.UpdateCampaignHandler)
+		r.Delete("/api/user/campaigns/{id}", handler.DeleteCampaignHandler)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Auth)
+		r.Use(rateLimit("redirect", cfg.RateLimit.Redirect))
+		r.Get("/{id}", handler.ReadHandler)
+		r.Get("/{id}/*", handler.ReadHandler)
+		r.Head("/{id}", handler.ReadHandler)
+		r.Head("/{id}/*", handler.ReadHandler)
+		r.Post("/{id}", handler.ReadHandler)
+		r.Post("/{id}/*", handler.ReadHandler)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(userAuth)
+		r.Use(rateLimit("list", cfg.RateLimit.List))
+		r.Get("/api/user/urls", handler.GetUserURLsHandler)
+		r.Get("/api/user/urls/{id}", handler.GetUserURLHandler)
+		r.Get("/api/user/campaigns", handler.ListCampaignsHandler)
+		r.Get("/api/user/campaigns/{id}", handler.GetCampaignHandler)
+		r.Get("/api/user/campaigns/{id}/stats", handler.CampaignStatsHandler)
+	})
 	r.Get("/ping", handler.PingHandler)
+	r.Get("/api/admin/audit", handler.AdminAuditHandler)
 
 	srv := &http.Server{
 		Addr:    cfg.Server.Address,
@@ -125,16 +282,154 @@ func New(cfg config.Config) *App {
 		dbPool:         pool,
 		logger:         logger,
 		auditPublisher: auditPublisher,
+		auditFile:      auditFile,
+		closeLimiter:   closeLimiter,
+		domainLists:    domainLists,
+	}
+}
+
+// newURLPolicy создает политику проверки URL назначения по конфигурации.
+func newURLPolicy(cfg config.Config) urlpolicy.Policy {
+	return urlpolicy.New(urlpolicy.Options{
+		AllowedSchemes: splitList(strings.ToLower(cfg.URLPolicy.AllowedSchemes)),
+		MaxLength:      cfg.URLPolicy.MaxLength,
+		AllowPrivate:   cfg.URLPolicy.AllowPrivate,
+		ResolveDNS:     cfg.URLPolicy.ResolveDNS,
+		BaseURL:        cfg.Server.BaseURL,
+	})
+}
+
+// newDomainLists загружает списки доменов.
+// Возвращает nil, если списки не настроены. Ошибка загрузки прерывает запуск:
+// без списка запрещенных доменов сервис не должен принимать ссылки.
+func newDomainLists(cfg config.Config, logger *zap.Logger) *domainlist.Lists {
+	blocklist := splitList(cfg.DomainList.Blocklist)
+	allowlist := splitList(cfg.DomainList.Allowlist)
+	if len(blocklist) == 0 && len(allowlist) == 0 {
+		return nil
+	}
+
+	lists, err := domainlist.New(domainlist.Options{
+		Blocklist:      blocklist,
+		Allowlist:      allowlist,
+		ReloadInterval: cfg.DomainList.ReloadInterval,
+	}, logger)
+	if err != nil {
+		log.Fatalf("Failed to load domain lists: %v", err)
+	}
+
+	log.Printf("Domain lists enabled: %d blocklist and %d allowlist sources", len(blocklist), len(allowlist))
+
+	return lists
+}
+
+// splitList разбирает список значений, перечисленных через запятую.
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
 	}
+
+	return result
+}
+
+// newRateLimiter создает ограничитель частоты запросов и функцию его остановки.
+func newRateLimiter(cfg config.Config, pool *pgxpool.Pool, logger *zap.Logger) (ratelimit.Limiter, func() error) {
+	if cfg.RateLimit.Backend == "postgres" {
+		if pool != nil {
+			limiter := ratelimit.NewPostgresLimiter(pool, logger)
+
+			return limiter, limiter.Close
+		}
+
+		log.Printf("Warning: Postgres rate limiter requires database DSN, using memory")
+	}
+
+	return ratelimit.NewMemoryLimiter(), nil
+}
+
+// rateLimitKeyFunc выбирает ключ подсчета запросов по конфигурации.
+func rateLimitKeyFunc(cfg config.RateLimitConfig) middleware.KeyFunc {
+	switch cfg.Key {
+	case "ip":
+		return middleware.KeyByIP
+	case "api_key":
+		keys := splitList(cfg.APIKeys)
+		if len(keys) == 0 {
+			log.Printf("Warning: no API keys configured for rate limiting, counting requests by IP")
+		}
+
+		return middleware.KeyByAPIKey(cfg.APIKeyHeader, keys)
+	default:
+		return middleware.KeyByUser
+	}
+}
+
+// newAuditStore создает хранилище событий аудита для административного API.
+// Возвращает nil, если хранилище не настроено или не может быть создано.
+func newAuditStore(cfg config.Config, pool *pgxpool.Pool, logger *zap.Logger) audit.Store {
+	switch cfg.Audit.Store {
+	case "":
+		return nil
+	case "postgres":
+		if pool == nil {
+			log.Printf("Warning: Postgres audit store requires database DSN")
+
+			return nil
+		}
+
+		return audit.NewPostgresStore(pool, logger)
+	case "file":
+		store, err := audit.NewFileStore(cfg.Audit.StorePath, logger)
+		if err != nil {
+			log.Printf("Warning: Failed to open file audit store: %v", err)
+
+			return nil
+		}
+
+		return store
+	default:
+		log.Printf("Warning: Unknown audit store %q", cfg.Audit.Store)
+
+		return nil
+	}
+}
+
+// subscribeAudit подписывает наблюдателя аудита с фильтром, заданным в конфигурации.
+func subscribeAudit(publisher *audit.AuditPublisher, observer audit.Observer, filterSpec string) {
+	filter, err := audit.ParseFilter(filterSpec)
+	if err != nil {
+		log.Fatalf("Failed to parse audit filter: %v", err)
+	}
+
+	publisher.SubscribeWithFilter(observer, filter)
 }
 
 // Run запускает HTTP-сервер приложения и ожидает сигнал завершения.
 // Сервер корректно завершается по сигналам SIGINT или SIGTERM.
+// По сигналу SIGHUP файл аудита открывается заново для поддержки внешней ротации.
 // Закрывает все ресурсы (соединения с БД, логгер, аудит) перед выходом.
 func (a *App) Run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go func() {
+		for {
+			select {
+			case <-hup:
+				a.reopenAuditFile()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	go func() {
 		log.Printf("Server is starting on %s", a.server.Addr)
 		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -153,12 +448,19 @@ func (a *App) Run() error {
 		return fmt.Errorf("server shutdown failed: %w", err)
 	}
 
-	if a.dbPool != nil {
-		a.dbPool.Close()
+	if a.closeLimiter != nil {
+		a.closeLimiter()
+	}
+	if a.domainLists != nil {
+		a.domainLists.Close()
 	}
+	// Аудит закрывается раньше пула: хранилище событий в PostgreSQL дописывает очередь.
 	if a.auditPublisher != nil {
 		a.auditPublisher.Close()
 	}
+	if a.dbPool != nil {
+		a.dbPool.Close()
+	}
 	if a.logger != nil {
 		a.logger.Sync()
 	}
@@ -167,3 +469,18 @@ func (a *App) Run() error {
 
 	return nil
 }
+
+// reopenAuditFile заново открывает файл аудита после внешней ротации.
+func (a *App) reopenAuditFile() {
+	if a.auditFile == nil {
+		return
+	}
+
+	if err := a.auditFile.Reopen(); err != nil {
+		a.logger.Error("failed to reopen audit file", zap.Error(err))
+
+		return
+	}
+
+	a.logger.Info("audit file reopened")
+}
@@ -3,6 +3,12 @@ package audit
 
 import "time"
 
+// SchemaVersion - текущая версия схемы события аудита.
+// Увеличивается при несовместимых изменениях формата, чтобы потребители
+// FileObserver, HTTPObserver и SyslogObserver могли различать версии.
+// Версия 1 - исходный формат без поля schema_version.
+const SchemaVersion = 2
+
 // Action представляет тип действия для аудита.
 type Action string
 
@@ -10,28 +16,79 @@ type Action string
 const (
 	// ActionShorten - действие сокращения URL.
 	ActionShorten Action = "shorten"
+	// ActionShortenBatch - сокращение URL в составе пакетного запроса.
+	ActionShortenBatch Action = "shorten_batch"
 	// ActionFollow - действие перехода по короткой ссылке.
 	ActionFollow Action = "follow"
+	// ActionDelete - запрос на удаление короткой ссылки.
+	ActionDelete Action = "delete"
+	// ActionUpdate - изменение короткой ссылки.
+	ActionUpdate Action = "update"
+	// ActionFollowExpired - переход по короткой ссылке с истекшим сроком действия.
+	// Публикуется при каждом таком переходе, а не один раз при истечении срока.
+	ActionFollowExpired Action = "follow_expired"
+	// ActionAuthIssue - выдача нового токена аутентификации.
+	ActionAuthIssue Action = "auth_issue"
+	// ActionProbeBlock - блокировка клиента, перебирающего короткие коды.
+	ActionProbeBlock Action = "probe_block"
 )
 
 // Event представляет событие аудита.
 type Event struct {
-	Timestamp int64   `json:"ts"`      // unix timestamp события
-	Action    Action  `json:"action"`  // действие: shorten или follow
-	UserID    *string `json:"user_id"` // идентификатор пользователя
-	URL       string  `json:"url"`     // оригинальный URL
+	SchemaVersion int     `json:"schema_version"`       // версия схемы события
+	Timestamp     int64   `json:"ts"`                   // unix timestamp события
+	Action        Action  `json:"action"`               // тип действия
+	UserID        *string `json:"user_id"`              // идентификатор пользователя
+	URL           string  `json:"url"`                  // оригинальный URL
+	ShortCode     string  `json:"short_code,omitempty"` // короткий код ссылки
+	ClientIP      string  `json:"client_ip,omitempty"`  // IP-адрес клиента
+	UserAgent     string  `json:"user_agent,omitempty"` // заголовок User-Agent
+	Referer       string  `json:"referer,omitempty"`    // заголовок Referer
+	Status        int     `json:"status,omitempty"`     // HTTP-статус результата
+	RequestID     string  `json:"request_id,omitempty"` // идентификатор HTTP-запроса
 }
 
 // NewEvent создает новое событие аудита.
 func NewEvent(action Action, url string, userID *string) Event {
 	return Event{
-		Timestamp: time.Now().Unix(),
-		Action:    action,
-		URL:       url,
-		UserID:    userID,
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().Unix(),
+		Action:        action,
+		URL:           url,
+		UserID:        userID,
 	}
 }
 
+// WithRequestID возвращает копию события с идентификатором запроса.
+func (e Event) WithRequestID(requestID string) Event {
+	e.RequestID = requestID
+
+	return e
+}
+
+// WithShortCode возвращает копию события с коротким кодом ссылки.
+func (e Event) WithShortCode(shortCode string) Event {
+	e.ShortCode = shortCode
+
+	return e
+}
+
+// WithClient возвращает копию события с метаданными клиента.
+func (e Event) WithClient(clientIP, userAgent, referer string) Event {
+	e.ClientIP = clientIP
+	e.UserAgent = userAgent
+	e.Referer = referer
+
+	return e
+}
+
+// WithStatus возвращает копию события с HTTP-статусом результата.
+func (e Event) WithStatus(status int) Event {
+	e.Status = status
+
+	return e
+}
+
 // Observer представляет интерфейс наблюдателя событий аудита.
 type Observer interface {
 	OnEvent(event Event) error
@@ -0,0 +1,265 @@
+package audit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrChainBroken возвращается, если цепочка записей аудита нарушена.
+var ErrChainBroken = errors.New("audit chain is broken")
+
+// genesisHash - хеш предыдущей записи для первой записи цепочки.
+var genesisHash = strings.Repeat("0", sha256.Size*2)
+
+// maxChainLineSize - максимальный размер строки файла аудита при чтении.
+const maxChainLineSize = 16 * 1024 * 1024
+
+// ChainRecord - запись файла аудита с защитой от изменений.
+// PrevHash содержит SHA-256 предыдущей строки файла, HMAC подписывает
+// номер записи, хеш предыдущей записи и само событие.
+type ChainRecord struct {
+	Seq      uint64          `json:"seq"`
+	PrevHash string          `json:"prev_hash"`
+	Event    json.RawMessage `json:"event"`
+	HMAC     string          `json:"hmac"`
+}
+
+// ChainError описывает первое найденное нарушение цепочки.
+type ChainError struct {
+	File   string
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+// Error возвращает текстовое описание нарушения.
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("%s:%d: seq %d: %s", e.File, e.Line, e.Seq, e.Reason)
+}
+
+// Unwrap позволяет сравнивать ошибку с ErrChainBroken.
+func (e *ChainError) Unwrap() error {
+	return ErrChainBroken
+}
+
+// VerifyResult содержит сводку по проверенной цепочке.
+type VerifyResult struct {
+	Files    int
+	Entries  int
+	FirstSeq uint64
+	LastSeq  uint64
+}
+
+// chain хранит состояние цепочки записей между событиями и ротациями файла.
+type chain struct {
+	key      []byte
+	seq      uint64
+	prevHash string
+}
+
+func newChain(key []byte) *chain {
+	return &chain{key: key, prevHash: genesisHash}
+}
+
+// next оборачивает событие в запись цепочки и возвращает строку для записи в файл.
+func (c *chain) next(event []byte) ([]byte, error) {
+	record := ChainRecord{
+		Seq:      c.seq + 1,
+		PrevHash: c.prevHash,
+		Event:    event,
+	}
+	record.HMAC = recordMAC(c.key, record)
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	c.seq = record.Seq
+	c.prevHash = lineHash(line)
+
+	return line, nil
+}
+
+// recover восстанавливает состояние цепочки по последней записи файла аудита
+// или, если файл пуст, по последнему ротированному сегменту.
+func (c *chain) recover(path string) error {
+	files := []string{path}
+
+	segments, err := RotatedSegments(path)
+	if err != nil {
+		return err
+	}
+	for i := len(segments) - 1; i >= 0; i-- {
+		files = append(files, segments[i])
+	}
+
+	for _, file := range files {
+		found, err := c.recoverFrom(file)
+		if err != nil {
+			return err
+		}
+		if found {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (c *chain) recoverFrom(path string) (bool, error) {
+	found := false
+
+	err := readChainLines(path, func(_ int, line []byte) error {
+		var record ChainRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil
+		}
+
+		c.seq = record.Seq
+		c.prevHash = lineHash(line)
+		found = true
+
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	return found, err
+}
+
+// VerifyChain проверяет цепочку записей в файлах аудита, переданных в порядке записи.
+// Первая запись задает начало цепочки: старые сегменты могли быть удалены политикой хранения.
+// Возвращает *ChainError с описанием первой поврежденной или пропущенной записи.
+func VerifyChain(key []byte, paths []string) (VerifyResult, error) {
+	var (
+		result   VerifyResult
+		prevHash string
+		started  bool
+	)
+
+	for _, path := range paths {
+		result.Files++
+
+		err := readChainLines(path, func(lineNum int, line []byte) error {
+			var record ChainRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return &ChainError{File: path, Line: lineNum, Seq: result.LastSeq + 1, Reason: "malformed record"}
+			}
+
+			if started {
+				if record.Seq != result.LastSeq+1 {
+					return &ChainError{
+						File:   path,
+						Line:   lineNum,
+						Seq:    result.LastSeq + 1,
+						Reason: "missing entry, found seq " + strconv.FormatUint(record.Seq, 10),
+					}
+				}
+				if record.PrevHash != prevHash {
+					return &ChainError{File: path, Line: lineNum, Seq: record.Seq, Reason: "previous hash mismatch"}
+				}
+			} else if record.Seq == 1 && record.PrevHash != genesisHash {
+				return &ChainError{File: path, Line: lineNum, Seq: record.Seq, Reason: "invalid genesis hash"}
+			}
+
+			if !hmac.Equal([]byte(record.HMAC), []byte(recordMAC(key, record))) {
+				return &ChainError{File: path, Line: lineNum, Seq: record.Seq, Reason: "HMAC mismatch"}
+			}
+
+			if !started {
+				result.FirstSeq = record.Seq
+				started = true
+			}
+			result.LastSeq = record.Seq
+			result.Entries++
+			prevHash = lineHash(line)
+
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// ChainFiles возвращает ротированные сегменты и текущий файл аудита в порядке записи.
+func ChainFiles(path string) ([]string, error) {
+	segments, err := RotatedSegments(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		segments = append(segments, path)
+	}
+
+	return segments, nil
+}
+
+func recordMAC(key []byte, record ChainRecord) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strconv.FormatUint(record.Seq, 10)))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(record.PrevHash))
+	mac.Write([]byte{'\n'})
+	mac.Write(record.Event)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func lineHash(line []byte) string {
+	sum := sha256.Sum256(line)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// readChainLines построчно читает файл аудита, распаковывая gzip-сегменты.
+func readChainLines(path string, fn func(lineNum int, line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, gzipSuffix) {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+
+		reader = zr
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxChainLineSize)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := fn(lineNum, scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
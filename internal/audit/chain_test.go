@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testChainKey = []byte("secret")
+
+// writeChain записывает события цепочкой с ротацией и перезапуском наблюдателя.
+func writeChain(t *testing.T, path string, events int) {
+	t.Helper()
+
+	opts := FileOptions{MaxSize: 600, ChainKey: testChainKey}
+
+	for _, batch := range []int{events / 2, events - events/2} {
+		observer, err := NewFileObserver(path, opts, zap.NewNop())
+		require.NoError(t, err)
+
+		for i := 0; i < batch; i++ {
+			require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+		}
+		require.NoError(t, observer.Close())
+	}
+}
+
+func TestVerifyChainAcrossRotationAndRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeChain(t, path, 10)
+
+	files, err := ChainFiles(path)
+	require.NoError(t, err)
+	require.Greater(t, len(files), 1)
+
+	result, err := VerifyChain(testChainKey, files)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Entries)
+	assert.Equal(t, uint64(1), result.FirstSeq)
+	assert.Equal(t, uint64(10), result.LastSeq)
+
+	_, err = VerifyChain([]byte("wrong"), files)
+	assert.ErrorIs(t, err, ErrChainBroken)
+}
+
+func TestVerifyChainDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(lines [][]byte) [][]byte
+		wantSeq uint64
+		reason  string
+	}{
+		{
+			name: "edited event",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[2] = bytes.Replace(lines[2], []byte("example.com"), []byte("evil.com"), 1)
+				return lines
+			},
+			wantSeq: 3,
+			reason:  "HMAC mismatch",
+		},
+		{
+			name: "deleted entry",
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:2:2], lines[3:]...)
+			},
+			wantSeq: 3,
+			reason:  "missing entry, found seq 4",
+		},
+		{
+			name: "swapped entries",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			wantSeq: 2,
+			reason:  "missing entry, found seq 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+
+			observer, err := NewFileObserver(path, FileOptions{ChainKey: testChainKey}, zap.NewNop())
+			require.NoError(t, err)
+			for i := 0; i < 5; i++ {
+				require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+			}
+			require.NoError(t, observer.Close())
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			lines := tt.tamper(bytes.Split(bytes.TrimSpace(data), []byte("\n")))
+			require.NoError(t, os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0644))
+
+			_, err = VerifyChain(testChainKey, []string{path})
+
+			var chainErr *ChainError
+			require.ErrorAs(t, err, &chainErr)
+			assert.Equal(t, tt.wantSeq, chainErr.Seq)
+			assert.Equal(t, tt.reason, chainErr.Reason)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// deadLetterSpool хранит недоставленные события на диске в формате JSON Lines.
+// Во время повторной отправки основной файл переименовывается, поэтому новые
+// недоставленные события продолжают записываться, не мешая повторной отправке.
+type deadLetterSpool struct {
+	path string
+	mu   *sync.Mutex
+}
+
+func newDeadLetterSpool(path string) (*deadLetterSpool, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	return &deadLetterSpool{
+		path: path,
+		mu:   &sync.Mutex{},
+	}, nil
+}
+
+// Append дописывает события в конец файла.
+func (s *deadLetterSpool) Append(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return appendEvents(s.path, events)
+}
+
+// TakeForReplay забирает накопленные события для повторной отправки.
+// Если предыдущая повторная отправка была прервана, возвращает ее остаток.
+func (s *deadLetterSpool) TakeForReplay() ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replayPath := s.replayPath()
+	if _, err := os.Stat(replayPath); errors.Is(err, os.ErrNotExist) {
+		if err := os.Rename(s.path, replayPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+	}
+
+	return readEvents(replayPath)
+}
+
+// FinishReplay завершает повторную отправку: неотправленный остаток
+// возвращается в основной файл, временный файл удаляется.
+func (s *deadLetterSpool) FinishReplay(unsent []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := appendEvents(s.path, unsent); err != nil {
+		return err
+	}
+
+	return os.Remove(s.replayPath())
+}
+
+func (s *deadLetterSpool) replayPath() string {
+	return s.path + ".replay"
+}
+
+func appendEvents(path string, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			file.Close()
+
+			return err
+		}
+
+		w.Write(data)
+		w.WriteByte('\n')
+	}
+
+	if err := w.Flush(); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+// readEvents читает события из файла, пропуская поврежденные строки.
+func readEvents(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	return events, scanner.Err()
+}
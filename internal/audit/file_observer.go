@@ -1,32 +1,112 @@
 package audit
 
 import (
+	"bufio"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+const (
+	rotatedTimeLayout = "20060102T150405.000000000"
+	gzipSuffix        = ".gz"
+)
+
+// FileOptions содержит настройки записи событий аудита в файл.
+// Нулевые значения отключают соответствующую возможность или задают значение по умолчанию.
+type FileOptions struct {
+	// MaxSize - размер файла в байтах, при превышении которого выполняется ротация
+	MaxSize int64
+	// RotateInterval - период ротации файла по времени
+	RotateInterval time.Duration
+	// Compress - сжимать ротированные файлы gzip
+	Compress bool
+	// MaxFiles - максимальное число хранимых ротированных файлов
+	MaxFiles int
+	// MaxAge - максимальный возраст хранимых ротированных файлов
+	MaxAge time.Duration
+	// FsyncInterval - период сброса буфера и синхронизации файла с диском
+	FsyncInterval time.Duration
+	// BufferSize - размер буфера записи в байтах
+	BufferSize int
+	// ChainKey - ключ HMAC; если задан, события записываются цепочкой ChainRecord
+	ChainKey []byte
+}
+
+func (o FileOptions) withDefaults() FileOptions {
+	if o.FsyncInterval <= 0 {
+		o.FsyncInterval = time.Second
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = 64 * 1024
+	}
+
+	return o
+}
+
 // FileObserver записывает события аудита в файл.
+// Запись буферизуется, буфер периодически сбрасывается на диск.
+// Файл ротируется по размеру и времени, старые файлы сжимаются и удаляются
+// согласно политике хранения. При заданном ключе записи связываются в цепочку,
+// которая продолжается в новых сегментах после ротации и перезапуска.
+// Если файл не удалось открыть заново, открытие повторяется при следующем событии.
 type FileObserver struct {
-	file   *os.File
-	mu     *sync.Mutex
-	logger *zap.Logger
+	path     string
+	opts     FileOptions
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64
+	openedAt time.Time
+	chain    *chain
+	mu       *sync.Mutex
+	logger   *zap.Logger
+	closed   bool
+	// rotated - ротированные файлы, ожидающие сжатия и политики хранения
+	rotated     []string
+	wake        chan struct{}
+	done        chan struct{}
+	stopped     chan struct{}
+	maintenance chan struct{}
 }
 
 // NewFileObserver создает новый наблюдатель для записи событий в файл.
-func NewFileObserver(filePath string, logger *zap.Logger) (*FileObserver, error) {
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
+func NewFileObserver(filePath string, opts FileOptions, logger *zap.Logger) (*FileObserver, error) {
+	fo := &FileObserver{
+		path:        filePath,
+		opts:        opts.withDefaults(),
+		mu:          &sync.Mutex{},
+		logger:      logger,
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		maintenance: make(chan struct{}),
+	}
+
+	if len(fo.opts.ChainKey) > 0 {
+		fo.chain = newChain(fo.opts.ChainKey)
+		if err := fo.chain.recover(filePath); err != nil {
+			return nil, fmt.Errorf("failed to recover audit chain: %w", err)
+		}
+	}
+
+	if err := fo.open(); err != nil {
 		return nil, err
 	}
 
-	return &FileObserver{
-		file:   file,
-		mu:     &sync.Mutex{},
-		logger: logger,
-	}, nil
+	go fo.flushLoop()
+	go fo.maintenanceLoop()
+
+	return fo, nil
 }
 
 // OnEvent обрабатывает событие аудита и записывает его в файл.
@@ -40,7 +120,45 @@ func (fo *FileObserver) OnEvent(event Event) error {
 	fo.mu.Lock()
 	defer fo.mu.Unlock()
 
-	if _, err := fo.file.Write(append(data, '\n')); err != nil {
+	if fo.closed {
+		return os.ErrClosed
+	}
+
+	if fo.file == nil {
+		if err := fo.open(); err != nil {
+			fo.logger.Error("failed to reopen audit file", zap.Error(err))
+			return err
+		}
+	}
+
+	var chainState chain
+	if fo.chain != nil {
+		chainState = *fo.chain
+		data, err = fo.chain.next(data)
+		if err != nil {
+			fo.logger.Error("failed to chain audit event", zap.Error(err))
+			return err
+		}
+	}
+
+	if fo.shouldRotate(int64(len(data)) + 1) {
+		if err := fo.rotate(); err != nil {
+			fo.logger.Error("failed to rotate audit file", zap.Error(err))
+
+			if fo.file == nil {
+				// Событие не записано: цепочка не должна продвигаться.
+				if fo.chain != nil {
+					*fo.chain = chainState
+				}
+
+				return err
+			}
+		}
+	}
+
+	n, err := fo.writer.Write(append(data, '\n'))
+	fo.size += int64(n)
+	if err != nil {
 		fo.logger.Error("failed to write audit event to file", zap.Error(err))
 		return err
 	}
@@ -48,14 +166,304 @@ func (fo *FileObserver) OnEvent(event Event) error {
 	return nil
 }
 
-// Close закрывает файл аудита.
-func (fo *FileObserver) Close() error {
+// Reopen закрывает и заново открывает файл аудита по исходному пути.
+// Используется при внешней ротации (например, logrotate по сигналу SIGHUP).
+func (fo *FileObserver) Reopen() error {
 	fo.mu.Lock()
 	defer fo.mu.Unlock()
 
+	if fo.closed {
+		return os.ErrClosed
+	}
+
+	if fo.file != nil {
+		if err := fo.closeFile(); err != nil {
+			fo.logger.Error("failed to close audit file before reopen", zap.Error(err))
+		}
+	}
+
+	return fo.open()
+}
+
+// Close сбрасывает буфер на диск, закрывает файл аудита
+// и дожидается сжатия и удаления ротированных файлов.
+func (fo *FileObserver) Close() error {
+	fo.mu.Lock()
+	if fo.closed {
+		fo.mu.Unlock()
+
+		return nil
+	}
+	fo.closed = true
+	close(fo.done)
+	fo.mu.Unlock()
+
+	<-fo.stopped
+
+	var err error
+	fo.mu.Lock()
 	if fo.file != nil {
-		return fo.file.Close()
+		err = fo.closeFile()
+	}
+	fo.mu.Unlock()
+
+	<-fo.maintenance
+
+	return err
+}
+
+func (fo *FileObserver) open() error {
+	if err := os.MkdirAll(filepath.Dir(fo.path), 0755); err != nil {
+		return err
 	}
 
+	file, err := os.OpenFile(fo.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return err
+	}
+
+	fo.file = file
+	fo.writer = bufio.NewWriterSize(file, fo.opts.BufferSize)
+	fo.size = info.Size()
+	fo.openedAt = time.Now()
+
 	return nil
 }
+
+// closeFile сбрасывает буфер, синхронизирует и закрывает файл.
+// Вызывается под мьютексом.
+func (fo *FileObserver) closeFile() error {
+	flushErr := fo.writer.Flush()
+	syncErr := fo.file.Sync()
+	closeErr := fo.file.Close()
+	fo.file = nil
+	fo.writer = nil
+
+	return errors.Join(flushErr, syncErr, closeErr)
+}
+
+func (fo *FileObserver) shouldRotate(nextWrite int64) bool {
+	if fo.opts.MaxSize > 0 && fo.size > 0 && fo.size+nextWrite > fo.opts.MaxSize {
+		return true
+	}
+
+	return fo.opts.RotateInterval > 0 && time.Since(fo.openedAt) >= fo.opts.RotateInterval
+}
+
+// rotate переименовывает текущий файл и открывает новый.
+// Сжатие и удаление старых файлов выполняются в фоне (см. maintenanceLoop).
+// Если новый файл открыть не удалось, он открывается при следующем событии.
+// Вызывается под мьютексом.
+func (fo *FileObserver) rotate() error {
+	if err := fo.closeFile(); err != nil {
+		fo.logger.Error("failed to close audit file before rotation", zap.Error(err))
+	}
+
+	rotated := fo.rotatedName(time.Now())
+	renameErr := os.Rename(fo.path, rotated)
+
+	if err := fo.open(); err != nil {
+		return errors.Join(renameErr, err)
+	}
+
+	if renameErr != nil {
+		return renameErr
+	}
+
+	fo.rotated = append(fo.rotated, rotated)
+	select {
+	case fo.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// maintenanceLoop сжимает ротированные файлы и применяет политику хранения.
+// Один фоновый обработчик не дает политике хранения увидеть файл,
+// который еще сжимается. При закрытии обрабатывает оставшиеся файлы.
+func (fo *FileObserver) maintenanceLoop() {
+	defer close(fo.maintenance)
+
+	for {
+		select {
+		case <-fo.wake:
+			fo.maintain()
+		case <-fo.done:
+			fo.maintain()
+
+			return
+		}
+	}
+}
+
+// maintain обрабатывает накопленные ротированные файлы.
+func (fo *FileObserver) maintain() {
+	fo.mu.Lock()
+	rotated := fo.rotated
+	fo.rotated = nil
+	fo.mu.Unlock()
+
+	if len(rotated) == 0 {
+		return
+	}
+
+	if fo.opts.Compress {
+		for _, path := range rotated {
+			if err := compressFile(path); err != nil {
+				fo.logger.Error("failed to compress rotated audit file",
+					zap.String("file", path),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	fo.applyRetention()
+}
+
+func (fo *FileObserver) rotatedName(now time.Time) string {
+	name := fo.path + "." + now.UTC().Format(rotatedTimeLayout)
+	candidate := name
+
+	for i := 1; ; i++ {
+		_, errPlain := os.Stat(candidate)
+		_, errGzip := os.Stat(candidate + gzipSuffix)
+		if errors.Is(errPlain, os.ErrNotExist) && errors.Is(errGzip, os.ErrNotExist) {
+			return candidate
+		}
+
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+// applyRetention удаляет ротированные файлы сверх MaxFiles и старше MaxAge.
+func (fo *FileObserver) applyRetention() {
+	if fo.opts.MaxFiles <= 0 && fo.opts.MaxAge <= 0 {
+		return
+	}
+
+	segments, err := RotatedSegments(fo.path)
+	if err != nil {
+		fo.logger.Error("failed to list rotated audit files", zap.Error(err))
+
+		return
+	}
+
+	cutoff := time.Now().Add(-fo.opts.MaxAge)
+	keep := len(segments)
+	if fo.opts.MaxFiles > 0 && keep > fo.opts.MaxFiles {
+		keep = fo.opts.MaxFiles
+	}
+
+	// Сегменты отсортированы от старых к новым.
+	for i, segment := range segments {
+		expired := false
+		if fo.opts.MaxAge > 0 {
+			if info, err := os.Stat(segment); err == nil && info.ModTime().Before(cutoff) {
+				expired = true
+			}
+		}
+
+		if i < len(segments)-keep || expired {
+			if err := os.Remove(segment); err != nil && !errors.Is(err, os.ErrNotExist) {
+				fo.logger.Error("failed to remove old audit file",
+					zap.String("file", segment),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+func (fo *FileObserver) flushLoop() {
+	defer close(fo.stopped)
+
+	ticker := time.NewTicker(fo.opts.FsyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			fo.mu.Lock()
+			if fo.file != nil {
+				if err := fo.writer.Flush(); err != nil {
+					fo.logger.Error("failed to flush audit file", zap.Error(err))
+				} else if err := fo.file.Sync(); err != nil {
+					fo.logger.Error("failed to sync audit file", zap.Error(err))
+				}
+			}
+			fo.mu.Unlock()
+		case <-fo.done:
+			return
+		}
+	}
+}
+
+// RotatedSegments возвращает ротированные файлы аудита для основного файла path,
+// отсортированные от старых к новым.
+func RotatedSegments(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(path) + "."
+	segments := make([]string, 0, len(matches))
+	for _, match := range matches {
+		suffix := strings.TrimPrefix(filepath.Base(match), prefix)
+		suffix = strings.TrimSuffix(suffix, gzipSuffix)
+		if len(suffix) < len(rotatedTimeLayout) {
+			continue
+		}
+		if _, err := time.Parse(rotatedTimeLayout, suffix[:len(rotatedTimeLayout)]); err != nil {
+			continue
+		}
+
+		segments = append(segments, match)
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return strings.TrimSuffix(segments[i], gzipSuffix) < strings.TrimSuffix(segments[j], gzipSuffix)
+	})
+
+	return segments, nil
+}
+
+// compressFile сжимает файл gzip и удаляет исходный файл.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+gzipSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		dst.Close()
+		os.Remove(path + gzipSuffix)
+
+		return err
+	}
+
+	if err := errors.Join(zw.Close(), dst.Sync(), dst.Close()); err != nil {
+		os.Remove(path + gzipSuffix)
+
+		return err
+	}
+
+	return os.Remove(path)
+}
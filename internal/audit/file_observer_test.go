@@ -0,0 +1,219 @@
+package audit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// readFileEvents читает события из файла аудита, при необходимости распаковывая gzip.
+func readFileEvents(t *testing.T, path string) []Event {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, gzipSuffix) {
+		zr, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		defer zr.Close()
+
+		reader = zr
+	}
+
+	events := make([]Event, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.NoError(t, scanner.Err())
+
+	return events
+}
+
+func TestFileObserverBuffersUntilFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	observer, err := NewFileObserver(path, FileOptions{FsyncInterval: time.Hour}, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	require.NoError(t, observer.Close())
+
+	assert.Len(t, readFileEvents(t, path), 1)
+	assert.ErrorIs(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)), os.ErrClosed)
+}
+
+func TestFileObserverRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     FileOptions
+		events   int
+		wantGzip bool
+		maxFiles int
+	}{
+		{
+			name:   "rotate by size",
+			opts:   FileOptions{MaxSize: 200},
+			events: 10,
+		},
+		{
+			name:     "rotate by size with compression",
+			opts:     FileOptions{MaxSize: 200, Compress: true},
+			events:   10,
+			wantGzip: true,
+		},
+		{
+			name:     "retention keeps max files",
+			opts:     FileOptions{MaxSize: 200, MaxFiles: 2},
+			events:   20,
+			maxFiles: 2,
+		},
+		{
+			name:     "retention counts compressed files once",
+			opts:     FileOptions{MaxSize: 200, Compress: true, MaxFiles: 2},
+			events:   20,
+			wantGzip: true,
+			maxFiles: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+
+			observer, err := NewFileObserver(path, tt.opts, zap.NewNop())
+			require.NoError(t, err)
+
+			for i := 0; i < tt.events; i++ {
+				require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+			}
+			require.NoError(t, observer.Close())
+
+			segments, err := RotatedSegments(path)
+			require.NoError(t, err)
+			require.NotEmpty(t, segments)
+
+			if tt.maxFiles > 0 {
+				assert.Len(t, segments, tt.maxFiles)
+			}
+
+			total := len(readFileEvents(t, path))
+			for _, segment := range segments {
+				assert.Equal(t, tt.wantGzip, strings.HasSuffix(segment, gzipSuffix), segment)
+				total += len(readFileEvents(t, segment))
+			}
+
+			if tt.maxFiles == 0 {
+				assert.Equal(t, tt.events, total)
+			}
+		})
+	}
+}
+
+func TestFileObserverRotateByInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	observer, err := NewFileObserver(path, FileOptions{RotateInterval: 10 * time.Millisecond}, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, observer.OnEvent(NewEvent(ActionFollow, "https://example.com", nil)))
+	require.NoError(t, observer.Close())
+
+	segments, err := RotatedSegments(path)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	assert.Equal(t, ActionShorten, readFileEvents(t, segments[0])[0].Action)
+	assert.Equal(t, ActionFollow, readFileEvents(t, path)[0].Action)
+}
+
+func TestFileObserverReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	moved := filepath.Join(dir, "audit.log.1")
+
+	observer, err := NewFileObserver(path, FileOptions{}, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+
+	// Внешняя ротация: файл переименован, затем наблюдатель получает сигнал.
+	require.NoError(t, os.Rename(path, moved))
+	require.NoError(t, observer.Reopen())
+
+	require.NoError(t, observer.OnEvent(NewEvent(ActionFollow, "https://example.com", nil)))
+	require.NoError(t, observer.Close())
+
+	assert.Equal(t, ActionShorten, readFileEvents(t, moved)[0].Action)
+	assert.Equal(t, ActionFollow, readFileEvents(t, path)[0].Action)
+}
+
+func TestFileObserverRecoversAfterFailedReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+
+	observer, err := NewFileObserver(path, FileOptions{}, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+
+	// Путь файла временно занят каталогом: открыть файл заново не удается.
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, os.Mkdir(path, 0755))
+	assert.Error(t, observer.Reopen())
+	assert.Error(t, observer.OnEvent(NewEvent(ActionFollow, "https://example.com", nil)))
+
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, observer.OnEvent(NewEvent(ActionDelete, "https://example.com", nil)),
+		"file is reopened on the next event")
+	require.NoError(t, observer.Close())
+
+	events := readFileEvents(t, path)
+	require.Len(t, events, 1)
+	assert.Equal(t, ActionDelete, events[0].Action)
+}
+
+func TestFileObserverCloseWithoutFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+
+	observer, err := NewFileObserver(path, FileOptions{}, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.Mkdir(path, 0755))
+	require.Error(t, observer.Reopen())
+
+	closed := make(chan error)
+	go func() { closed <- observer.Close() }()
+
+	select {
+	case err := <-closed:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Close did not stop background goroutines")
+	}
+	assert.ErrorIs(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)), os.ErrClosed)
+}
@@ -0,0 +1,291 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// fileIndexEntry - положение события в файле и поля, по которым выполняется поиск.
+type fileIndexEntry struct {
+	offset    int64
+	length    int
+	timestamp int64
+	action    Action
+	userID    *string
+}
+
+// FileStore хранит события аудита в файле, открытом только на дописывание,
+// и поддерживает поиск по индексу в памяти.
+// Идентификатор события - его порядковый номер в файле; индекс строится при открытии.
+type FileStore struct {
+	file     *os.File
+	size     int64
+	broken   error
+	entries  []fileIndexEntry
+	byAction map[Action][]int
+	byUser   map[string][]int
+	mu       *sync.RWMutex
+	logger   *zap.Logger
+}
+
+// NewFileStore открывает файловое хранилище событий аудита и строит индекс.
+// Неполная последняя строка, оставшаяся после сбоя, отбрасывается.
+func NewFileStore(path string, logger *zap.Logger) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := &FileStore{
+		file:     file,
+		byAction: make(map[Action][]int),
+		byUser:   make(map[string][]int),
+		mu:       &sync.RWMutex{},
+		logger:   logger,
+	}
+
+	if err := fs.buildIndex(); err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+// OnEvent дописывает событие в файл и добавляет его в индекс.
+func (fs *FileStore) OnEvent(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		fs.logger.Error("failed to marshal audit event", zap.Error(err))
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.file == nil {
+		return os.ErrClosed
+	}
+
+	if fs.broken != nil {
+		return fs.broken
+	}
+
+	if _, err := fs.file.Write(append(data, '\n')); err != nil {
+		fs.logger.Error("failed to write audit event to store", zap.Error(err))
+		fs.discardPartialWrite()
+
+		return err
+	}
+
+	fs.index(event, fs.size, len(data))
+	fs.size += int64(len(data)) + 1
+
+	return nil
+}
+
+// discardPartialWrite отрезает часть события, записанную до ошибки,
+// чтобы смещения следующих событий в индексе оставались верными.
+// Если обрезать файл не удалось, хранилище перестает принимать события.
+// Вызывается под мьютексом.
+func (fs *FileStore) discardPartialWrite() {
+	info, err := fs.file.Stat()
+	if err == nil && info.Size() == fs.size {
+		return
+	}
+
+	if err == nil {
+		err = fs.file.Truncate(fs.size)
+	}
+
+	if err != nil {
+		fs.logger.Error("failed to discard partial audit event, store is read-only", zap.Error(err))
+		fs.broken = fmt.Errorf("audit store is read-only after failed write: %w", err)
+	}
+}
+
+// Query ищет события по условиям, начиная с самых новых.
+func (fs *FileStore) Query(_ context.Context, query Query) (Page, error) {
+	before, err := query.normalize()
+	if err != nil {
+		return Page{}, err
+	}
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	if fs.file == nil {
+		return Page{}, os.ErrClosed
+	}
+
+	candidates := fs.candidates(query)
+
+	// Позиции в списках кандидатов возрастают; идентификатор равен позиции + 1.
+	start := len(candidates)
+	if before > 0 {
+		start = sort.SearchInts(candidates, int(before-1))
+	}
+
+	page := Page{Events: make([]StoredEvent, 0, query.Limit)}
+	for i := start - 1; i >= 0; i-- {
+		pos := candidates[i]
+		if !fs.entryMatches(fs.entries[pos], query) {
+			continue
+		}
+
+		event, err := fs.read(fs.entries[pos])
+		if err != nil {
+			return Page{}, err
+		}
+		if !query.matches(event) {
+			continue
+		}
+
+		if len(page.Events) == query.Limit {
+			page.NextCursor = encodeCursor(page.Events[len(page.Events)-1].ID)
+
+			break
+		}
+
+		page.Events = append(page.Events, StoredEvent{ID: int64(pos) + 1, Event: event})
+	}
+
+	return page, nil
+}
+
+// Close закрывает файл хранилища.
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.file == nil {
+		return nil
+	}
+
+	err := errors.Join(fs.file.Sync(), fs.file.Close())
+	fs.file = nil
+
+	return err
+}
+
+// candidates возвращает позиции событий, которые могут удовлетворять запросу,
+// используя самый узкий из доступных индексов.
+func (fs *FileStore) candidates(query Query) []int {
+	var lists [][]int
+	if query.Action != "" {
+		lists = append(lists, fs.byAction[query.Action])
+	}
+	if query.UserID != "" {
+		lists = append(lists, fs.byUser[query.UserID])
+	}
+
+	if len(lists) == 0 {
+		all := make([]int, len(fs.entries))
+		for i := range all {
+			all[i] = i
+		}
+
+		return all
+	}
+
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+
+	return lists[0]
+}
+
+// entryMatches проверяет условия, которые можно проверить по индексу без чтения файла.
+func (fs *FileStore) entryMatches(entry fileIndexEntry, query Query) bool {
+	if query.Action != "" && entry.action != query.Action {
+		return false
+	}
+	if query.UserID != "" && (entry.userID == nil || *entry.userID != query.UserID) {
+		return false
+	}
+	if !query.From.IsZero() && entry.timestamp < query.From.Unix() {
+		return false
+	}
+
+	return query.To.IsZero() || entry.timestamp < query.To.Unix()
+}
+
+func (fs *FileStore) read(entry fileIndexEntry) (Event, error) {
+	buf := make([]byte, entry.length)
+	if _, err := fs.file.ReadAt(buf, entry.offset); err != nil {
+		return Event{}, err
+	}
+
+	var event Event
+	if err := json.Unmarshal(buf, &event); err != nil {
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+func (fs *FileStore) index(event Event, offset int64, length int) {
+	pos := len(fs.entries)
+
+	fs.entries = append(fs.entries, fileIndexEntry{
+		offset:    offset,
+		length:    length,
+		timestamp: event.Timestamp,
+		action:    event.Action,
+		userID:    event.UserID,
+	})
+
+	fs.byAction[event.Action] = append(fs.byAction[event.Action], pos)
+	if event.UserID != nil {
+		fs.byUser[*event.UserID] = append(fs.byUser[*event.UserID], pos)
+	}
+}
+
+// buildIndex читает файл и строит индекс. Вызывается при открытии хранилища.
+func (fs *FileStore) buildIndex() error {
+	reader := bufio.NewReader(io.NewSectionReader(fs.file, 0, 1<<62))
+
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				fs.logger.Warn("discarding incomplete audit store record", zap.Int64("offset", offset))
+
+				if err := fs.file.Truncate(offset); err != nil {
+					return err
+				}
+			}
+
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal(line[:len(line)-1], &event); err != nil {
+			return err
+		}
+
+		fs.index(event, offset, len(line)-1)
+		offset += int64(len(line))
+	}
+
+	fs.size = offset
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFileStoreQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit-store.log")
+	alice := "alice"
+	bob := "bob"
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store, err := NewFileStore(path, zap.NewNop())
+	require.NoError(t, err)
+
+	events := []Event{
+		{Timestamp: base.Unix(), Action: ActionShorten, UserID: &alice, URL: "https://example.com/Report"},
+		{Timestamp: base.Add(time.Hour).Unix(), Action: ActionFollow, URL: "https://example.com/report"},
+		{Timestamp: base.Add(2 * time.Hour).Unix(), Action: ActionShorten, UserID: &bob, URL: "https://go.dev"},
+		{Timestamp: base.Add(3 * time.Hour).Unix(), Action: ActionDelete, UserID: &alice, URL: "https://example.com/report"},
+	}
+	for _, event := range events {
+		require.NoError(t, store.OnEvent(event))
+	}
+	require.NoError(t, store.Close())
+
+	// Индекс восстанавливается при повторном открытии.
+	store, err = NewFileStore(path, zap.NewNop())
+	require.NoError(t, err)
+	defer store.Close()
+
+	tests := []struct {
+		name    string
+		query   Query
+		wantIDs []int64
+	}{
+		{
+			name:    "all events newest first",
+			query:   Query{},
+			wantIDs: []int64{4, 3, 2, 1},
+		},
+		{
+			name:    "by action",
+			query:   Query{Action: ActionShorten},
+			wantIDs: []int64{3, 1},
+		},
+		{
+			name:    "by user",
+			query:   Query{UserID: alice},
+			wantIDs: []int64{4, 1},
+		},
+		{
+			name:    "by action and user",
+			query:   Query{Action: ActionShorten, UserID: alice},
+			wantIDs: []int64{1},
+		},
+		{
+			name:    "by URL substring ignoring case",
+			query:   Query{URLContains: "REPORT"},
+			wantIDs: []int64{4, 2, 1},
+		},
+		{
+			name:    "by time range",
+			query:   Query{From: base.Add(time.Hour), To: base.Add(3 * time.Hour)},
+			wantIDs: []int64{3, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := store.Query(context.Background(), tt.query)
+			require.NoError(t, err)
+
+			ids := make([]int64, 0, len(page.Events))
+			for _, event := range page.Events {
+				ids = append(ids, event.ID)
+			}
+
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func TestFileStorePagination(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "audit-store.log"), zap.NewNop())
+	require.NoError(t, err)
+	defer store.Close()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+	}
+
+	var (
+		ids    []int64
+		cursor string
+	)
+	for {
+		page, err := store.Query(context.Background(), Query{Limit: 2, Cursor: cursor})
+		require.NoError(t, err)
+
+		for _, event := range page.Events {
+			ids = append(ids, event.ID)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	assert.Equal(t, []int64{5, 4, 3, 2, 1}, ids)
+
+	_, err = store.Query(context.Background(), Query{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestFileStoreDiscardsIncompleteRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit-store.log")
+	require.NoError(t, os.WriteFile(path, []byte(`{"action":"shorten","ts":1}`+"\n"+`{"action":"fol`), 0644))
+
+	store, err := NewFileStore(path, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, store.OnEvent(NewEvent(ActionFollow, "https://example.com", nil)))
+
+	page, err := store.Query(context.Background(), Query{})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 2)
+	assert.Equal(t, ActionFollow, page.Events[0].Action)
+	require.NoError(t, store.Close())
+
+	store, err = NewFileStore(path, zap.NewNop())
+	require.NoError(t, err)
+	defer store.Close()
+
+	page, err = store.Query(context.Background(), Query{})
+	require.NoError(t, err)
+	assert.Len(t, page.Events, 2)
+}
+
+func TestFileStoreDiscardsPartialWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit-store.log")
+
+	store, err := NewFileStore(path, zap.NewNop())
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+
+	// Запись прервалась на середине события (например, закончилось место на диске).
+	_, err = store.file.Write([]byte(`{"action":"fol`))
+	require.NoError(t, err)
+	store.mu.Lock()
+	store.discardPartialWrite()
+	store.mu.Unlock()
+
+	require.NoError(t, store.OnEvent(NewEvent(ActionFollow, "https://example.com", nil)))
+
+	page, err := store.Query(context.Background(), Query{})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 2)
+	assert.Equal(t, ActionFollow, page.Events[0].Action)
+	assert.Equal(t, ActionShorten, page.Events[1].Action)
+}
@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidFilter возвращается при разборе некорректного описания фильтра.
+var ErrInvalidFilter = errors.New("invalid audit filter")
+
+// Filter определяет, какие события получает наблюдатель.
+// Пустой фильтр пропускает все события.
+type Filter struct {
+	// Actions - допустимые действия; если пуст, допускаются все действия
+	Actions []Action
+	// SampleRates - доля событий действия, передаваемых наблюдателю, от 0 до 1
+	SampleRates map[Action]float64
+	// IncludeUsers - если не пуст, передаются только события этих пользователей
+	IncludeUsers []string
+	// ExcludeUsers - пользователи, события которых не передаются
+	ExcludeUsers []string
+}
+
+// ParseFilter разбирает описание фильтра вида
+//
+//	actions=shorten,follow;sample=follow:0.1;include_users=u1,u2;exclude_users=u3
+//
+// Пустая строка соответствует пустому фильтру.
+func ParseFilter(spec string) (Filter, error) {
+	var filter Filter
+
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Filter{}, fmt.Errorf("%w: %q: expected key=value", ErrInvalidFilter, part)
+		}
+
+		values := splitList(value)
+
+		switch strings.TrimSpace(key) {
+		case "actions":
+			for _, v := range values {
+				filter.Actions = append(filter.Actions, Action(v))
+			}
+		case "sample":
+			if filter.SampleRates == nil {
+				filter.SampleRates = make(map[Action]float64, len(values))
+			}
+
+			for _, v := range values {
+				action, rateStr, ok := strings.Cut(v, ":")
+				if !ok {
+					return Filter{}, fmt.Errorf("%w: %q: expected action:rate", ErrInvalidFilter, v)
+				}
+
+				rate, err := strconv.ParseFloat(rateStr, 64)
+				if err != nil || rate < 0 || rate > 1 {
+					return Filter{}, fmt.Errorf("%w: %q: rate must be between 0 and 1", ErrInvalidFilter, v)
+				}
+
+				filter.SampleRates[Action(action)] = rate
+			}
+		case "include_users":
+			filter.IncludeUsers = append(filter.IncludeUsers, values...)
+		case "exclude_users":
+			filter.ExcludeUsers = append(filter.ExcludeUsers, values...)
+		default:
+			return Filter{}, fmt.Errorf("%w: unknown key %q", ErrInvalidFilter, key)
+		}
+	}
+
+	return filter, nil
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// eventFilter - подготовленный к проверке фильтр.
+type eventFilter struct {
+	actions      map[Action]struct{}
+	sampleRates  map[Action]float64
+	includeUsers map[string]struct{}
+	excludeUsers map[string]struct{}
+	random       func() float64
+}
+
+func newEventFilter(filter Filter) *eventFilter {
+	return &eventFilter{
+		actions:      toSet(filter.Actions),
+		sampleRates:  filter.SampleRates,
+		includeUsers: toSet(filter.IncludeUsers),
+		excludeUsers: toSet(filter.ExcludeUsers),
+		random:       rand.Float64,
+	}
+}
+
+// allow проверяет, должно ли событие быть передано наблюдателю.
+func (f *eventFilter) allow(event Event) bool {
+	if len(f.actions) > 0 {
+		if _, ok := f.actions[event.Action]; !ok {
+			return false
+		}
+	}
+
+	if len(f.includeUsers) > 0 {
+		if event.UserID == nil {
+			return false
+		}
+		if _, ok := f.includeUsers[*event.UserID]; !ok {
+			return false
+		}
+	}
+
+	if event.UserID != nil {
+		if _, ok := f.excludeUsers[*event.UserID]; ok {
+			return false
+		}
+	}
+
+	if rate, ok := f.sampleRates[event.Action]; ok {
+		return rate >= 1 || f.random() < rate
+	}
+
+	return true
+}
+
+func toSet[T comparable](items []T) map[T]struct{} {
+	set := make(map[T]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+
+	return set
+}
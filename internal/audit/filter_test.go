@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Filter
+		wantErr bool
+	}{
+		{
+			name: "empty spec",
+			spec: "",
+			want: Filter{},
+		},
+		{
+			name: "all keys",
+			spec: "actions=shorten, follow; sample=follow:0.1;include_users=u1,u2;exclude_users=u3",
+			want: Filter{
+				Actions:      []Action{ActionShorten, ActionFollow},
+				SampleRates:  map[Action]float64{ActionFollow: 0.1},
+				IncludeUsers: []string{"u1", "u2"},
+				ExcludeUsers: []string{"u3"},
+			},
+		},
+		{
+			name:    "unknown key",
+			spec:    "levels=info",
+			wantErr: true,
+		},
+		{
+			name:    "missing value separator",
+			spec:    "actions",
+			wantErr: true,
+		},
+		{
+			name:    "rate out of range",
+			spec:    "sample=follow:2",
+			wantErr: true,
+		},
+		{
+			name:    "rate without action",
+			spec:    "sample=0.5",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.spec)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidFilter)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEventFilterAllow(t *testing.T) {
+	alice := "alice"
+	bob := "bob"
+
+	tests := []struct {
+		name   string
+		filter Filter
+		event  Event
+		want   bool
+	}{
+		{
+			name:  "empty filter allows everything",
+			event: NewEvent(ActionFollow, "https://example.com", nil),
+			want:  true,
+		},
+		{
+			name:   "action not in allow-list",
+			filter: Filter{Actions: []Action{ActionShorten}},
+			event:  NewEvent(ActionFollow, "https://example.com", nil),
+			want:   false,
+		},
+		{
+			name:   "action in allow-list",
+			filter: Filter{Actions: []Action{ActionShorten}},
+			event:  NewEvent(ActionShorten, "https://example.com", nil),
+			want:   true,
+		},
+		{
+			name:   "included user",
+			filter: Filter{IncludeUsers: []string{alice}},
+			event:  NewEvent(ActionShorten, "https://example.com", &alice),
+			want:   true,
+		},
+		{
+			name:   "anonymous event with include list",
+			filter: Filter{IncludeUsers: []string{alice}},
+			event:  NewEvent(ActionShorten, "https://example.com", nil),
+			want:   false,
+		},
+		{
+			name:   "excluded user",
+			filter: Filter{ExcludeUsers: []string{bob}},
+			event:  NewEvent(ActionShorten, "https://example.com", &bob),
+			want:   false,
+		},
+		{
+			name:   "sampled out",
+			filter: Filter{SampleRates: map[Action]float64{ActionFollow: 0.1}},
+			event:  NewEvent(ActionFollow, "https://example.com", nil),
+			want:   false,
+		},
+		{
+			name:   "sampled in",
+			filter: Filter{SampleRates: map[Action]float64{ActionFollow: 0.9}},
+			event:  NewEvent(ActionFollow, "https://example.com", nil),
+			want:   true,
+		},
+		{
+			name:   "sampling applies only to its action",
+			filter: Filter{SampleRates: map[Action]float64{ActionFollow: 0}},
+			event:  NewEvent(ActionShorten, "https://example.com", nil),
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := newEventFilter(tt.filter)
+			filter.random = func() float64 { return 0.5 }
+
+			assert.Equal(t, tt.want, filter.allow(tt.event))
+		})
+	}
+}
@@ -2,64 +2,477 @@ package audit
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// ErrQueueFull - очередь наблюдателя переполнена и событие не может быть принято.
+var ErrQueueFull = errors.New("audit queue is full")
+
+// errPermanent - удаленный сервер отклонил пакет, и повторная отправка не поможет.
+var errPermanent = errors.New("permanent delivery failure")
+
+// HTTPOptions содержит настройки доставки событий на удаленный HTTP-сервер.
+// Нулевые значения заменяются значениями по умолчанию.
+type HTTPOptions struct {
+	// QueueSize - размер очереди событий, ожидающих отправки
+	QueueSize int
+	// BatchSize - максимальное число событий в одном запросе
+	BatchSize int
+	// FlushInterval - максимальная задержка отправки неполного пакета
+	FlushInterval time.Duration
+	// RequestTimeout - таймаут одного HTTP-запроса
+	RequestTimeout time.Duration
+	// MaxRetries - число повторных попыток отправки пакета
+	MaxRetries int
+	// InitialBackoff - задержка перед первой повторной попыткой
+	InitialBackoff time.Duration
+	// MaxBackoff - максимальная задержка между попытками
+	MaxBackoff time.Duration
+	// BreakerThreshold - число подряд неудачных отправок, после которого отправка приостанавливается
+	BreakerThreshold int
+	// BreakerCooldown - время, на которое приостанавливается отправка
+	BreakerCooldown time.Duration
+	// DeadLetterPath - файл для недоставленных событий; если пуст, такие события теряются
+	DeadLetterPath string
+	// CloseTimeout - время на отправку оставшихся событий при закрытии
+	CloseTimeout time.Duration
+}
+
+// DefaultHTTPOptions возвращает настройки доставки по умолчанию.
+func DefaultHTTPOptions() HTTPOptions {
+	return HTTPOptions{
+		QueueSize:        DefaultQueueSize,
+		BatchSize:        100,
+		FlushInterval:    time.Second,
+		RequestTimeout:   10 * time.Second,
+		MaxRetries:       3,
+		InitialBackoff:   200 * time.Millisecond,
+		MaxBackoff:       5 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+		CloseTimeout:     5 * time.Second,
+	}
+}
+
+func (o HTTPOptions) withDefaults() HTTPOptions {
+	d := DefaultHTTPOptions()
+
+	if o.QueueSize <= 0 {
+		o.QueueSize = d.QueueSize
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = d.BatchSize
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = d.FlushInterval
+	}
+	if o.RequestTimeout <= 0 {
+		o.RequestTimeout = d.RequestTimeout
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = d.MaxRetries
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = d.InitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = d.MaxBackoff
+	}
+	if o.BreakerThreshold <= 0 {
+		o.BreakerThreshold = d.BreakerThreshold
+	}
+	if o.BreakerCooldown <= 0 {
+		o.BreakerCooldown = d.BreakerCooldown
+	}
+	if o.CloseTimeout <= 0 {
+		o.CloseTimeout = d.CloseTimeout
+	}
+
+	return o
+}
+
 // HTTPObserver отправляет события аудита на удаленный HTTP-сервер.
+// События накапливаются в ограниченной очереди и отправляются пакетами
+// в виде JSON-массива. Неудачные отправки повторяются с экспоненциальной
+// задержкой, а при недоступности сервера события сохраняются в файл
+// недоставленных событий и отправляются повторно после восстановления сервера.
 type HTTPObserver struct {
-	url    string
-	client *http.Client
-	logger *zap.Logger
+	url       string
+	opts      HTTPOptions
+	client    *http.Client
+	logger    *zap.Logger
+	events    chan Event
+	spool     *deadLetterSpool
+	breaker   *circuitBreaker
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+	stopped   chan struct{}
+	closed    atomic.Bool
+	closeOnce *sync.Once
 }
 
-// NewHTTPObserver создает новый HTTP-наблюдатель для отправки событий.
-func NewHTTPObserver(url string, logger *zap.Logger) *HTTPObserver {
-	return &HTTPObserver{
-		url: url,
+// NewHTTPObserver создает новый HTTP-наблюдатель и запускает фоновую отправку событий.
+func NewHTTPObserver(url string, opts HTTPOptions, logger *zap.Logger) (*HTTPObserver, error) {
+	opts = opts.withDefaults()
+
+	var spool *deadLetterSpool
+	if opts.DeadLetterPath != "" {
+		var err error
+		spool, err = newDeadLetterSpool(opts.DeadLetterPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare dead letter spool: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ho := &HTTPObserver{
+		url:  url,
+		opts: opts,
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: opts.RequestTimeout,
 		},
-		logger: logger,
+		logger:    logger,
+		events:    make(chan Event, opts.QueueSize),
+		spool:     spool,
+		breaker:   newCircuitBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		closeOnce: &sync.Once{},
 	}
+
+	go ho.run()
+
+	return ho, nil
 }
 
-// OnEvent обрабатывает событие аудита и отправляет его на удаленный сервер.
+// OnEvent ставит событие в очередь на отправку без блокировки.
+// Если очередь переполнена, событие сохраняется в файл недоставленных событий.
 func (ho *HTTPObserver) OnEvent(event Event) error {
-	data, err := json.Marshal(event)
-	if err != nil {
-		ho.logger.Error("failed to marshal audit event", zap.Error(err))
+	if ho.closed.Load() {
+		return ho.spoolEvents([]Event{event}, "observer closed")
+	}
 
-		return err
+	select {
+	case ho.events <- event:
+		return nil
+	default:
+		return ho.spoolEvents([]Event{event}, "queue is full")
 	}
+}
 
-	resp, err := ho.client.Post(ho.url, "application/json", bytes.NewReader(data))
-	if err != nil {
-		ho.logger.Error("failed to send audit event to remote server",
+// Close отправляет оставшиеся события в пределах CloseTimeout.
+// События, которые не удалось отправить, сохраняются в файл недоставленных событий.
+func (ho *HTTPObserver) Close() error {
+	ho.closeOnce.Do(func() {
+		ho.closed.Store(true)
+		close(ho.done)
+
+		timer := time.NewTimer(ho.opts.CloseTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-ho.stopped:
+		case <-timer.C:
+			ho.logger.Warn("audit HTTP observer close timeout exceeded, spooling remaining events",
+				zap.String("url", ho.url),
+			)
+			ho.cancel()
+			<-ho.stopped
+		}
+
+		ho.cancel()
+		ho.client.CloseIdleConnections()
+	})
+
+	return nil
+}
+
+func (ho *HTTPObserver) run() {
+	defer close(ho.stopped)
+
+	ticker := time.NewTicker(ho.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, ho.opts.BatchSize)
+
+	for {
+		select {
+		case event := <-ho.events:
+			batch = append(batch, event)
+			if len(batch) >= ho.opts.BatchSize {
+				ho.deliver(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				ho.deliver(batch)
+				batch = batch[:0]
+			}
+
+			ho.replayDeadLetters()
+		case <-ho.done:
+			ho.drain(batch)
+
+			return
+		}
+	}
+}
+
+// drain отправляет все события, оставшиеся в очереди при закрытии.
+func (ho *HTTPObserver) drain(batch []Event) {
+	for {
+		select {
+		case event := <-ho.events:
+			batch = append(batch, event)
+			if len(batch) >= ho.opts.BatchSize {
+				ho.deliver(batch)
+				batch = batch[:0]
+			}
+		default:
+			if len(batch) > 0 {
+				ho.deliver(batch)
+			}
+
+			return
+		}
+	}
+}
+
+// deliver отправляет пакет с повторными попытками.
+// При неудаче пакет сохраняется в файл недоставленных событий.
+func (ho *HTTPObserver) deliver(batch []Event) {
+	if ho.ctx.Err() != nil {
+		ho.spoolEvents(batch, "observer is shutting down")
+
+		return
+	}
+
+	if !ho.breaker.Allow(time.Now()) {
+		ho.spoolEvents(batch, "circuit breaker is open")
+
+		return
+	}
+
+	err := ho.sendWithRetry(batch)
+	if err == nil {
+		ho.breaker.Success()
+
+		return
+	}
+
+	if errors.Is(err, errPermanent) {
+		ho.logger.Error("remote audit server rejected events, dropping batch",
 			zap.String("url", ho.url),
+			zap.Int("events", len(batch)),
 			zap.Error(err),
 		)
 
+		return
+	}
+
+	if ho.breaker.Failure(time.Now()) {
+		ho.logger.Warn("audit HTTP circuit breaker opened",
+			zap.String("url", ho.url),
+			zap.Duration("cooldown", ho.opts.BreakerCooldown),
+		)
+	}
+
+	ho.spoolEvents(batch, err.Error())
+}
+
+// replayDeadLetters повторно отправляет недоставленные события, если сервер доступен.
+func (ho *HTTPObserver) replayDeadLetters() {
+	if ho.spool == nil || !ho.breaker.Allow(time.Now()) {
+		return
+	}
+
+	events, err := ho.spool.TakeForReplay()
+	if err != nil {
+		ho.logger.Error("failed to read audit dead letter spool", zap.Error(err))
+
+		return
+	}
+
+	if len(events) == 0 {
+		return
+	}
+
+	sent := 0
+	for sent < len(events) {
+		end := min(sent+ho.opts.BatchSize, len(events))
+		if err := ho.send(events[sent:end]); err != nil {
+			if errors.Is(err, errPermanent) {
+				ho.logger.Error("remote audit server rejected replayed events, dropping batch",
+					zap.String("url", ho.url),
+					zap.Int("events", end-sent),
+					zap.Error(err),
+				)
+				sent = end
+
+				continue
+			}
+
+			ho.breaker.Failure(time.Now())
+
+			break
+		}
+
+		ho.breaker.Success()
+		sent = end
+	}
+
+	if sent > 0 {
+		ho.logger.Info("replayed audit events from dead letter spool",
+			zap.String("url", ho.url),
+			zap.Int("events", sent),
+		)
+	}
+
+	if err := ho.spool.FinishReplay(events[sent:]); err != nil {
+		ho.logger.Error("failed to update audit dead letter spool", zap.Error(err))
+	}
+}
+
+func (ho *HTTPObserver) sendWithRetry(batch []Event) error {
+	var err error
+
+	for attempt := 0; attempt <= ho.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(ho.backoff(attempt)):
+			case <-ho.ctx.Done():
+				return fmt.Errorf("delivery aborted: %w", ho.ctx.Err())
+			}
+		}
+
+		err = ho.send(batch)
+		if err == nil || errors.Is(err, errPermanent) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// backoff возвращает задержку перед попыткой с номером attempt:
+// экспоненциальный рост с половинным случайным разбросом.
+func (ho *HTTPObserver) backoff(attempt int) time.Duration {
+	d := ho.opts.InitialBackoff << (attempt - 1)
+	if d <= 0 || d > ho.opts.MaxBackoff {
+		d = ho.opts.MaxBackoff
+	}
+
+	half := d / 2
+
+	return half + rand.N(half+1)
+}
+
+func (ho *HTTPObserver) send(batch []Event) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+
+	req, err := http.NewRequestWithContext(ho.ctx, http.MethodPost, ho.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ho.client.Do(req)
+	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= http.StatusBadRequest {
-		ho.logger.Warn("remote audit server returned error status",
+	switch {
+	case resp.StatusCode < http.StatusBadRequest:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("remote audit server returned status %d", resp.StatusCode)
+	default:
+		return fmt.Errorf("%w: remote audit server returned status %d", errPermanent, resp.StatusCode)
+	}
+}
+
+func (ho *HTTPObserver) spoolEvents(events []Event, reason string) error {
+	if ho.spool == nil {
+		ho.logger.Error("audit events lost: dead letter spool is not configured",
 			zap.String("url", ho.url),
-			zap.Int("status_code", resp.StatusCode),
+			zap.String("reason", reason),
+			zap.Int("events", len(events)),
 		)
+
+		return ErrQueueFull
 	}
 
+	if err := ho.spool.Append(events); err != nil {
+		ho.logger.Error("failed to write audit events to dead letter spool",
+			zap.String("url", ho.url),
+			zap.String("reason", reason),
+			zap.Int("events", len(events)),
+			zap.Error(err),
+		)
+
+		return err
+	}
+
+	ho.logger.Warn("audit events moved to dead letter spool",
+		zap.String("url", ho.url),
+		zap.String("reason", reason),
+		zap.Int("events", len(events)),
+	)
+
 	return nil
 }
 
-// Close закрывает HTTP-клиент и освобождает ресурсы.
-func (ho *HTTPObserver) Close() error {
-	ho.client.CloseIdleConnections()
+// circuitBreaker приостанавливает отправку после серии неудач.
+// Используется только горутиной отправки, поэтому не требует синхронизации.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+}
 
-	return nil
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow сообщает, разрешена ли отправка в момент now.
+// После истечения паузы разрешается пробная отправка.
+func (cb *circuitBreaker) Allow(now time.Time) bool {
+	return !now.Before(cb.openUntil)
+}
+
+// Success сбрасывает счетчик неудач.
+func (cb *circuitBreaker) Success() {
+	cb.failures = 0
+	cb.openUntil = time.Time{}
+}
+
+// Failure регистрирует неудачу и возвращает true, если отправка приостановлена.
+func (cb *circuitBreaker) Failure(now time.Time) bool {
+	cb.failures++
+	if cb.failures < cb.threshold {
+		return false
+	}
+
+	cb.openUntil = now.Add(cb.cooldown)
+
+	return true
 }
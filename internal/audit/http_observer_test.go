@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// auditServer - тестовый приемник событий аудита.
+type auditServer struct {
+	mu       sync.Mutex
+	batches  [][]Event
+	failing  atomic.Bool
+	requests atomic.Int32
+}
+
+func (s *auditServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+
+	if s.failing.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+
+	var batch []Event
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	s.mu.Lock()
+	s.batches = append(s.batches, batch)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *auditServer) events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []Event
+	for _, batch := range s.batches {
+		events = append(events, batch...)
+	}
+
+	return events
+}
+
+func testHTTPOptions(t *testing.T) HTTPOptions {
+	return HTTPOptions{
+		BatchSize:        3,
+		FlushInterval:    20 * time.Millisecond,
+		MaxRetries:       1,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       2 * time.Millisecond,
+		BreakerThreshold: 1,
+		BreakerCooldown:  50 * time.Millisecond,
+		DeadLetterPath:   filepath.Join(t.TempDir(), "dead-letter.jsonl"),
+		CloseTimeout:     time.Second,
+	}
+}
+
+func TestHTTPObserverSendsBatches(t *testing.T) {
+	receiver := &auditServer{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	observer, err := NewHTTPObserver(server.URL, testHTTPOptions(t), zap.NewNop())
+	require.NoError(t, err)
+
+	for i := 0; i < 7; i++ {
+		require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+	}
+
+	require.NoError(t, observer.Close())
+
+	assert.Len(t, receiver.events(), 7)
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	for _, batch := range receiver.batches {
+		assert.LessOrEqual(t, len(batch), 3)
+	}
+}
+
+func TestHTTPObserverRetriesAndReplaysDeadLetters(t *testing.T) {
+	receiver := &auditServer{}
+	receiver.failing.Store(true)
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	opts := testHTTPOptions(t)
+	observer, err := NewHTTPObserver(server.URL, opts, zap.NewNop())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, observer.OnEvent(NewEvent(ActionFollow, "https://example.com", nil)))
+	}
+
+	require.Eventually(t, func() bool {
+		events, err := readEvents(opts.DeadLetterPath)
+		return err == nil && len(events) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	assert.GreaterOrEqual(t, receiver.requests.Load(), int32(2), "batch should be retried")
+
+	receiver.failing.Store(false)
+
+	require.Eventually(t, func() bool {
+		return len(receiver.events()) == 3
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, observer.Close())
+
+	_, err = os.Stat(opts.DeadLetterPath)
+	assert.True(t, os.IsNotExist(err), "spool should be empty after replay")
+}
+
+func TestHTTPObserverCloseSpoolsWhenServerIsDown(t *testing.T) {
+	receiver := &auditServer{}
+	receiver.failing.Store(true)
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	opts := testHTTPOptions(t)
+	opts.FlushInterval = time.Hour
+
+	observer, err := NewHTTPObserver(server.URL, opts, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, observer.OnEvent(NewEvent(ActionDelete, "", nil)))
+	require.NoError(t, observer.Close())
+
+	events, err := readEvents(opts.DeadLetterPath)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	require.NoError(t, observer.OnEvent(NewEvent(ActionDelete, "", nil)))
+
+	events, err = readEvents(opts.DeadLetterPath)
+	require.NoError(t, err)
+	assert.Len(t, events, 2, "events published after close go to the spool")
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	cb := newCircuitBreaker(2, time.Minute)
+
+	assert.True(t, cb.Allow(now))
+	assert.False(t, cb.Failure(now))
+	assert.True(t, cb.Allow(now))
+	assert.True(t, cb.Failure(now))
+	assert.False(t, cb.Allow(now.Add(time.Second)))
+	assert.True(t, cb.Allow(now.Add(time.Minute)))
+
+	assert.True(t, cb.Failure(now.Add(time.Minute)), "failed probe reopens the breaker")
+
+	cb.Success()
+	assert.True(t, cb.Allow(now.Add(time.Minute)))
+}
@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// postgresWriteTimeout - таймаут сохранения одного события.
+const postgresWriteTimeout = 5 * time.Second
+
+// PostgresStore сохраняет события аудита в таблицу audit_events.
+// Пул подключений принадлежит приложению и не закрывается хранилищем.
+type PostgresStore struct {
+	pool   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+// NewPostgresStore создает хранилище событий аудита в PostgreSQL.
+func NewPostgresStore(pool *pgxpool.Pool, logger *zap.Logger) *PostgresStore {
+	return &PostgresStore{pool, logger}
+}
+
+// OnEvent сохраняет событие аудита в базу данных.
+func (ps *PostgresStore) OnEvent(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		ps.logger.Error("failed to marshal audit event", zap.Error(err))
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), postgresWriteTimeout)
+	defer cancel()
+
+	_, err = ps.pool.Exec(ctx,
+		`INSERT INTO audit_events (ts, action, user_id, url, short_code, request_id, payload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		time.Unix(event.Timestamp, 0).UTC(), event.Action, event.UserID, event.URL,
+		event.ShortCode, event.RequestID, payload)
+
+	return err
+}
+
+// Query ищет события по условиям, начиная с самых новых.
+func (ps *PostgresStore) Query(ctx context.Context, query Query) (Page, error) {
+	before, err := query.normalize()
+	if err != nil {
+		return Page{}, err
+	}
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if before > 0 {
+		addCondition("id < $%d", before)
+	}
+	if query.Action != "" {
+		addCondition("action = $%d", query.Action)
+	}
+	if query.UserID != "" {
+		addCondition("user_id = $%d", query.UserID)
+	}
+	if query.URLContains != "" {
+		addCondition(`url ILIKE '%%' || $%d || '%%' ESCAPE '\'`, escapeLike(query.URLContains))
+	}
+	if !query.From.IsZero() {
+		addCondition("ts >= $%d", query.From.UTC())
+	}
+	if !query.To.IsZero() {
+		addCondition("ts < $%d", query.To.UTC())
+	}
+
+	sql := "SELECT id, payload FROM audit_events"
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница.
+	args = append(args, query.Limit+1)
+	sql += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := ps.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return Page{}, err
+	}
+	defer rows.Close()
+
+	page := Page{Events: make([]StoredEvent, 0, query.Limit)}
+	hasMore := false
+	for rows.Next() {
+		if len(page.Events) == query.Limit {
+			hasMore = true
+
+			break
+		}
+
+		var (
+			stored  StoredEvent
+			payload []byte
+		)
+		if err := rows.Scan(&stored.ID, &payload); err != nil {
+			return Page{}, err
+		}
+		if err := json.Unmarshal(payload, &stored.Event); err != nil {
+			return Page{}, err
+		}
+
+		page.Events = append(page.Events, stored)
+	}
+
+	if err := rows.Err(); err != nil {
+		return Page{}, err
+	}
+
+	if hasMore {
+		page.NextCursor = encodeCursor(page.Events[len(page.Events)-1].ID)
+	}
+
+	return page, nil
+}
+
+// Close ничего не делает: пул подключений закрывает приложение.
+func (ps *PostgresStore) Close() error {
+	return nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -3,80 +3,181 @@ package audit
 import (
 	"slices"
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 )
 
+// DefaultQueueSize - размер очереди событий для каждого наблюдателя по умолчанию.
+const DefaultQueueSize = 1024
+
+// subscription связывает наблюдателя с его очередью событий.
+// Каждый наблюдатель обрабатывает события в собственной горутине,
+// поэтому медленный наблюдатель не задерживает остальных.
+type subscription struct {
+	observer Observer
+	filter   *eventFilter
+	queue    chan Event
+	done     chan struct{}
+	counters *counters
+}
+
+// Metrics содержит счетчики обработки событий аудита.
+type Metrics struct {
+	// Delivered - события, успешно обработанные наблюдателем
+	Delivered uint64
+	// Filtered - события, отброшенные фильтром наблюдателя
+	Filtered uint64
+	// Dropped - события, отброшенные из-за переполнения очереди
+	Dropped uint64
+	// Failed - события, при обработке которых наблюдатель вернул ошибку
+	Failed uint64
+}
+
+// counters - атомарные счетчики, из которых собирается Metrics.
+type counters struct {
+	delivered atomic.Uint64
+	filtered  atomic.Uint64
+	dropped   atomic.Uint64
+	failed    atomic.Uint64
+}
+
+func (c *counters) snapshot() Metrics {
+	return Metrics{
+		Delivered: c.delivered.Load(),
+		Filtered:  c.filtered.Load(),
+		Dropped:   c.dropped.Load(),
+		Failed:    c.failed.Load(),
+	}
+}
+
 // AuditPublisher реализует паттерн Publisher для рассылки событий аудита.
 type AuditPublisher struct {
-	observers []Observer
-	mu        *sync.RWMutex
-	logger    *zap.Logger
+	subscriptions []*subscription
+	queueSize     int
+	total         *counters
+	mu            *sync.RWMutex
+	logger        *zap.Logger
 }
 
-// NewPublisher создает новый экземпляр AuditPublisher.
+// NewPublisher создает новый экземпляр AuditPublisher с очередью DefaultQueueSize.
 func NewPublisher(logger *zap.Logger) *AuditPublisher {
+	return NewPublisherWithQueue(DefaultQueueSize, logger)
+}
+
+// NewPublisherWithQueue создает новый экземпляр AuditPublisher
+// с ограниченной очередью заданного размера для каждого наблюдателя.
+func NewPublisherWithQueue(queueSize int, logger *zap.Logger) *AuditPublisher {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
 	return &AuditPublisher{
-		observers: make([]Observer, 0),
-		mu:        &sync.RWMutex{},
-		logger:    logger,
+		subscriptions: make([]*subscription, 0),
+		queueSize:     queueSize,
+		total:         &counters{},
+		mu:            &sync.RWMutex{},
+		logger:        logger,
 	}
 }
 
-// Subscribe добавляет наблюдателя для получения событий аудита.
+// Subscribe добавляет наблюдателя для получения всех событий аудита.
 func (p *AuditPublisher) Subscribe(observer Observer) {
+	p.SubscribeWithFilter(observer, Filter{})
+}
+
+// SubscribeWithFilter добавляет наблюдателя, который получает только события,
+// прошедшие фильтр.
+func (p *AuditPublisher) SubscribeWithFilter(observer Observer, filter Filter) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.observers = append(p.observers, observer)
-	p.logger.Info("audit observer subscribed", zap.Int("total_observers", len(p.observers)))
+	sub := &subscription{
+		observer: observer,
+		filter:   newEventFilter(filter),
+		queue:    make(chan Event, p.queueSize),
+		done:     make(chan struct{}),
+		counters: &counters{},
+	}
+	go p.dispatch(sub)
+
+	p.subscriptions = append(p.subscriptions, sub)
+	p.logger.Info("audit observer subscribed", zap.Int("total_observers", len(p.subscriptions)))
 }
 
 // Unsubscribe удаляет наблюдателя из списка подписчиков.
+// События, уже поставленные в очередь наблюдателя, будут ему доставлены.
 func (p *AuditPublisher) Unsubscribe(observer Observer) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	for i, obs := range p.observers {
-		if obs == observer {
-			p.observers = slices.Delete(p.observers, i, i+1)
-			p.logger.Info("audit observer unsubscribed", zap.Int("total_observers", len(p.observers)))
+	for i, sub := range p.subscriptions {
+		if sub.observer == observer {
+			close(sub.queue)
+			<-sub.done
+
+			p.subscriptions = slices.Delete(p.subscriptions, i, i+1)
+			p.logger.Info("audit observer unsubscribed", zap.Int("total_observers", len(p.subscriptions)))
+
 			return
 		}
 	}
 }
 
-// Publish рассылает событие всем наблюдателям асинхронно.
+// Publish ставит событие в очереди наблюдателей, фильтры которых его пропускают, без блокировки.
+// Если очередь наблюдателя переполнена, событие для него отбрасывается.
 func (p *AuditPublisher) Publish(event Event) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	for _, observer := range p.observers {
-		go func(obs Observer) {
-			if err := obs.OnEvent(event); err != nil {
-				p.logger.Error("failed to send audit event to observer",
-					zap.Error(err),
-					zap.String("action", string(event.Action)),
-					zap.String("url", event.URL),
-				)
-			}
-		}(observer)
+	for _, sub := range p.subscriptions {
+		if !sub.filter.allow(event) {
+			sub.counters.filtered.Add(1)
+			p.total.filtered.Add(1)
+
+			continue
+		}
+
+		select {
+		case sub.queue <- event:
+		default:
+			sub.counters.dropped.Add(1)
+			p.total.dropped.Add(1)
+
+			p.logger.Warn("audit observer queue is full, event dropped",
+				zap.String("action", string(event.Action)),
+				zap.String("request_id", event.RequestID),
+			)
+		}
 	}
 }
 
-// Close закрывает всех наблюдателей и освобождает ресурсы.
+// Close доставляет события из очередей, закрывает всех наблюдателей и освобождает ресурсы.
 func (p *AuditPublisher) Close() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	for _, observer := range p.observers {
-		if err := observer.Close(); err != nil {
+	for _, sub := range p.subscriptions {
+		close(sub.queue)
+	}
+
+	for _, sub := range p.subscriptions {
+		<-sub.done
+
+		if err := sub.observer.Close(); err != nil {
 			p.logger.Error("failed to close audit observer", zap.Error(err))
 		}
 	}
 
-	p.observers = nil
-	p.logger.Info("audit publisher closed")
+	p.subscriptions = nil
+
+	metrics := p.total.snapshot()
+	p.logger.Info("audit publisher closed",
+		zap.Uint64("delivered", metrics.Delivered),
+		zap.Uint64("filtered", metrics.Filtered),
+		zap.Uint64("dropped", metrics.Dropped),
+		zap.Uint64("failed", metrics.Failed),
+	)
 
 	return nil
 }
@@ -86,5 +187,47 @@ func (p *AuditPublisher) HasObservers() bool {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	return len(p.observers) > 0
+	return len(p.subscriptions) > 0
+}
+
+// Metrics возвращает суммарные счетчики по всем наблюдателям за время работы издателя.
+func (p *AuditPublisher) Metrics() Metrics {
+	return p.total.snapshot()
+}
+
+// ObserverMetrics возвращает счетчики подписанного наблюдателя.
+func (p *AuditPublisher) ObserverMetrics(observer Observer) (Metrics, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, sub := range p.subscriptions {
+		if sub.observer == observer {
+			return sub.counters.snapshot(), true
+		}
+	}
+
+	return Metrics{}, false
+}
+
+func (p *AuditPublisher) dispatch(sub *subscription) {
+	defer close(sub.done)
+
+	for event := range sub.queue {
+		if err := sub.observer.OnEvent(event); err != nil {
+			sub.counters.failed.Add(1)
+			p.total.failed.Add(1)
+
+			p.logger.Error("failed to send audit event to observer",
+				zap.Error(err),
+				zap.String("action", string(event.Action)),
+				zap.String("url", event.URL),
+				zap.String("request_id", event.RequestID),
+			)
+
+			continue
+		}
+
+		sub.counters.delivered.Add(1)
+		p.total.delivered.Add(1)
+	}
 }
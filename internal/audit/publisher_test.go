@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// recordingObserver сохраняет полученные события.
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []Event
+	block  chan struct{}
+	closed bool
+}
+
+func (o *recordingObserver) OnEvent(event Event) error {
+	if o.block != nil {
+		<-o.block
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.events = append(o.events, event)
+
+	return nil
+}
+
+func (o *recordingObserver) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.closed = true
+
+	return nil
+}
+
+func TestAuditPublisherDeliversOnClose(t *testing.T) {
+	publisher := NewPublisher(zap.NewNop())
+	first := &recordingObserver{}
+	second := &recordingObserver{}
+
+	publisher.Subscribe(first)
+	publisher.Subscribe(second)
+
+	for i := 0; i < 10; i++ {
+		publisher.Publish(NewEvent(ActionShorten, "https://example.com", nil))
+	}
+
+	assert.NoError(t, publisher.Close())
+
+	assert.Len(t, first.events, 10)
+	assert.Len(t, second.events, 10)
+	assert.True(t, first.closed)
+	assert.True(t, second.closed)
+	assert.False(t, publisher.HasObservers())
+}
+
+func TestAuditPublisherDropsWhenQueueIsFull(t *testing.T) {
+	publisher := NewPublisherWithQueue(2, zap.NewNop())
+	slow := &recordingObserver{block: make(chan struct{})}
+	fast := &recordingObserver{}
+
+	publisher.Subscribe(slow)
+	publisher.Subscribe(fast)
+
+	for i := 0; i < 10; i++ {
+		publisher.Publish(NewEvent(ActionFollow, "https://example.com", nil))
+	}
+
+	slowMetrics, ok := publisher.ObserverMetrics(slow)
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, slowMetrics.Dropped, uint64(7))
+
+	close(slow.block)
+	assert.NoError(t, publisher.Close())
+
+	assert.LessOrEqual(t, len(slow.events), 3, "slow observer keeps at most queue size plus one in-flight event")
+	assert.NotEmpty(t, fast.events)
+}
+
+func TestAuditPublisherFilterMetrics(t *testing.T) {
+	publisher := NewPublisher(zap.NewNop())
+	all := &recordingObserver{}
+	shortenOnly := &recordingObserver{}
+
+	publisher.Subscribe(all)
+	publisher.SubscribeWithFilter(shortenOnly, Filter{Actions: []Action{ActionShorten}})
+
+	for i := 0; i < 3; i++ {
+		publisher.Publish(NewEvent(ActionShorten, "https://example.com", nil))
+		publisher.Publish(NewEvent(ActionFollow, "https://example.com", nil))
+	}
+
+	filtered, ok := publisher.ObserverMetrics(shortenOnly)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), filtered.Filtered)
+
+	assert.NoError(t, publisher.Close())
+
+	assert.Len(t, all.events, 6)
+	assert.Len(t, shortenOnly.events, 3)
+	assert.Equal(t, Metrics{Delivered: 9, Filtered: 3}, publisher.Metrics())
+}
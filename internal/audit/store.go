@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor возвращается, если курсор пагинации поврежден.
+var ErrInvalidCursor = errors.New("invalid audit cursor")
+
+const (
+	// DefaultQueryLimit - размер страницы по умолчанию.
+	DefaultQueryLimit = 50
+	// MaxQueryLimit - максимальный размер страницы.
+	MaxQueryLimit = 500
+)
+
+// Query описывает условия поиска событий аудита.
+// Пустые поля не ограничивают выборку.
+type Query struct {
+	// Action - тип действия
+	Action Action
+	// UserID - идентификатор пользователя
+	UserID string
+	// URLContains - подстрока оригинального URL (без учета регистра)
+	URLContains string
+	// From - начало интервала (включительно)
+	From time.Time
+	// To - конец интервала (не включительно)
+	To time.Time
+	// Cursor - курсор, полученный с предыдущей страницы
+	Cursor string
+	// Limit - размер страницы
+	Limit int
+}
+
+// StoredEvent - сохраненное событие аудита с идентификатором.
+type StoredEvent struct {
+	ID int64 `json:"id"`
+	Event
+}
+
+// Page - страница результатов поиска, упорядоченная от новых событий к старым.
+type Page struct {
+	Events     []StoredEvent `json:"events"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// Store - наблюдатель, сохраняющий события аудита с возможностью поиска.
+type Store interface {
+	Observer
+	Query(ctx context.Context, query Query) (Page, error)
+}
+
+// normalize приводит размер страницы к допустимому и разбирает курсор.
+// Возвращает идентификатор, с которого начинается страница (не включительно), или 0.
+func (q *Query) normalize() (int64, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultQueryLimit
+	}
+	if q.Limit > MaxQueryLimit {
+		q.Limit = MaxQueryLimit
+	}
+
+	if q.Cursor == "" {
+		return 0, nil
+	}
+
+	return decodeCursor(q.Cursor)
+}
+
+// matches проверяет событие на соответствие условиям, кроме курсора.
+func (q Query) matches(event Event) bool {
+	if q.Action != "" && event.Action != q.Action {
+		return false
+	}
+	if q.UserID != "" && (event.UserID == nil || *event.UserID != q.UserID) {
+		return false
+	}
+	if !q.From.IsZero() && event.Timestamp < q.From.Unix() {
+		return false
+	}
+	if !q.To.IsZero() && event.Timestamp >= q.To.Unix() {
+		return false
+	}
+	if q.URLContains != "" && !strings.Contains(strings.ToLower(event.URL), strings.ToLower(q.URLContains)) {
+		return false
+	}
+
+	return true
+}
+
+// encodeCursor кодирует идентификатор последнего события страницы.
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
@@ -0,0 +1,289 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrSyslogUnavailable - соединение с syslog-сервером отсутствует и повторное подключение еще не разрешено.
+var ErrSyslogUnavailable = errors.New("syslog server is unavailable")
+
+const (
+	// syslogVersion - версия протокола RFC 5424.
+	syslogVersion = 1
+	// syslogSeverityInfo - уровень важности Informational.
+	syslogSeverityInfo = 6
+	// syslogFacilityAudit - категория log audit.
+	syslogFacilityAudit = 13
+	// syslogSDID - идентификатор элемента структурированных данных.
+	// 32473 - номер предприятия, зарезервированный для документации (RFC 5612).
+	syslogSDID = "audit@32473"
+	// syslogNil - значение отсутствующего поля заголовка.
+	syslogNil = "-"
+)
+
+// SyslogOptions содержит настройки отправки событий аудита в syslog.
+// Нулевые значения заменяются значениями по умолчанию.
+type SyslogOptions struct {
+	// Network - транспорт: udp, tcp, unix или unixgram
+	Network string
+	// Address - адрес syslog-сервера или путь к Unix-сокету
+	Address string
+	// Facility - категория сообщений syslog
+	Facility int
+	// AppName - имя приложения в заголовке сообщения
+	AppName string
+	// Hostname - имя хоста в заголовке сообщения
+	Hostname string
+	// DialTimeout - таймаут подключения к серверу
+	DialTimeout time.Duration
+	// WriteTimeout - таймаут отправки одного сообщения
+	WriteTimeout time.Duration
+	// ReconnectInterval - минимальный интервал между попытками переподключения
+	ReconnectInterval time.Duration
+}
+
+func (o SyslogOptions) withDefaults() SyslogOptions {
+	if o.Network == "" {
+		o.Network = "udp"
+	}
+	if o.Facility <= 0 {
+		o.Facility = syslogFacilityAudit
+	}
+	if o.AppName == "" {
+		o.AppName = "url-shorter"
+	}
+	if o.Hostname == "" {
+		o.Hostname, _ = os.Hostname()
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = 5 * time.Second
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = 5 * time.Second
+	}
+	if o.ReconnectInterval <= 0 {
+		o.ReconnectInterval = time.Second
+	}
+
+	return o
+}
+
+// SyslogObserver отправляет события аудита в syslog в формате RFC 5424.
+// Пользователь, действие и URL передаются структурированными данными,
+// событие целиком - в теле сообщения в формате JSON.
+// По TCP и потоковому Unix-сокету используется подсчет октетов (RFC 6587).
+type SyslogObserver struct {
+	opts     SyslogOptions
+	conn     net.Conn
+	lastDial time.Time
+	procID   string
+	mu       *sync.Mutex
+	logger   *zap.Logger
+}
+
+// NewSyslogObserver создает новый наблюдатель для отправки событий в syslog.
+// Если сервер недоступен при создании, подключение повторяется при отправке событий.
+func NewSyslogObserver(opts SyslogOptions, logger *zap.Logger) (*SyslogObserver, error) {
+	opts = opts.withDefaults()
+
+	switch opts.Network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", opts.Network)
+	}
+
+	if opts.Address == "" {
+		return nil, errors.New("syslog address is required")
+	}
+
+	so := &SyslogObserver{
+		opts:   opts,
+		procID: strconv.Itoa(os.Getpid()),
+		mu:     &sync.Mutex{},
+		logger: logger,
+	}
+
+	if err := so.dial(); err != nil {
+		logger.Warn("syslog server is unavailable, will reconnect later",
+			zap.String("address", opts.Address),
+			zap.Error(err),
+		)
+	}
+
+	return so, nil
+}
+
+// OnEvent обрабатывает событие аудита и отправляет его в syslog.
+// При ошибке отправки соединение переустанавливается и отправка повторяется один раз.
+func (so *SyslogObserver) OnEvent(event Event) error {
+	msg, err := so.format(event)
+	if err != nil {
+		so.logger.Error("failed to format syslog message", zap.Error(err))
+		return err
+	}
+
+	so.mu.Lock()
+	defer so.mu.Unlock()
+
+	if err := so.write(msg); err == nil {
+		return nil
+	}
+
+	so.closeConn()
+
+	if err := so.dial(); err != nil {
+		return err
+	}
+
+	if err := so.write(msg); err != nil {
+		so.closeConn()
+
+		return fmt.Errorf("failed to send syslog message: %w", err)
+	}
+
+	return nil
+}
+
+// Close закрывает соединение с syslog-сервером.
+func (so *SyslogObserver) Close() error {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+
+	return so.closeConn()
+}
+
+// dial подключается к серверу не чаще ReconnectInterval. Вызывается под мьютексом.
+func (so *SyslogObserver) dial() error {
+	if !so.lastDial.IsZero() && time.Since(so.lastDial) < so.opts.ReconnectInterval {
+		return ErrSyslogUnavailable
+	}
+	so.lastDial = time.Now()
+
+	conn, err := net.DialTimeout(so.opts.Network, so.opts.Address, so.opts.DialTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog server: %w", err)
+	}
+
+	so.conn = conn
+
+	return nil
+}
+
+func (so *SyslogObserver) write(msg []byte) error {
+	if so.conn == nil {
+		return ErrSyslogUnavailable
+	}
+
+	if so.isStream() {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+
+	if err := so.conn.SetWriteDeadline(time.Now().Add(so.opts.WriteTimeout)); err != nil {
+		return err
+	}
+
+	_, err := so.conn.Write(msg)
+
+	return err
+}
+
+func (so *SyslogObserver) closeConn() error {
+	if so.conn == nil {
+		return nil
+	}
+
+	err := so.conn.Close()
+	so.conn = nil
+
+	return err
+}
+
+func (so *SyslogObserver) isStream() bool {
+	return so.opts.Network == "tcp" || so.opts.Network == "unix"
+}
+
+// format формирует сообщение RFC 5424:
+// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG.
+func (so *SyslogObserver) format(event Event) ([]byte, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	userID := syslogNil
+	if event.UserID != nil {
+		userID = *event.UserID
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "<%d>%d %s %s %s %s %s ",
+		so.opts.Facility*8+syslogSeverityInfo,
+		syslogVersion,
+		time.Unix(event.Timestamp, 0).UTC().Format(time.RFC3339),
+		headerField(so.opts.Hostname, 255),
+		headerField(so.opts.AppName, 48),
+		headerField(so.procID, 128),
+		headerField(string(event.Action), 32),
+	)
+
+	sb.WriteString("[" + syslogSDID)
+	writeSDParam(&sb, "user", userID)
+	writeSDParam(&sb, "action", string(event.Action))
+	writeSDParam(&sb, "url", event.URL)
+	if event.ShortCode != "" {
+		writeSDParam(&sb, "short_code", event.ShortCode)
+	}
+	if event.RequestID != "" {
+		writeSDParam(&sb, "request_id", event.RequestID)
+	}
+	sb.WriteString("] ")
+	sb.Write(body)
+
+	return []byte(sb.String()), nil
+}
+
+// headerField приводит значение поля заголовка к допустимому виду:
+// только печатные символы ASCII, ограниченная длина, "-" для пустого значения.
+func headerField(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+
+		return r
+	}, value)
+
+	if len(value) > maxLen {
+		value = value[:maxLen]
+	}
+	if value == "" {
+		return syslogNil
+	}
+
+	return value
+}
+
+// writeSDParam добавляет параметр структурированных данных, экранируя '"', '\' и ']'.
+func writeSDParam(sb *strings.Builder, name, value string) {
+	sb.WriteString(" " + name + `="`)
+
+	for _, r := range value {
+		if r == '"' || r == '\\' || r == ']' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+
+	sb.WriteByte('"')
+}
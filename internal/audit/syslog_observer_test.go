@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// readOctetCounted читает одно сообщение с подсчетом октетов (RFC 6587).
+func readOctetCounted(reader *bufio.Reader) (string, error) {
+	length, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(length))
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func TestSyslogObserverFormat(t *testing.T) {
+	userID := `user"1]`
+
+	tests := []struct {
+		name  string
+		event Event
+		want  []string
+	}{
+		{
+			name: "authenticated user",
+			event: Event{
+				Timestamp: 1700000000,
+				Action:    ActionShorten,
+				UserID:    &userID,
+				URL:       "https://example.com/a\\b",
+				ShortCode: "abc",
+			},
+			want: []string{
+				"<110>1 2023-11-14T22:13:20Z host url-shorter ",
+				` shorten [audit@32473 user="user\"1\]" action="shorten" url="https://example.com/a\\b" short_code="abc"] {`,
+			},
+		},
+		{
+			name:  "anonymous user",
+			event: Event{Timestamp: 1700000000, Action: ActionFollow, URL: "https://example.com"},
+			want: []string{
+				`[audit@32473 user="-" action="follow" url="https://example.com"]`,
+			},
+		},
+	}
+
+	observer := &SyslogObserver{
+		opts:   SyslogOptions{Hostname: "host"}.withDefaults(),
+		procID: "42",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := observer.format(tt.event)
+			require.NoError(t, err)
+
+			for _, want := range tt.want {
+				assert.Contains(t, string(msg), want)
+			}
+		})
+	}
+}
+
+func TestSyslogObserverUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	observer, err := NewSyslogObserver(SyslogOptions{Network: "udp", Address: conn.LocalAddr().String()}, zap.NewNop())
+	require.NoError(t, err)
+	defer observer.Close()
+
+	require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(string(buf[:n]), "<110>1 "))
+	assert.Contains(t, string(buf[:n]), `action="shorten"`)
+}
+
+func TestSyslogObserverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		for i := 0; i < 2; i++ {
+			msg, err := readOctetCounted(reader)
+			if err != nil {
+				return
+			}
+			messages <- msg
+		}
+	}()
+
+	observer, err := NewSyslogObserver(SyslogOptions{Network: "tcp", Address: listener.Addr().String()}, zap.NewNop())
+	require.NoError(t, err)
+	defer observer.Close()
+
+	require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com/1", nil)))
+	require.NoError(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com/2", nil)))
+
+	for _, url := range []string{"https://example.com/1", "https://example.com/2"} {
+		select {
+		case msg := <-messages:
+			assert.Contains(t, msg, `url="`+url+`"`)
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
+
+func TestSyslogObserverReconnectsToUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syslog.sock")
+
+	observer, err := NewSyslogObserver(SyslogOptions{
+		Network:           "unix",
+		Address:           path,
+		ReconnectInterval: time.Millisecond,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	defer observer.Close()
+
+	time.Sleep(2 * time.Millisecond)
+	assert.Error(t, observer.OnEvent(NewEvent(ActionShorten, "https://example.com", nil)))
+
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if msg, err := readOctetCounted(bufio.NewReader(conn)); err == nil {
+			messages <- msg
+		}
+	}()
+
+	time.Sleep(2 * time.Millisecond)
+	require.NoError(t, observer.OnEvent(NewEvent(ActionFollow, "https://example.com", nil)))
+
+	select {
+	case msg := <-messages:
+		assert.Contains(t, msg, `action="follow"`)
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+	}
+}
@@ -4,6 +4,8 @@ package config
 import (
 	"flag"
 	"os"
+	"strconv"
+	"time"
 )
 
 const (
@@ -11,8 +13,79 @@ const (
 	baseURLEnv         = "BASE_URL"
 	fileStoragePathEnv = "FILE_STORAGE_PATH"
 	databaseDSNEnv     = "DATABASE_DSN"
+	dedupScopeEnv      = "DEDUP_SCOPE"
 	auditFileEnv       = "AUDIT_FILE"
 	auditURLEnv        = "AUDIT_URL"
+
+	auditQueueSizeEnv         = "AUDIT_QUEUE_SIZE"
+	auditHTTPBatchSizeEnv     = "AUDIT_HTTP_BATCH_SIZE"
+	auditHTTPFlushIntervalEnv = "AUDIT_HTTP_FLUSH_INTERVAL"
+	auditHTTPMaxRetriesEnv    = "AUDIT_HTTP_MAX_RETRIES"
+	auditHTTPDeadLetterEnv    = "AUDIT_HTTP_DEAD_LETTER"
+	auditHTTPCloseTimeoutEnv  = "AUDIT_HTTP_CLOSE_TIMEOUT"
+
+	auditFileMaxSizeEnv        = "AUDIT_FILE_MAX_SIZE"
+	auditFileRotateIntervalEnv = "AUDIT_FILE_ROTATE_INTERVAL"
+	auditFileCompressEnv       = "AUDIT_FILE_COMPRESS"
+	auditFileMaxFilesEnv       = "AUDIT_FILE_MAX_FILES"
+	auditFileMaxAgeEnv         = "AUDIT_FILE_MAX_AGE"
+	auditFileFsyncIntervalEnv  = "AUDIT_FILE_FSYNC_INTERVAL"
+	auditFileChainKeyEnv       = "AUDIT_FILE_CHAIN_KEY"
+
+	auditSyslogNetworkEnv = "AUDIT_SYSLOG_NETWORK"
+	auditSyslogAddressEnv = "AUDIT_SYSLOG_ADDRESS"
+	auditSyslogAppNameEnv = "AUDIT_SYSLOG_APP_NAME"
+
+	auditFileFilterEnv   = "AUDIT_FILE_FILTER"
+	auditHTTPFilterEnv   = "AUDIT_HTTP_FILTER"
+	auditSyslogFilterEnv = "AUDIT_SYSLOG_FILTER"
+
+	auditStoreEnv     = "AUDIT_STORE"
+	auditStorePathEnv = "AUDIT_STORE_PATH"
+	adminTokenEnv     = "ADMIN_TOKEN"
+
+	rateLimitKeyEnv           = "RATE_LIMIT_KEY"
+	rateLimitAPIKeyHeaderEnv  = "RATE_LIMIT_API_KEY_HEADER"
+	rateLimitAPIKeysEnv       = "RATE_LIMIT_API_KEYS"
+	rateLimitBackendEnv       = "RATE_LIMIT_BACKEND"
+	rateLimitCreateRateEnv    = "RATE_LIMIT_CREATE_RPS"
+	rateLimitCreateBurstEnv   = "RATE_LIMIT_CREATE_BURST"
+	rateLimitRedirectRateEnv  = "RATE_LIMIT_REDIRECT_RPS"
+	rateLimitRedirectBurstEnv = "RATE_LIMIT_REDIRECT_BURST"
+	rateLimitListRateEnv      = "RATE_LIMIT_LIST_RPS"
+	rateLimitListBurstEnv     = "RATE_LIMIT_LIST_BURST"
+
+	enumGuardEnabledEnv       = "ENUM_GUARD"
+	enumGuardWindowEnv        = "ENUM_GUARD_WINDOW"
+	enumGuardMinRequestsEnv   = "ENUM_GUARD_MIN_REQUESTS"
+	enumGuardDelayRatioEnv    = "ENUM_GUARD_DELAY_RATIO"
+	enumGuardBlockRatioEnv    = "ENUM_GUARD_BLOCK_RATIO"
+	enumGuardMaxDelayEnv      = "ENUM_GUARD_MAX_DELAY"
+	enumGuardBlockDurationEnv = "ENUM_GUARD_BLOCK_DURATION"
+
+	urlAllowedSchemesEnv = "URL_ALLOWED_SCHEMES"
+	urlMaxLengthEnv      = "URL_MAX_LENGTH"
+	urlAllowPrivateEnv   = "URL_ALLOW_PRIVATE"
+	urlResolveDNSEnv     = "URL_RESOLVE_DNS"
+	urlSortQueryEnv      = "URL_SORT_QUERY"
+	urlStripTrackingEnv  = "URL_STRIP_TRACKING"
+	urlTrackingParamsEnv = "URL_TRACKING_PARAMS"
+
+	domainBlocklistEnv          = "DOMAIN_BLOCKLIST"
+	domainAllowlistEnv          = "DOMAIN_ALLOWLIST"
+	domainListReloadIntervalEnv = "DOMAIN_LIST_RELOAD_INTERVAL"
+	domainListCheckRedirectEnv  = "DOMAIN_LIST_CHECK_REDIRECT"
+
+	redirectStatusEnv      = "REDIRECT_STATUS"
+	redirectCacheMaxAgeEnv = "REDIRECT_CACHE_MAX_AGE"
+	comingSoonURLEnv       = "COMING_SOON_URL"
+	comingSoonPageEnv      = "COMING_SOON_PAGE"
+
+	linkPasswordSecretEnv        = "LINK_PASSWORD_SECRET"
+	linkPasswordCookieTTLEnv     = "LINK_PASSWORD_COOKIE_TTL"
+	linkPasswordMaxAttemptsEnv   = "LINK_PASSWORD_MAX_ATTEMPTS"
+	linkPasswordAttemptWindowEnv = "LINK_PASSWORD_ATTEMPT_WINDOW"
+	linkPasswordMaxBatchEnv      = "LINK_PASSWORD_MAX_BATCH"
 )
 
 // ServerConfig содержит настройки HTTP-сервера.
@@ -27,6 +100,8 @@ type ServerConfig struct {
 type StorageConfig struct {
 	// FilePath - путь к файлу для хранения URL (если не используется PostgreSQL)
 	FilePath string
+	// DedupScope - область поиска дубликатов: global, per_user или none
+	DedupScope string
 }
 
 // DatabaseConfig содержит настройки подключения к базе данных.
@@ -41,6 +116,183 @@ type AuditConfig struct {
 	FilePath string
 	// URL - URL удаленного сервера для отправки событий аудита
 	URL string
+	// QueueSize - размер очереди событий для каждого наблюдателя
+	QueueSize int
+	// HTTP - настройки доставки событий на удаленный сервер
+	HTTP AuditHTTPConfig
+	// File - настройки ротации и хранения файла аудита
+	File AuditFileConfig
+	// Syslog - настройки отправки событий аудита в syslog
+	Syslog AuditSyslogConfig
+	// Store - хранилище для поиска событий: "postgres", "file" или пусто, если поиск отключен
+	Store string
+	// StorePath - путь к файлу хранилища событий для Store = "file"
+	StorePath string
+}
+
+// AuditSyslogConfig содержит настройки отправки событий аудита в syslog (RFC 5424).
+type AuditSyslogConfig struct {
+	// Network - транспорт: udp, tcp, unix или unixgram
+	Network string
+	// Address - адрес syslog-сервера или путь к Unix-сокету; если пуст, отправка отключена
+	Address string
+	// AppName - имя приложения в заголовке сообщения
+	AppName string
+	// Filter - фильтр событий наблюдателя (см. audit.ParseFilter)
+	Filter string
+}
+
+// AuditFileConfig содержит настройки ротации и хранения файла аудита.
+// Нулевые значения отключают ротацию и ограничения хранения.
+type AuditFileConfig struct {
+	// MaxSize - размер файла в байтах, при превышении которого выполняется ротация
+	MaxSize int64
+	// RotateInterval - период ротации файла по времени
+	RotateInterval time.Duration
+	// Compress - сжимать ротированные файлы gzip
+	Compress bool
+	// MaxFiles - максимальное число хранимых ротированных файлов
+	MaxFiles int
+	// MaxAge - максимальный возраст хранимых ротированных файлов
+	MaxAge time.Duration
+	// FsyncInterval - период сброса буфера и синхронизации файла с диском
+	FsyncInterval time.Duration
+	// ChainKey - секрет HMAC для цепочки записей, защищающей файл от изменений
+	ChainKey string
+	// Filter - фильтр событий наблюдателя (см. audit.ParseFilter)
+	Filter string
+}
+
+// AuditHTTPConfig содержит настройки надежной доставки событий аудита по HTTP.
+// Нулевые значения означают настройки по умолчанию.
+type AuditHTTPConfig struct {
+	// BatchSize - максимальное число событий в одном запросе
+	BatchSize int
+	// FlushInterval - максимальная задержка отправки неполного пакета
+	FlushInterval time.Duration
+	// MaxRetries - число повторных попыток отправки пакета
+	MaxRetries int
+	// DeadLetterPath - файл для событий, которые не удалось доставить
+	DeadLetterPath string
+	// CloseTimeout - время на отправку оставшихся событий при остановке
+	CloseTimeout time.Duration
+	// Filter - фильтр событий наблюдателя (см. audit.ParseFilter)
+	Filter string
+}
+
+// AdminConfig содержит настройки административного API.
+type AdminConfig struct {
+	// Token - токен доступа к административному API; если пуст, API недоступно
+	Token string
+}
+
+// RateLimitRule задает ограничение частоты запросов для группы маршрутов.
+// Нулевые значения отключают ограничение.
+type RateLimitRule struct {
+	// Rate - допустимое число запросов в секунду
+	Rate float64
+	// Burst - максимальное число запросов подряд
+	Burst int
+}
+
+// RateLimitConfig содержит настройки ограничения частоты запросов.
+type RateLimitConfig struct {
+	// Key - ключ подсчета запросов: user, ip или api_key
+	Key string
+	// APIKeyHeader - заголовок с API-ключом для Key = "api_key"
+	APIKeyHeader string
+	// APIKeys - известные API-ключи через запятую; остальные клиенты считаются по IP-адресу
+	APIKeys string
+	// Backend - хранилище счетчиков: memory или postgres (общее для всех реплик)
+	Backend string
+	// Create - ограничение для создания и удаления ссылок
+	Create RateLimitRule
+	// Redirect - ограничение для переходов по коротким ссылкам
+	Redirect RateLimitRule
+	// List - ограничение для получения списков ссылок
+	List RateLimitRule
+}
+
+// EnumGuardConfig содержит пороги обнаружения перебора коротких кодов.
+// Нулевые значения порогов означают значения по умолчанию.
+type EnumGuardConfig struct {
+	// Enabled - включить обнаружение перебора
+	Enabled bool
+	// Window - длительность скользящего окна
+	Window time.Duration
+	// MinRequests - минимальное число запросов в окне для оценки клиента
+	MinRequests int
+	// DelayRatio - доля промахов, начиная с которой ответы замедляются
+	DelayRatio float64
+	// BlockRatio - доля промахов, при которой клиент блокируется
+	BlockRatio float64
+	// MaxDelay - максимальная задержка ответа
+	MaxDelay time.Duration
+	// BlockDuration - длительность блокировки
+	BlockDuration time.Duration
+}
+
+// URLPolicyConfig содержит правила проверки URL назначения.
+type URLPolicyConfig struct {
+	// AllowedSchemes - допустимые схемы через запятую
+	AllowedSchemes string
+	// MaxLength - максимальная длина URL в байтах; проверяется обработчиками и политикой
+	MaxLength int
+	// AllowPrivate - разрешить ссылки на частные сети и внутренние имена хостов
+	AllowPrivate bool
+	// ResolveDNS - проверять адреса, в которые разрешается имя хоста
+	ResolveDNS bool
+}
+
+// URLNormalizeConfig содержит необязательные шаги нормализации URL перед поиском дубликатов.
+type URLNormalizeConfig struct {
+	// SortQuery - сортировать параметры запроса по имени
+	SortQuery bool
+	// StripTracking - удалять параметры отслеживания
+	StripTracking bool
+	// TrackingParams - шаблоны параметров отслеживания через запятую; если пусто, используются стандартные
+	TrackingParams string
+}
+
+// DomainListConfig содержит источники списков доменов.
+// Источник - путь к файлу или URL; несколько источников перечисляются через запятую.
+type DomainListConfig struct {
+	// Blocklist - источники запрещенных доменов
+	Blocklist string
+	// Allowlist - источники разрешенных доменов; если заданы, разрешены только эти домены
+	Allowlist string
+	// ReloadInterval - период проверки источников на изменения
+	ReloadInterval time.Duration
+	// CheckOnRedirect - проверять домен при переходе по короткой ссылке
+	CheckOnRedirect bool
+}
+
+// RedirectConfig содержит настройки ответа на переход по короткой ссылке.
+type RedirectConfig struct {
+	// Status - код перенаправления для ссылок без собственного (301, 302, 307 или 308)
+	Status int
+	// CacheMaxAge - сколько браузеры и CDN могут хранить постоянные (301, 308) перенаправления
+	CacheMaxAge time.Duration
+	// ComingSoonURL - куда перенаправлять переходы по ссылкам, которые еще не начали действовать
+	ComingSoonURL string
+	// ComingSoonPage - путь к HTML-странице для таких переходов, если ComingSoonURL не задан
+	ComingSoonPage string
+}
+
+// LinkPasswordConfig содержит настройки перехода по ссылкам, защищенным паролем.
+type LinkPasswordConfig struct {
+	// CookieSecret - ключ подписи cookie доступа; если пуст, ключ создается при запуске,
+	// и выданные cookie перестают действовать после перезапуска
+	CookieSecret string
+	// CookieTTL - срок действия cookie доступа после ввода пароля
+	CookieTTL time.Duration
+	// MaxAttempts - число попыток ввода пароля одной ссылки с одного адреса подряд
+	MaxAttempts int
+	// AttemptWindow - за какое время попытки восстанавливаются полностью
+	AttemptWindow time.Duration
+	// MaxBatch - сколько ссылок с паролем можно создать одним пакетным запросом
+	// (хеширование пароля затратно); 0 - без ограничения
+	MaxBatch int
 }
 
 // Config содержит настройки приложения.
@@ -53,6 +305,22 @@ type Config struct {
 	Database DatabaseConfig
 	// Audit - настройки системы аудита
 	Audit AuditConfig
+	// Admin - настройки административного API
+	Admin AdminConfig
+	// RateLimit - настройки ограничения частоты запросов
+	RateLimit RateLimitConfig
+	// EnumGuard - настройки обнаружения перебора коротких кодов
+	EnumGuard EnumGuardConfig
+	// URLPolicy - правила проверки URL назначения
+	URLPolicy URLPolicyConfig
+	// URLNormalize - настройки нормализации URL
+	URLNormalize URLNormalizeConfig
+	// DomainList - списки запрещенных и разрешенных доменов
+	DomainList DomainListConfig
+	// Redirect - настройки перенаправления по короткой ссылке
+	Redirect RedirectConfig
+	// LinkPassword - настройки ссылок, защищенных паролем
+	LinkPassword LinkPasswordConfig
 }
 
 // New создает новый экземпляр конфигурации с заданными параметрами.
@@ -83,19 +351,144 @@ func New(serverAddr, baseURL, fileStoragePath, databaseDSN, auditFile, auditURL
 //	-b: базовый URL (по умолчанию "http://localhost:8080")
 //	-f: путь к файлу хранилища
 //	-d: DSN для PostgreSQL
+//	-dedup-scope: область поиска дубликатов (global, per_user, none)
 //	-audit-file: путь к файлу аудита
 //	-audit-url: URL удаленного сервера аудита
+//	-audit-queue-size: размер очереди событий для каждого наблюдателя
+//	-audit-http-batch-size: максимальное число событий в одном запросе
+//	-audit-http-flush-interval: максимальная задержка отправки пакета
+//	-audit-http-max-retries: число повторных попыток отправки
+//	-audit-http-dead-letter: файл для недоставленных событий
+//	-audit-http-close-timeout: время на отправку событий при остановке
+//	-audit-file-max-size: размер файла аудита в байтах для ротации
+//	-audit-file-rotate-interval: период ротации файла аудита
+//	-audit-file-compress: сжимать ротированные файлы аудита
+//	-audit-file-max-files: число хранимых ротированных файлов аудита
+//	-audit-file-max-age: срок хранения ротированных файлов аудита
+//	-audit-file-fsync-interval: период синхронизации файла аудита с диском
+//	-audit-file-chain-key: секрет HMAC для цепочки записей аудита
+//	-audit-syslog-network: транспорт syslog (udp, tcp, unix, unixgram)
+//	-audit-syslog-address: адрес syslog-сервера или путь к Unix-сокету
+//	-audit-syslog-app-name: имя приложения в сообщениях syslog
+//	-audit-file-filter, -audit-http-filter, -audit-syslog-filter: фильтры событий наблюдателей
+//	-audit-store: хранилище для поиска событий аудита (postgres или file)
+//	-audit-store-path: путь к файлу хранилища событий аудита
+//	-admin-token: токен доступа к административному API
+//	-rate-limit-key: ключ подсчета запросов (user, ip, api_key)
+//	-rate-limit-api-key-header: заголовок с API-ключом
+//	-rate-limit-api-keys: известные API-ключи через запятую
+//	-rate-limit-backend: хранилище счетчиков (memory, postgres)
+//	-rate-limit-{create,redirect,list}-rps: допустимое число запросов в секунду для группы
+//	-rate-limit-{create,redirect,list}-burst: максимальное число запросов подряд для группы
+//	-enum-guard: включить обнаружение перебора коротких кодов
+//	-enum-guard-window, -enum-guard-min-requests, -enum-guard-delay-ratio, -enum-guard-block-ratio,
+//	-enum-guard-max-delay, -enum-guard-block-duration: пороги обнаружения перебора
+//	-url-allowed-schemes: допустимые схемы URL назначения через запятую
+//	-url-max-length: максимальная длина URL назначения
+//	-url-allow-private: разрешить ссылки на частные сети и внутренние имена хостов
+//	-url-resolve-dns: проверять адреса, в которые разрешается имя хоста
+//	-url-sort-query: сортировать параметры запроса при поиске дубликатов
+//	-url-strip-tracking: удалять параметры отслеживания при поиске дубликатов
+//	-url-tracking-params: шаблоны параметров отслеживания через запятую (например, utm_*,fbclid)
+//	-domain-blocklist, -domain-allowlist: файлы или URL списков доменов через запятую
+//	-domain-list-reload-interval: период перечитывания списков доменов
+//	-domain-list-check-redirect: проверять домен при переходе по короткой ссылке
+//	-redirect-status: код перенаправления по умолчанию (301, 302, 307, 308)
+//	-redirect-cache-max-age: время кеширования постоянных перенаправлений
+//	-coming-soon-url: адрес перенаправления для ссылок, которые еще не начали действовать
+//	-coming-soon-page: HTML-страница для ссылок, которые еще не начали действовать
+//	-link-password-secret: ключ подписи cookie доступа к ссылкам с паролем
+//	-link-password-cookie-ttl: срок действия cookie доступа
+//	-link-password-max-attempts, -link-password-attempt-window: ограничение попыток ввода пароля
+//	-link-password-max-batch: число ссылок с паролем в одном пакетном запросе
 //
 // Поддерживаемые переменные окружения:
 //
-//	SERVER_ADDRESS, BASE_URL, FILE_STORAGE_PATH, DATABASE_DSN, AUDIT_FILE, AUDIT_URL
+//	SERVER_ADDRESS, BASE_URL, FILE_STORAGE_PATH, DATABASE_DSN, DEDUP_SCOPE, AUDIT_FILE, AUDIT_URL,
+//	AUDIT_QUEUE_SIZE, AUDIT_HTTP_BATCH_SIZE, AUDIT_HTTP_FLUSH_INTERVAL,
+//	AUDIT_HTTP_MAX_RETRIES, AUDIT_HTTP_DEAD_LETTER, AUDIT_HTTP_CLOSE_TIMEOUT,
+//	AUDIT_FILE_MAX_SIZE, AUDIT_FILE_ROTATE_INTERVAL, AUDIT_FILE_COMPRESS,
+//	AUDIT_FILE_MAX_FILES, AUDIT_FILE_MAX_AGE, AUDIT_FILE_FSYNC_INTERVAL,
+//	AUDIT_FILE_CHAIN_KEY, AUDIT_SYSLOG_NETWORK, AUDIT_SYSLOG_ADDRESS, AUDIT_SYSLOG_APP_NAME,
+//	AUDIT_FILE_FILTER, AUDIT_HTTP_FILTER, AUDIT_SYSLOG_FILTER,
+//	AUDIT_STORE, AUDIT_STORE_PATH, ADMIN_TOKEN,
+//	RATE_LIMIT_KEY, RATE_LIMIT_API_KEY_HEADER, RATE_LIMIT_API_KEYS, RATE_LIMIT_BACKEND,
+//	RATE_LIMIT_{CREATE,REDIRECT,LIST}_RPS, RATE_LIMIT_{CREATE,REDIRECT,LIST}_BURST,
+//	ENUM_GUARD, ENUM_GUARD_WINDOW, ENUM_GUARD_MIN_REQUESTS, ENUM_GUARD_DELAY_RATIO,
+//	ENUM_GUARD_BLOCK_RATIO, ENUM_GUARD_MAX_DELAY, ENUM_GUARD_BLOCK_DURATION,
+//	URL_ALLOWED_SCHEMES, URL_MAX_LENGTH, URL_ALLOW_PRIVATE, URL_RESOLVE_DNS,
+//	URL_SORT_QUERY, URL_STRIP_TRACKING, URL_TRACKING_PARAMS,
+//	DOMAIN_BLOCKLIST, DOMAIN_ALLOWLIST, DOMAIN_LIST_RELOAD_INTERVAL, DOMAIN_LIST_CHECK_REDIRECT,
+//	REDIRECT_STATUS, REDIRECT_CACHE_MAX_AGE, COMING_SOON_URL, COMING_SOON_PAGE,
+//	LINK_PASSWORD_SECRET, LINK_PASSWORD_COOKIE_TTL, LINK_PASSWORD_MAX_ATTEMPTS, LINK_PASSWORD_ATTEMPT_WINDOW,
+//	LINK_PASSWORD_MAX_BATCH
 func ParseFlags() Config {
 	serverAddr := flag.String("a", ":8080", "HTTP server address (e.g. localhost:8888)")
 	baseURL := flag.String("b", "http://localhost:8080", "base URL")
 	fileStoragePath := flag.String("f", "/var/lib/url-shorter/short-url-db.json", "file storage path")
 	databaseDSN := flag.String("d", "", "database connection string")
+	dedupScope := flag.String("dedup-scope", "global", "duplicate URL scope: global, per_user or none")
 	auditFile := flag.String("audit-file", "", "path to audit log file")
 	auditURL := flag.String("audit-url", "", "URL of remote audit server")
+	auditQueueSize := flag.Int("audit-queue-size", 1024, "audit event queue size per observer")
+	auditHTTPBatchSize := flag.Int("audit-http-batch-size", 100, "max audit events per HTTP request")
+	auditHTTPFlushInterval := flag.Duration("audit-http-flush-interval", time.Second, "max delay before sending a partial audit batch")
+	auditHTTPMaxRetries := flag.Int("audit-http-max-retries", 3, "retries for a failed audit batch")
+	auditHTTPDeadLetter := flag.String("audit-http-dead-letter", "", "path to dead letter file for undelivered audit events")
+	auditHTTPCloseTimeout := flag.Duration("audit-http-close-timeout", 5*time.Second, "time to flush audit events on shutdown")
+	auditFileMaxSize := flag.Int64("audit-file-max-size", 0, "audit file size in bytes that triggers rotation (0 - disabled)")
+	auditFileRotateInterval := flag.Duration("audit-file-rotate-interval", 0, "audit file rotation period (0 - disabled)")
+	auditFileCompress := flag.Bool("audit-file-compress", false, "gzip rotated audit files")
+	auditFileMaxFiles := flag.Int("audit-file-max-files", 0, "max rotated audit files to keep (0 - unlimited)")
+	auditFileMaxAge := flag.Duration("audit-file-max-age", 0, "max age of rotated audit files (0 - unlimited)")
+	auditFileFsyncInterval := flag.Duration("audit-file-fsync-interval", time.Second, "audit file flush and fsync period")
+	auditFileChainKey := flag.String("audit-file-chain-key", "", "HMAC secret for tamper-evident audit records")
+	auditSyslogNetwork := flag.String("audit-syslog-network", "udp", "syslog transport: udp, tcp, unix or unixgram")
+	auditSyslogAddress := flag.String("audit-syslog-address", "", "syslog server address or unix socket path")
+	auditSyslogAppName := flag.String("audit-syslog-app-name", "url-shorter", "application name in syslog messages")
+	auditFileFilter := flag.String("audit-file-filter", "", "audit file observer filter, e.g. actions=shorten,follow;sample=follow:0.1")
+	auditHTTPFilter := flag.String("audit-http-filter", "", "audit HTTP observer filter")
+	auditSyslogFilter := flag.String("audit-syslog-filter", "", "audit syslog observer filter")
+	auditStore := flag.String("audit-store", "", "queryable audit store: postgres or file")
+	auditStorePath := flag.String("audit-store-path", "", "path to file audit store")
+	adminToken := flag.String("admin-token", "", "bearer token for admin API")
+	rateLimitKey := flag.String("rate-limit-key", "user", "rate limit key: user, ip or api_key")
+	rateLimitAPIKeyHeader := flag.String("rate-limit-api-key-header", "X-API-Key", "header with API key for rate limiting")
+	rateLimitAPIKeys := flag.String("rate-limit-api-keys", "", "comma-separated API keys counted separately for rate limiting")
+	rateLimitBackend := flag.String("rate-limit-backend", "memory", "rate limit counters backend: memory or postgres")
+	rateLimitCreateRate := flag.Float64("rate-limit-create-rps", 0, "allowed create requests per second (0 - unlimited)")
+	rateLimitCreateBurst := flag.Int("rate-limit-create-burst", 0, "max create requests in a burst")
+	rateLimitRedirectRate := flag.Float64("rate-limit-redirect-rps", 0, "allowed redirects per second (0 - unlimited)")
+	rateLimitRedirectBurst := flag.Int("rate-limit-redirect-burst", 0, "max redirects in a burst")
+	rateLimitListRate := flag.Float64("rate-limit-list-rps", 0, "allowed listing requests per second (0 - unlimited)")
+	rateLimitListBurst := flag.Int("rate-limit-list-burst", 0, "max listing requests in a burst")
+	enumGuardEnabled := flag.Bool("enum-guard", false, "detect and block short code enumeration")
+	enumGuardWindow := flag.Duration("enum-guard-window", time.Minute, "sliding window for not-found ratio")
+	enumGuardMinRequests := flag.Int("enum-guard-min-requests", 20, "min requests in window before a client is judged")
+	enumGuardDelayRatio := flag.Float64("enum-guard-delay-ratio", 0.5, "not-found ratio that starts delaying responses")
+	enumGuardBlockRatio := flag.Float64("enum-guard-block-ratio", 0.8, "not-found ratio that blocks a client")
+	enumGuardMaxDelay := flag.Duration("enum-guard-max-delay", 2*time.Second, "max delay of a not-found response")
+	enumGuardBlockDuration := flag.Duration("enum-guard-block-duration", 10*time.Minute, "how long a probing client is blocked")
+	urlAllowedSchemes := flag.String("url-allowed-schemes", "http,https", "comma-separated allowed destination URL schemes")
+	urlMaxLength := flag.Int("url-max-length", 8192, "max destination URL length in bytes (0 - service default)")
+	urlAllowPrivate := flag.Bool("url-allow-private", false, "allow destination URLs on private networks and internal hosts")
+	urlResolveDNS := flag.Bool("url-resolve-dns", false, "resolve destination hosts and reject private addresses")
+	urlSortQuery := flag.Bool("url-sort-query", false, "sort query parameters before duplicate lookup")
+	urlStripTracking := flag.Bool("url-strip-tracking", false, "strip tracking query parameters before duplicate lookup")
+	urlTrackingParams := flag.String("url-tracking-params", "", "comma-separated tracking parameter patterns (default utm_*,fbclid,gclid,...)")
+	domainBlocklist := flag.String("domain-blocklist", "", "comma-separated files or URLs with blocked domains")
+	domainAllowlist := flag.String("domain-allowlist", "", "comma-separated files or URLs with the only allowed domains")
+	domainListReloadInterval := flag.Duration("domain-list-reload-interval", time.Minute, "how often domain lists are checked for changes (0 - never)")
+	domainListCheckRedirect := flag.Bool("domain-list-check-redirect", false, "re-check domain lists when following a short link")
+	redirectStatus := flag.Int("redirect-status", 307, "default redirect status code: 301, 302, 307 or 308")
+	redirectCacheMaxAge := flag.Duration("redirect-cache-max-age", 24*time.Hour, "how long permanent redirects may be cached")
+	comingSoonURL := flag.String("coming-soon-url", "", "fallback URL for links that are not active yet")
+	comingSoonPage := flag.String("coming-soon-page", "", "HTML page served for links that are not active yet")
+	linkPasswordSecret := flag.String("link-password-secret", "", "key for signing access cookies of password-protected links (random if empty)")
+	linkPasswordCookieTTL := flag.Duration("link-password-cookie-ttl", time.Hour, "how long a link stays unlocked after the password is entered")
+	linkPasswordMaxAttempts := flag.Int("link-password-max-attempts", 5, "password attempts per link and client in a burst")
+	linkPasswordMaxBatch := flag.Int("link-password-max-batch", 10, "max password-protected links in one batch request (0 - unlimited)")
+	linkPasswordAttemptWindow := flag.Duration("link-password-attempt-window", 15*time.Minute, "time to regain all password attempts")
 	flag.Parse()
 
 	finalServerAddr := *serverAddr
@@ -128,5 +521,164 @@ func ParseFlags() Config {
 		finalAuditURL = envAuditURL
 	}
 
-	return New(finalServerAddr, finalBaseURL, finalFileStoragePath, finalDatabaseDSN, finalAuditFile, finalAuditURL)
+	cfg := New(finalServerAddr, finalBaseURL, finalFileStoragePath, finalDatabaseDSN, finalAuditFile, finalAuditURL)
+
+	cfg.Storage.DedupScope = envString(dedupScopeEnv, *dedupScope)
+	cfg.Audit.QueueSize = envInt(auditQueueSizeEnv, *auditQueueSize)
+	cfg.Audit.HTTP = AuditHTTPConfig{
+		BatchSize:      envInt(auditHTTPBatchSizeEnv, *auditHTTPBatchSize),
+		FlushInterval:  envDuration(auditHTTPFlushIntervalEnv, *auditHTTPFlushInterval),
+		MaxRetries:     envInt(auditHTTPMaxRetriesEnv, *auditHTTPMaxRetries),
+		DeadLetterPath: envString(auditHTTPDeadLetterEnv, *auditHTTPDeadLetter),
+		CloseTimeout:   envDuration(auditHTTPCloseTimeoutEnv, *auditHTTPCloseTimeout),
+		Filter:         envString(auditHTTPFilterEnv, *auditHTTPFilter),
+	}
+	cfg.Audit.File = AuditFileConfig{
+		MaxSize:        envInt64(auditFileMaxSizeEnv, *auditFileMaxSize),
+		RotateInterval: envDuration(auditFileRotateIntervalEnv, *auditFileRotateInterval),
+		Compress:       envBool(auditFileCompressEnv, *auditFileCompress),
+		MaxFiles:       envInt(auditFileMaxFilesEnv, *auditFileMaxFiles),
+		MaxAge:         envDuration(auditFileMaxAgeEnv, *auditFileMaxAge),
+		FsyncInterval:  envDuration(auditFileFsyncIntervalEnv, *auditFileFsyncInterval),
+		ChainKey:       envString(auditFileChainKeyEnv, *auditFileChainKey),
+		Filter:         envString(auditFileFilterEnv, *auditFileFilter),
+	}
+	cfg.Audit.Syslog = AuditSyslogConfig{
+		Network: envString(auditSyslogNetworkEnv, *auditSyslogNetwork),
+		Address: envString(auditSyslogAddressEnv, *auditSyslogAddress),
+		AppName: envString(auditSyslogAppNameEnv, *auditSyslogAppName),
+		Filter:  envString(auditSyslogFilterEnv, *auditSyslogFilter),
+	}
+	cfg.Audit.Store = envString(auditStoreEnv, *auditStore)
+	cfg.Audit.StorePath = envString(auditStorePathEnv, *auditStorePath)
+	cfg.Admin.Token = envString(adminTokenEnv, *adminToken)
+	cfg.RateLimit = RateLimitConfig{
+		Key:          envString(rateLimitKeyEnv, *rateLimitKey),
+		APIKeyHeader: envString(rateLimitAPIKeyHeaderEnv, *rateLimitAPIKeyHeader),
+		APIKeys:      envString(rateLimitAPIKeysEnv, *rateLimitAPIKeys),
+		Backend:      envString(rateLimitBackendEnv, *rateLimitBackend),
+		Create: RateLimitRule{
+			Rate:  envFloat(rateLimitCreateRateEnv, *rateLimitCreateRate),
+			Burst: envInt(rateLimitCreateBurstEnv, *rateLimitCreateBurst),
+		},
+		Redirect: RateLimitRule{
+			Rate:  envFloat(rateLimitRedirectRateEnv, *rateLimitRedirectRate),
+			Burst: envInt(rateLimitRedirectBurstEnv, *rateLimitRedirectBurst),
+		},
+		List: RateLimitRule{
+			Rate:  envFloat(rateLimitListRateEnv, *rateLimitListRate),
+			Burst: envInt(rateLimitListBurstEnv, *rateLimitListBurst),
+		},
+	}
+
+	cfg.EnumGuard = EnumGuardConfig{
+		Enabled:       envBool(enumGuardEnabledEnv, *enumGuardEnabled),
+		Window:        envDuration(enumGuardWindowEnv, *enumGuardWindow),
+		MinRequests:   envInt(enumGuardMinRequestsEnv, *enumGuardMinRequests),
+		DelayRatio:    envFloat(enumGuardDelayRatioEnv, *enumGuardDelayRatio),
+		BlockRatio:    envFloat(enumGuardBlockRatioEnv, *enumGuardBlockRatio),
+		MaxDelay:      envDuration(enumGuardMaxDelayEnv, *enumGuardMaxDelay),
+		BlockDuration: envDuration(enumGuardBlockDurationEnv, *enumGuardBlockDuration),
+	}
+	cfg.URLPolicy = URLPolicyConfig{
+		AllowedSchemes: envString(urlAllowedSchemesEnv, *urlAllowedSchemes),
+		MaxLength:      envInt(urlMaxLengthEnv, *urlMaxLength),
+		AllowPrivate:   envBool(urlAllowPrivateEnv, *urlAllowPrivate),
+		ResolveDNS:     envBool(urlResolveDNSEnv, *urlResolveDNS),
+	}
+	cfg.URLNormalize = URLNormalizeConfig{
+		SortQuery:      envBool(urlSortQueryEnv, *urlSortQuery),
+		StripTracking:  envBool(urlStripTrackingEnv, *urlStripTracking),
+		TrackingParams: envString(urlTrackingParamsEnv, *urlTrackingParams),
+	}
+	cfg.DomainList = DomainListConfig{
+		Blocklist:       envString(domainBlocklistEnv, *domainBlocklist),
+		Allowlist:       envString(domainAllowlistEnv, *domainAllowlist),
+		ReloadInterval:  envDuration(domainListReloadIntervalEnv, *domainListReloadInterval),
+		CheckOnRedirect: envBool(domainListCheckRedirectEnv, *domainListCheckRedirect),
+	}
+	cfg.Redirect = RedirectConfig{
+		Status:         envInt(redirectStatusEnv, *redirectStatus),
+		CacheMaxAge:    envDuration(redirectCacheMaxAgeEnv, *redirectCacheMaxAge),
+		ComingSoonURL:  envString(comingSoonURLEnv, *comingSoonURL),
+		ComingSoonPage: envString(comingSoonPageEnv, *comingSoonPage),
+	}
+	cfg.LinkPassword = LinkPasswordConfig{
+		CookieSecret:  envString(linkPasswordSecretEnv, *linkPasswordSecret),
+		CookieTTL:     envDuration(linkPasswordCookieTTLEnv, *linkPasswordCookieTTL),
+		MaxAttempts:   envInt(linkPasswordMaxAttemptsEnv, *linkPasswordMaxAttempts),
+		AttemptWindow: envDuration(linkPasswordAttemptWindowEnv, *linkPasswordAttemptWindow),
+		MaxBatch:      envInt(linkPasswordMaxBatchEnv, *linkPasswordMaxBatch),
+	}
+
+	return cfg
+}
+
+// envString возвращает значение переменной окружения или значение флага, если переменная не задана.
+func envString(name, value string) string {
+	if env, ok := os.LookupEnv(name); ok {
+		return env
+	}
+
+	return value
+}
+
+// envInt возвращает целочисленное значение переменной окружения
+// или значение флага, если переменная не задана или некорректна.
+func envInt(name string, value int) int {
+	if env, ok := os.LookupEnv(name); ok {
+		if parsed, err := strconv.Atoi(env); err == nil {
+			return parsed
+		}
+	}
+
+	return value
+}
+
+// envInt64 возвращает целочисленное значение переменной окружения
+// или значение флага, если переменная не задана или некорректна.
+func envInt64(name string, value int64) int64 {
+	if env, ok := os.LookupEnv(name); ok {
+		if parsed, err := strconv.ParseInt(env, 10, 64); err == nil {
+			return parsed
+		}
+	}
+
+	return value
+}
+
+// envFloat возвращает дробное значение переменной окружения
+// или значение флага, если переменная не задана или некорректна.
+func envFloat(name string, value float64) float64 {
+	if env, ok := os.LookupEnv(name); ok {
+		if parsed, err := strconv.ParseFloat(env, 64); err == nil {
+			return parsed
+		}
+	}
+
+	return value
+}
+
+// envBool возвращает логическое значение переменной окружения
+// или значение флага, если переменная не задана или некорректна.
+func envBool(name string, value bool) bool {
+	if env, ok := os.LookupEnv(name); ok {
+		if parsed, err := strconv.ParseBool(env); err == nil {
+			return parsed
+		}
+	}
+
+	return value
+}
+
+// envDuration возвращает длительность из переменной окружения
+// или значение флага, если переменная не задана или некорректна.
+func envDuration(name string, value time.Duration) time.Duration {
+	if env, ok := os.LookupEnv(name); ok {
+		if parsed, err := time.ParseDuration(env); err == nil {
+			return parsed
+		}
+	}
+
+	return value
 }
@@ -0,0 +1,219 @@
+// Package enumguard обнаруживает перебор коротких кодов.
+// Для каждого клиента считается доля запросов несуществующих кодов в скользящем окне.
+// При превышении первого порога ответы на промахи замедляются, при превышении второго
+// клиент временно блокируется. Клиенты с высокой долей попаданий не затрагиваются.
+package enumguard
+
+import (
+	"sync"
+	"time"
+)
+
+// slots - число интервалов, на которые делится скользящее окно.
+const slots = 10
+
+// Options содержит пороги обнаружения перебора.
+// Нулевые значения заменяются значениями по умолчанию.
+type Options struct {
+	// Window - длительность скользящего окна
+	Window time.Duration
+	// MinRequests - минимальное число запросов в окне, после которого учитывается доля промахов
+	MinRequests int
+	// DelayRatio - доля промахов, начиная с которой ответы на промахи замедляются
+	DelayRatio float64
+	// BlockRatio - доля промахов, при которой клиент блокируется
+	BlockRatio float64
+	// MaxDelay - задержка ответа при доле промахов, близкой к BlockRatio
+	MaxDelay time.Duration
+	// BlockDuration - длительность блокировки
+	BlockDuration time.Duration
+}
+
+// DefaultOptions возвращает пороги по умолчанию.
+func DefaultOptions() Options {
+	return Options{
+		Window:        time.Minute,
+		MinRequests:   20,
+		DelayRatio:    0.5,
+		BlockRatio:    0.8,
+		MaxDelay:      2 * time.Second,
+		BlockDuration: 10 * time.Minute,
+	}
+}
+
+func (o Options) withDefaults() Options {
+	d := DefaultOptions()
+
+	if o.Window <= 0 {
+		o.Window = d.Window
+	}
+	if o.MinRequests <= 0 {
+		o.MinRequests = d.MinRequests
+	}
+	if o.DelayRatio <= 0 {
+		o.DelayRatio = d.DelayRatio
+	}
+	if o.BlockRatio <= 0 {
+		o.BlockRatio = d.BlockRatio
+	}
+	if o.BlockRatio < o.DelayRatio {
+		o.BlockRatio = o.DelayRatio
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = d.MaxDelay
+	}
+	if o.BlockDuration <= 0 {
+		o.BlockDuration = d.BlockDuration
+	}
+
+	return o
+}
+
+// Verdict - решение по клиенту.
+type Verdict struct {
+	// Blocked - клиент заблокирован
+	Blocked bool
+	// NewlyBlocked - блокировка началась на этом запросе
+	NewlyBlocked bool
+	// RetryAfter - время до снятия блокировки
+	RetryAfter time.Duration
+	// Delay - задержка ответа на промах
+	Delay time.Duration
+}
+
+// slot - счетчики запросов за один интервал окна.
+type slot struct {
+	start  time.Time
+	hits   int
+	misses int
+}
+
+type client struct {
+	slots        [slots]slot
+	blockedUntil time.Time
+	lastSeen     time.Time
+}
+
+// Guard отслеживает долю промахов по клиентам.
+type Guard struct {
+	opts      Options
+	clients   map[string]*client
+	lastSweep time.Time
+	now       func() time.Time
+	mu        *sync.Mutex
+}
+
+// New создает новый детектор перебора.
+func New(opts Options) *Guard {
+	return &Guard{
+		opts:    opts.withDefaults(),
+		clients: make(map[string]*client),
+		now:     time.Now,
+		mu:      &sync.Mutex{},
+	}
+}
+
+// Check проверяет, заблокирован ли клиент, до обработки запроса.
+func (g *Guard) Check(key string) Verdict {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	c, ok := g.clients[key]
+	if !ok || !now.Before(c.blockedUntil) {
+		return Verdict{}
+	}
+
+	return Verdict{Blocked: true, RetryAfter: c.blockedUntil.Sub(now)}
+}
+
+// Record учитывает результат запроса клиента и возвращает решение:
+// задержку ответа на промах или начало блокировки.
+func (g *Guard) Record(key string, found bool) Verdict {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.sweep(now)
+
+	c, ok := g.clients[key]
+	if !ok {
+		c = &client{}
+		g.clients[key] = c
+	}
+	c.lastSeen = now
+
+	if now.Before(c.blockedUntil) {
+		return Verdict{Blocked: true, RetryAfter: c.blockedUntil.Sub(now)}
+	}
+
+	current := g.slot(c, now)
+	if found {
+		current.hits++
+
+		return Verdict{}
+	}
+	current.misses++
+
+	hits, misses := g.count(c, now)
+	total := hits + misses
+	if total < g.opts.MinRequests {
+		return Verdict{}
+	}
+
+	ratio := float64(misses) / float64(total)
+
+	switch {
+	case ratio >= g.opts.BlockRatio:
+		c.blockedUntil = now.Add(g.opts.BlockDuration)
+		c.slots = [slots]slot{}
+
+		return Verdict{Blocked: true, NewlyBlocked: true, RetryAfter: g.opts.BlockDuration}
+	case ratio > g.opts.DelayRatio:
+		// Задержка растет линейно от 0 при DelayRatio до MaxDelay при BlockRatio.
+		share := (ratio - g.opts.DelayRatio) / (g.opts.BlockRatio - g.opts.DelayRatio)
+
+		return Verdict{Delay: time.Duration(share * float64(g.opts.MaxDelay))}
+	}
+
+	return Verdict{}
+}
+
+// slot возвращает счетчики текущего интервала, сбрасывая устаревшие.
+func (g *Guard) slot(c *client, now time.Time) *slot {
+	width := g.opts.Window / slots
+	start := now.Truncate(width)
+
+	s := &c.slots[(start.UnixNano()/int64(width))%slots]
+	if !s.start.Equal(start) {
+		*s = slot{start: start}
+	}
+
+	return s
+}
+
+// count суммирует счетчики интервалов, попадающих в окно.
+func (g *Guard) count(c *client, now time.Time) (hits, misses int) {
+	for _, s := range c.slots {
+		if now.Sub(s.start) < g.opts.Window {
+			hits += s.hits
+			misses += s.misses
+		}
+	}
+
+	return hits, misses
+}
+
+// sweep удаляет клиентов без активности и действующей блокировки. Вызывается под мьютексом.
+func (g *Guard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < g.opts.Window {
+		return
+	}
+	g.lastSweep = now
+
+	for key, c := range g.clients {
+		if now.Sub(c.lastSeen) >= g.opts.Window && !now.Before(c.blockedUntil) {
+			delete(g.clients, key)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package enumguard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testGuard(now *time.Time) *Guard {
+	g := New(Options{
+		Window:        time.Minute,
+		MinRequests:   10,
+		DelayRatio:    0.5,
+		BlockRatio:    0.8,
+		MaxDelay:      time.Second,
+		BlockDuration: 5 * time.Minute,
+	})
+	g.now = func() time.Time { return *now }
+
+	return g
+}
+
+func TestGuardRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		hits   int
+		misses int
+		want   Verdict
+	}{
+		{
+			name:   "few requests are not judged",
+			misses: 9,
+			want:   Verdict{},
+		},
+		{
+			name:   "high hit rate is unaffected",
+			hits:   90,
+			misses: 10,
+			want:   Verdict{},
+		},
+		{
+			name:   "ratio between thresholds delays misses",
+			hits:   35,
+			misses: 65,
+			want:   Verdict{Delay: 500 * time.Millisecond},
+		},
+		{
+			name:   "ratio above block threshold blocks",
+			hits:   2,
+			misses: 8,
+			want:   Verdict{Blocked: true, NewlyBlocked: true, RetryAfter: 5 * time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			g := testGuard(&now)
+
+			for i := 0; i < tt.hits; i++ {
+				g.Record("client", true)
+			}
+
+			var got Verdict
+			for i := 0; i < tt.misses; i++ {
+				got = g.Record("client", false)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGuardBlockExpires(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := testGuard(&now)
+
+	for i := 0; i < 10; i++ {
+		g.Record("scanner", false)
+	}
+
+	assert.True(t, g.Check("scanner").Blocked)
+	assert.False(t, g.Check("other").Blocked)
+
+	now = now.Add(5 * time.Minute)
+	assert.False(t, g.Check("scanner").Blocked)
+	assert.Equal(t, Verdict{}, g.Record("scanner", false), "counters are reset after a block")
+}
+
+func TestGuardWindowSlides(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := testGuard(&now)
+
+	for i := 0; i < 7; i++ {
+		g.Record("client", false)
+	}
+
+	// Промахи вышли за пределы окна и больше не учитываются.
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, Verdict{}, g.Record("client", false))
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+)
+
+// AdminAuditHandler обрабатывает запрос на поиск событий аудита.
+// Параметры запроса: action, user_id, url (подстрока), from и to (RFC 3339), cursor, limit.
+// Требует заголовок Authorization: Bearer <токен администратора>.
+func (h *handler) AdminAuditHandler(w http.ResponseWriter, r *http.Request) {
+	if h.auditStore == nil {
+		h.writeProblem(w, r, errAuditNotConfigured)
+
+		return
+	}
+
+	if !h.isAdmin(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		h.writeProblem(w, r, errAdminUnauthorized)
+
+		return
+	}
+
+	query, err := parseAuditQuery(r)
+	if err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	page, err := h.auditStore.Query(r.Context(), query)
+	if err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		h.log(r.Context()).Error("Failed to encode response: " + err.Error())
+	}
+}
+
+// isAdmin сравнивает токен из заголовка Authorization с токеном администратора
+// за постоянное время.
+func (h *handler) isAdmin(r *http.Request) bool {
+	if h.adminToken == "" {
+		return false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
+
+// parseAuditQuery разбирает параметры поиска событий аудита.
+func parseAuditQuery(r *http.Request) (audit.Query, error) {
+	values := r.URL.Query()
+
+	query := audit.Query{
+		Action:      audit.Action(values.Get("action")),
+		UserID:      values.Get("user_id"),
+		URLContains: values.Get("url"),
+		Cursor:      values.Get("cursor"),
+	}
+
+	var err error
+	if query.From, err = parseQueryTime(values.Get("from")); err != nil {
+		return audit.Query{}, newAPIError(http.StatusBadRequest, codeInvalidQuery, "from must be an RFC 3339 timestamp")
+	}
+	if query.To, err = parseQueryTime(values.Get("to")); err != nil {
+		return audit.Query{}, newAPIError(http.StatusBadRequest, codeInvalidQuery, "to must be an RFC 3339 timestamp")
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit <= 0 || query.Limit > audit.MaxQueryLimit {
+			return audit.Query{}, newAPIError(http.StatusBadRequest, codeInvalidQuery,
+				"limit must be between 1 and "+strconv.Itoa(audit.MaxQueryLimit))
+		}
+	}
+
+	return query, nil
+}
+
+func parseQueryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
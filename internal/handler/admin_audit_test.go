@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+	"github.com/MarkelovSergey/url-shorter/internal/config"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAdminAuditHandler(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := config.New("localhost:8080", "http://localhost:8080", "", "", "", "")
+	userID := "user-1"
+
+	store, err := audit.NewFileStore(filepath.Join(t.TempDir(), "audit-store.log"), logger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.OnEvent(audit.NewEvent(audit.ActionShorten, "https://example.com/a", &userID)))
+	require.NoError(t, store.OnEvent(audit.NewEvent(audit.ActionFollow, "https://example.com/a", nil)))
+	require.NoError(t, store.OnEvent(audit.NewEvent(audit.ActionShorten, "https://go.dev", &userID)))
+
+	tests := []struct {
+		name           string
+		store          audit.Store
+		authorization  string
+		query          string
+		expectedStatus int
+		expectedCode   string
+		expectedURLs   []string
+		expectCursor   bool
+	}{
+		{
+			name:           "store not configured",
+			authorization:  "Bearer secret",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   codeNotConfigured,
+		},
+		{
+			name:           "missing token",
+			store:          store,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   codeUnauthorized,
+		},
+		{
+			name:           "wrong token",
+			store:          store,
+			authorization:  "Bearer wrong",
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   codeUnauthorized,
+		},
+		{
+			name:           "filter by action and url",
+			store:          store,
+			authorization:  "Bearer secret",
+			query:          "?action=shorten&url=example.com",
+			expectedStatus: http.StatusOK,
+			expectedURLs:   []string{"https://example.com/a"},
+		},
+		{
+			name:           "pagination",
+			store:          store,
+			authorization:  "Bearer secret",
+			query:          "?user_id=user-1&limit=1",
+			expectedStatus: http.StatusOK,
+			expectedURLs:   []string{"https://go.dev"},
+			expectCursor:   true,
+		},
+		{
+			name:           "invalid time",
+			store:          store,
+			authorization:  "Bearer secret",
+			query:          "?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeInvalidQuery,
+		},
+		{
+			name:           "invalid cursor",
+			store:          store,
+			authorization:  "Bearer secret",
+			query:          "?cursor=%21%21",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.store != nil {
+				opts = append(opts, WithAuditStore(tt.store, "secret"))
+			}
+
+			h := New(cfg, new(urlshorterservice.MockURLShorterService), new(healthservice.MockHealthService),
+				logger, audit.NewMockPublisher(), opts...)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/audit"+tt.query, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			h.AdminAuditHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus != http.StatusOK {
+				var problem model.Problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedCode, problem.Code)
+
+				return
+			}
+
+			var page audit.Page
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+
+			urls := make([]string, 0, len(page.Events))
+			for _, event := range page.Events {
+				urls = append(urls, event.URL)
+			}
+
+			assert.Equal(t, tt.expectedURLs, urls)
+			assert.Equal(t, tt.expectCursor, page.NextCursor != "")
+		})
+	}
+}
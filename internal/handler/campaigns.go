@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/MarkelovSergey/url-shorter/internal/middleware"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/go-chi/chi/v5"
+)
+
+var errCampaignsNotConfigured = newAPIError(http.StatusNotFound, codeNotConfigured, "campaigns are not configured")
+
+// CreateCampaignHandler обрабатывает запрос на создание кампании: POST /api/user/campaigns.
+func (h *handler) CreateCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.campaignUser(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeCampaignRequest(w, r)
+	if !ok {
+		return
+	}
+
+	campaign, err := h.campaignService.Create(r.Context(), userID, req)
+	if err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusCreated, campaign)
+}
+
+// ListCampaignsHandler обрабатывает запрос на получение кампаний пользователя: GET /api/user/campaigns.
+func (h *handler) ListCampaignsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.campaignUser(w, r)
+	if !ok {
+		return
+	}
+
+	campaigns, err := h.campaignService.List(r.Context(), userID)
+	if err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	if len(campaigns) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, campaigns)
+}
+
+// GetCampaignHandler обрабатывает запрос на получение кампании: GET /api/user/campaigns/{id}.
+func (h *handler) GetCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.campaignUser(w, r)
+	if !ok {
+		return
+	}
+
+	campaign, err := h.campaignService.Get(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, campaign)
+}
+
+// UpdateCampaignHandler обрабатывает запрос на изменение кампании: PATCH /api/user/campaigns/{id}.
+// Не переданные поля не меняются.
+func (h *handler) UpdateCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.campaignUser(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeCampaignRequest(w, r)
+	if !ok {
+		return
+	}
+
+	campaign, err := h.campaignService.Update(r.Context(), userID, chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, campaign)
+}
+
+// DeleteCampaignHandler обрабатывает запрос на удаление кампании: DELETE /api/user/campaigns/{id}.
+// По умолчанию ссылки кампании остаются без кампании; с параметром delete_links=true
+// они удаляются так же, как через DELETE /api/user/urls.
+func (h *handler) DeleteCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.campaignUser(w, r)
+	if !ok {
+		return
+	}
+
+	var deleteLinks bool
+	if raw := r.URL.Query().Get("delete_links"); raw != "" {
+		var err error
+		deleteLinks, err = strconv.ParseBool(raw)
+		if err != nil {
+			h.writeProblem(w, r, newAPIError(http.StatusBadRequest, codeInvalidQuery, "invalid delete_links"))
+
+			return
+		}
+	}
+
+	if err := h.campaignService.Delete(r.Context(), userID, chi.URLParam(r, "id"), deleteLinks); err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CampaignStatsHandler обрабатывает запрос на получение статистики кампании:
+// GET /api/user/campaigns/{id}/stats.
+func (h *handler) CampaignStatsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.campaignUser(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.campaignService.Stats(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, stats)
+}
+
+// campaignUser проверяет, что кампании подключены, и возвращает идентификатор пользователя.
+// При ошибке ответ уже отправлен.
+func (h *handler) campaignUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if h.campaignService == nil {
+		h.writeProblem(w, r, errCampaignsNotConfigured)
+
+		return "", false
+	}
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok || userID == "" {
+		h.writeProblem(w, r, errUnauthorized)
+
+		return "", false
+	}
+
+	return userID, true
+}
+
+// decodeCampaignRequest читает свойства кампании из тела запроса.
+// При ошибке ответ уже отправлен.
+func (h *handler) decodeCampaignRequest(w http.ResponseWriter, r *http.Request) (model.CampaignRequest, bool) {
+	var req model.CampaignRequest
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		h.writeProblem(w, r, errUnsupportedMediaType)
+
+		return req, false
+	}
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeProblem(w, r, errInvalidJSON)
+
+		return req, false
+	}
+
+	return req, true
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+	"github.com/MarkelovSergey/url-shorter/internal/config"
+	"github.com/MarkelovSergey/url-shorter/internal/middleware"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/campaignservice"
+	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestCampaignHandlers(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+	userID := "test-user-123"
+	name := "Spring"
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	campaign := model.Campaign{ID: "c1", UserID: userID, Name: name, CreatedAt: createdAt, UpdatedAt: createdAt}
+	campaignJSON := `{"id":"c1","user_id":"test-user-123","name":"Spring",` +
+		`"created_at":"2025-03-01T10:00:00Z","updated_at":"2025-03-01T10:00:00Z"}`
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		serve          func(*handler) http.HandlerFunc
+		mockSetup      func(*campaignservice.MockCampaignService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "create",
+			method: http.MethodPost,
+			target: "/api/user/campaigns",
+			body:   `{"name":"Spring"}`,
+			serve:  func(h *handler) http.HandlerFunc { return h.CreateCampaignHandler },
+			mockSetup: func(m *campaignservice.MockCampaignService) {
+				m.EXPECT().Create(mock.Anything, userID, model.CampaignRequest{Name: &name}).Return(campaign, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   campaignJSON,
+		},
+		{
+			name:   "create without name",
+			method: http.MethodPost,
+			target: "/api/user/campaigns",
+			body:   `{}`,
+			serve:  func(h *handler) http.HandlerFunc { return h.CreateCampaignHandler },
+			mockSetup: func(m *campaignservice.MockCampaignService) {
+				m.EXPECT().Create(mock.Anything, userID, model.CampaignRequest{}).
+					Return(model.Campaign{}, service.NewValidationError("name", campaignservice.CodeNameRequired, "campaign name is required"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			target: "/api/user/campaigns",
+			serve:  func(h *handler) http.HandlerFunc { return h.ListCampaignsHandler },
+			mockSetup: func(m *campaignservice.MockCampaignService) {
+				m.EXPECT().List(mock.Anything, userID).Return([]model.Campaign{campaign}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + campaignJSON + "]",
+		},
+		{
+			name:   "empty list",
+			method: http.MethodGet,
+			target: "/api/user/campaigns",
+			serve:  func(h *handler) http.HandlerFunc { return h.ListCampaignsHandler },
+			mockSetup: func(m *campaignservice.MockCampaignService) {
+				m.EXPECT().List(mock.Anything, userID).Return(nil, nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "get foreign campaign",
+			method: http.MethodGet,
+			target: "/api/user/campaigns/c1",
+			serve:  func(h *handler) http.HandlerFunc { return h.GetCampaignHandler },
+			mockSetup: func(m *campaignservice.MockCampaignService) {
+				m.EXPECT().Get(mock.Anything, userID, "c1").
+					Return(model.Campaign{}, fmt.Errorf("%w: c1", service.ErrCampaignNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "update",
+			method: http.MethodPatch,
+			target: "/api/user/campaigns/c1",
+			body:   `{"name":"Spring"}`,
+			serve:  func(h *handler) http.HandlerFunc { return h.UpdateCampaignHandler },
+			mockSetup: func(m *campaignservice.MockCampaignService) {
+				m.EXPECT().Update(mock.Anything, userID, "c1", model.CampaignRequest{Name: &name}).Return(campaign, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   campaignJSON,
+		},
+		{
+			name:           "update with invalid JSON",
+			method:         http.MethodPatch,
+			target:         "/api/user/campaigns/c1",
+			body:           `{"name":`,
+			serve:          func(h *handler) http.HandlerFunc { return h.UpdateCampaignHandler },
+			mockSetup:      func(m *campaignservice.MockCampaignService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "delete keeping links",
+			method: http.MethodDelete,
+			target: "/api/user/campaigns/c1",
+			serve:  func(h *handler) http.HandlerFunc { return h.DeleteCampaignHandler },
+			mockSetup: func(m *campaignservice.MockCampaignService) {
+				m.EXPECT().Delete(mock.Anything, userID, "c1", false).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "delete with links",
+			method: http.MethodDelete,
+			target: "/api/user/campaigns/c1?delete_links=true",
+			serve:  func(h *handler) http.HandlerFunc { return h.DeleteCampaignHandler },
+			mockSetup: func(m *campaignservice.MockCampaignService) {
+				m.EXPECT().Delete(mock.Anything, userID, "c1", true).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "delete with invalid flag",
+			method:         http.MethodDelete,
+			target:         "/api/user/campaigns/c1?delete_links=maybe",
+			serve:          func(h *handler) http.HandlerFunc { return h.DeleteCampaignHandler },
+			mockSetup:      func(m *campaignservice.MockCampaignService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "stats",
+			method: http.MethodGet,
+			target: "/api/user/campaigns/c1/stats",
+			serve:  func(h *handler) http.HandlerFunc { return h.CampaignStatsHandler },
+			mockSetup: func(m *campaignservice.MockCampaignService) {
+				m.EXPECT().Stats(mock.Anything, userID, "c1").
+					Return(model.CampaignStats{Links: 3, ActiveLinks: 2, DeletedLinks: 1, Clicks: 42}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"links":3,"active_links":2,"deleted_links":1,"clicks":42}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockCampaigns := new(campaignservice.MockCampaignService)
+			test.mockSetup(mockCampaigns)
+
+			req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", "c1")
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+			req = req.WithContext(middleware.SetUserID(ctx, userID))
+
+			w := httptest.NewRecorder()
+
+			h := New(cfg, new(urlshorterservice.MockURLShorterService), new(healthservice.MockHealthService),
+				zap.NewNop(), audit.NewMockPublisher(), WithCampaignService(mockCampaigns))
+			test.serve(h)(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, w.Body.String())
+			}
+			mockCampaigns.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCampaignHandlersNotConfigured(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/user/campaigns", nil)
+	req = req.WithContext(middleware.SetUserID(req.Context(), "test-user-123"))
+	w := httptest.NewRecorder()
+
+	h := New(cfg, new(urlshorterservice.MockURLShorterService), new(healthservice.MockHealthService),
+		zap.NewNop(), audit.NewMockPublisher())
+	h.ListCampaignsHandler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), codeNotConfigured)
+}
@@ -6,77 +6,73 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/middleware"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
 	"github.com/MarkelovSergey/url-shorter/internal/service"
 )
 
 // CreateHandler обрабатывает запрос на создание короткой ссылки в формате text/plain.
 func (h *handler) CreateHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get("Content-Type") != "text/plain" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("unsupported media type"))
+		h.writeTextError(w, r, errUnsupportedMediaType)
 
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	// Тело запроса - сам URL: читаем не больше допустимой длины и одного лишнего байта.
+	var body io.Reader = r.Body
+	if limit := h.config.URLPolicy.MaxLength; limit > 0 {
+		body = io.LimitReader(r.Body, int64(limit)+1)
+	}
+
+	data, err := io.ReadAll(body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error reading request body"))
+		h.writeTextError(w, r, errReadBody)
 
 		return
 	}
 	defer r.Body.Close()
 
-	u := string(body)
+	u := string(data)
 
-	uParsed, err := url.Parse(u)
-	if err != nil || uParsed == nil ||
-		(!strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://")) {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("url not correct"))
+	if err := h.validateURL(u); err != nil {
+		h.writeTextError(w, r, err)
 
 		return
 	}
 
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok || userID == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		h.writeTextError(w, r, errUnauthorized)
 
 		return
 	}
 
-	us, err := h.urlShorterService.Generate(r.Context(), u, userID)
+	us, err := h.urlShorterService.Generate(r.Context(), u, userID, model.LinkAttributes{})
+	if err != nil && !errors.Is(err, service.ErrURLConflict) {
+		h.writeTextError(w, r, err)
+
+		return
+	}
 
 	shortURL, joinErr := url.JoinPath(h.config.Server.BaseURL, us)
 	if joinErr != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("invalid URL format"))
+		h.writeTextError(w, r, joinErr)
 
 		return
 	}
 
+	status := http.StatusCreated
 	if err != nil {
-		if errors.Is(err, service.ErrURLConflict) {
-			w.WriteHeader(http.StatusConflict)
-			w.Write([]byte(shortURL))
-
-			h.auditPublisher.Publish(audit.NewEvent(audit.ActionShorten, u, &userID))
-
-			return
-		}
-
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-
-		return
+		status = http.StatusConflict
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(status)
 	w.Write([]byte(shortURL))
 
-	h.auditPublisher.Publish(audit.NewEvent(audit.ActionShorten, u, &userID))
+	h.auditPublisher.Publish(h.newAuditEvent(r, audit.ActionShorten, u, &userID).
+		WithShortCode(us).
+		WithStatus(status))
 }
@@ -6,28 +6,24 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/middleware"
 	"github.com/MarkelovSergey/url-shorter/internal/model"
 	"github.com/MarkelovSergey/url-shorter/internal/service"
-	"go.uber.org/zap"
 )
 
 // CreateAPIHandler обрабатывает JSON-запрос на создание короткой ссылки.
 func (h *handler) CreateAPIHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get("Content-Type") != "application/json" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("unsupported media type"))
+		h.writeProblem(w, r, errUnsupportedMediaType)
 
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error reading request body"))
+		h.writeProblem(w, r, errReadBody)
 
 		return
 	}
@@ -36,34 +32,34 @@ func (h *handler) CreateAPIHandler(w http.ResponseWriter, r *http.Request) {
 	var req model.Request
 	err = json.Unmarshal(body, &req)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error parsing JSON"))
+		h.writeProblem(w, r, errInvalidJSON)
 
 		return
 	}
 
-	uParsed, err := url.Parse(req.URL)
-	if err != nil || uParsed == nil ||
-		(!strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://")) {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("url not correct"))
+	if err := h.validateURL(req.URL); err != nil {
+		h.writeProblem(w, r, err)
 
 		return
 	}
 
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok || userID == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		h.writeProblem(w, r, errUnauthorized)
 
 		return
 	}
 
-	us, err := h.urlShorterService.Generate(r.Context(), req.URL, userID)
+	us, err := h.urlShorterService.Generate(r.Context(), req.URL, userID, req.LinkAttributes)
+	if err != nil && !errors.Is(err, service.ErrURLConflict) {
+		h.writeProblem(w, r, err)
+
+		return
+	}
 
 	shortURL, joinErr := url.JoinPath(h.config.Server.BaseURL, us)
 	if joinErr != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("invalid URL format"))
+		h.writeProblem(w, r, joinErr)
 
 		return
 	}
@@ -71,38 +67,21 @@ func (h *handler) CreateAPIHandler(w http.ResponseWriter, r *http.Request) {
 	resp := model.Response{Result: shortURL}
 	jsonResp, marshalErr := json.Marshal(resp)
 	if marshalErr != nil {
-		h.logger.Error("failed to marshal JSON response",
-			zap.Error(marshalErr),
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
-		)
-
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		h.writeProblem(w, r, marshalErr)
 
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
+	status := http.StatusCreated
 	if err != nil {
-		if errors.Is(err, service.ErrURLConflict) {
-			w.WriteHeader(http.StatusConflict)
-			w.Write(jsonResp)
-
-			h.auditPublisher.Publish(audit.NewEvent(audit.ActionShorten, req.URL, &userID))
-
-			return
-		}
-
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-
-		return
+		status = http.StatusConflict
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	w.Write(jsonResp)
 
-	h.auditPublisher.Publish(audit.NewEvent(audit.ActionShorten, req.URL, &userID))
+	h.auditPublisher.Publish(h.newAuditEvent(r, audit.ActionShorten, req.URL, &userID).
+		WithShortCode(us).
+		WithStatus(status))
 }
@@ -31,6 +31,7 @@ func TestCreateAPIHandler(t *testing.T) {
 		"",
 		"",
 	)
+	cfg.URLPolicy.MaxLength = 64
 
 	originalURL := "https://practicum.yandex.ru"
 	shortID := "test"
@@ -55,7 +56,7 @@ func TestCreateAPIHandler(t *testing.T) {
 			contentType: "application/json",
 			body:        `{"url":"https://practicum.yandex.ru"}`,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().Generate(mock.Anything, originalURL, mock.Anything).Return(shortID, nil)
+				m.EXPECT().Generate(mock.Anything, originalURL, mock.Anything, mock.Anything).Return(shortID, nil)
 			},
 			expectedStatus: http.StatusCreated,
 			expectedBody:   expectedShortURL,
@@ -102,11 +103,32 @@ func TestCreateAPIHandler(t *testing.T) {
 			contentType: "application/json",
 			body:        `{"url":"https://practicum.yandex.ru"}`,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().Generate(mock.Anything, originalURL, mock.Anything).Return(shortID, service.ErrURLConflict)
+				m.EXPECT().Generate(mock.Anything, originalURL, mock.Anything, mock.Anything).Return(shortID, service.ErrURLConflict)
 			},
 			expectedStatus: http.StatusConflict,
 			expectedBody:   expectedShortURL,
 		},
+		{
+			name:        "URL rejected by policy",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"url":"http://169.254.169.254/latest/meta-data"}`,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().Generate(mock.Anything, "http://169.254.169.254/latest/meta-data", mock.Anything, mock.Anything).
+					Return("", service.NewValidationError("url", "url_private_address", "url points to a private or reserved address"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "url points to a private or reserved address",
+		},
+		{
+			name:           "URL too long",
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           `{"url":"https://example.com/` + strings.Repeat("a", 64) + `"}`,
+			mockSetup:      func(m *urlshorterservice.MockURLShorterService) {},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "url is longer than 64 characters",
+		},
 	}
 
 	for _, test := range tests {
@@ -142,7 +164,12 @@ func TestCreateAPIHandler(t *testing.T) {
 				assert.Equal(t, test.expectedBody, resp.Result)
 				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 			} else {
-				assert.Equal(t, test.expectedBody, w.Body.String())
+				var problem model.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &problem)
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedBody, problem.Detail)
+				assert.Equal(t, test.expectedStatus, problem.Status)
+				assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
 			}
 
 			mockURLShorterService.AssertExpectations(t)
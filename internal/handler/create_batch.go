@@ -5,26 +5,24 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/middleware"
 	"github.com/MarkelovSergey/url-shorter/internal/model"
-	"go.uber.org/zap"
 )
 
 // CreateBatchHandler обрабатывает пакетный запрос на создание коротких ссылок.
+// Число ссылок с паролем в пакете ограничено LinkPassword.MaxBatch: каждый пароль хешируется bcrypt.
 func (h *handler) CreateBatchHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get("Content-Type") != "application/json" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("unsupported media type"))
+		h.writeProblem(w, r, errUnsupportedMediaType)
 
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error reading request body"))
+		h.writeProblem(w, r, errReadBody)
 
 		return
 	}
@@ -33,59 +31,56 @@ func (h *handler) CreateBatchHandler(w http.ResponseWriter, r *http.Request) {
 	var requests []model.BatchRequest
 	err = json.Unmarshal(body, &requests)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error parsing JSON"))
+		h.writeProblem(w, r, errInvalidJSON)
 
 		return
 	}
 
 	if len(requests) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("empty batch"))
+		h.writeProblem(w, r, errEmptyBatch)
 
 		return
 	}
 
-	urls := make([]string, 0, len(requests))
+	links := make([]model.Request, 0, len(requests))
 	correlationIDs := make([]string, 0, len(requests))
+	passwords := 0
 	for _, req := range requests {
 		if req.CorrelationID == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte("correlation_id is required"))
+			h.writeProblem(w, r, errMissingCorrelationID)
 
 			return
 		}
 
-		uParsed, err := url.Parse(req.OriginalURL)
-		if err != nil || uParsed == nil ||
-			(!strings.HasPrefix(req.OriginalURL, "http://") && !strings.HasPrefix(req.OriginalURL, "https://")) {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte("url not correct"))
+		if err := h.validateURL(req.OriginalURL); err != nil {
+			h.writeProblem(w, r, err)
 
 			return
 		}
 
-		urls = append(urls, req.OriginalURL)
+		if req.Password != "" {
+			passwords++
+			if limit := h.config.LinkPassword.MaxBatch; limit > 0 && passwords > limit {
+				h.writeProblem(w, r, newTooManyPasswordsError(limit))
+
+				return
+			}
+		}
+
+		links = append(links, model.Request{URL: req.OriginalURL, LinkAttributes: req.LinkAttributes})
 		correlationIDs = append(correlationIDs, req.CorrelationID)
 	}
 
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok || userID == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		h.writeProblem(w, r, errUnauthorized)
 
 		return
 	}
 
-	shortCodes, err := h.urlShorterService.GenerateBatch(r.Context(), urls, userID)
+	shortCodes, err := h.urlShorterService.GenerateBatch(r.Context(), links, userID)
 	if err != nil {
-		h.logger.Error("failed to generate batch short codes",
-			zap.Error(err),
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
-		)
-
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		h.writeProblem(w, r, err)
 
 		return
 	}
@@ -94,14 +89,7 @@ func (h *handler) CreateBatchHandler(w http.ResponseWriter, r *http.Request) {
 	for i, shortCode := range shortCodes {
 		shortURL, err := url.JoinPath(h.config.Server.BaseURL, shortCode)
 		if err != nil {
-			h.logger.Error("failed to join URL path",
-				zap.Error(err),
-				zap.String("method", r.Method),
-				zap.String("path", r.URL.Path),
-			)
-
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("invalid URL format"))
+			h.writeProblem(w, r, err)
 
 			return
 		}
@@ -114,14 +102,7 @@ func (h *handler) CreateBatchHandler(w http.ResponseWriter, r *http.Request) {
 
 	jsonResp, err := json.Marshal(responses)
 	if err != nil {
-		h.logger.Error("failed to marshal JSON response",
-			zap.Error(err),
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
-		)
-
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		h.writeProblem(w, r, err)
 
 		return
 	}
@@ -129,4 +110,10 @@ func (h *handler) CreateBatchHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	w.Write(jsonResp)
+
+	for i, shortCode := range shortCodes {
+		h.auditPublisher.Publish(h.newAuditEvent(r, audit.ActionShortenBatch, links[i].URL, &userID).
+			WithShortCode(shortCode).
+			WithStatus(http.StatusCreated))
+	}
 }
@@ -38,11 +38,18 @@ func TestCreateBatchHandler(t *testing.T) {
 			name: "Successful batch creation",
 			requestBody: []model.BatchRequest{
 				{CorrelationID: "1", OriginalURL: "https://example.com"},
-				{CorrelationID: "2", OriginalURL: "https://google.com"},
+				{
+					CorrelationID:  "2",
+					OriginalURL:    "https://google.com",
+					LinkAttributes: model.LinkAttributes{Title: "Search", CampaignID: "spring"},
+				},
 			},
 			contentType: "application/json",
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().GenerateBatch(mock.Anything, []string{"https://example.com", "https://google.com"}, mock.Anything).
+				m.EXPECT().GenerateBatch(mock.Anything, []model.Request{
+					{URL: "https://example.com"},
+					{URL: "https://google.com", LinkAttributes: model.LinkAttributes{Title: "Search", CampaignID: "spring"}},
+				}, mock.Anything).
 					Return([]string{"abc12345", "def67890"}, nil)
 			},
 			expectedStatusCode: http.StatusCreated,
@@ -172,3 +179,67 @@ func TestCreateBatchHandlerServiceError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestCreateBatchHandlerPasswordLimit(t *testing.T) {
+	cfg := config.Config{
+		Server:       config.ServerConfig{BaseURL: "http://localhost:8080"},
+		LinkPassword: config.LinkPasswordConfig{MaxBatch: 2},
+	}
+
+	withPassword := func(id string) model.BatchRequest {
+		return model.BatchRequest{
+			CorrelationID:  id,
+			OriginalURL:    "https://example.com/" + id,
+			LinkAttributes: model.LinkAttributes{Password: "s3cret"},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		requests       []model.BatchRequest
+		expectedStatus int
+	}{
+		{
+			name: "within limit",
+			requests: []model.BatchRequest{
+				withPassword("1"),
+				withPassword("2"),
+				{CorrelationID: "3", OriginalURL: "https://example.com/3"},
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "over limit",
+			requests:       []model.BatchRequest{withPassword("1"), withPassword("2"), withPassword("3")},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(urlshorterservice.MockURLShorterService)
+			if tt.expectedStatus == http.StatusCreated {
+				mockService.EXPECT().GenerateBatch(mock.Anything, mock.Anything, "test-user-123").
+					Return([]string{"a", "b", "c"}, nil)
+			}
+
+			h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), audit.NewMockPublisher())
+
+			body, _ := json.Marshal(tt.requests)
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(middleware.SetUserID(req.Context(), "test-user-123"))
+
+			w := httptest.NewRecorder()
+			h.CreateBatchHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusBadRequest {
+				var problem model.Problem
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+				assert.Equal(t, codeTooManyPasswords, problem.Code)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/config"
 	"github.com/MarkelovSergey/url-shorter/internal/middleware"
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
 	"github.com/MarkelovSergey/url-shorter/internal/service"
 	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
 	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
@@ -29,6 +30,7 @@ func TestCreateHandler(t *testing.T) {
 		"",
 		"",
 	)
+	cfg.URLPolicy.MaxLength = 64
 
 	originalURL := "https://practicum.yandex.ru"
 	shortID := "test"
@@ -53,7 +55,7 @@ func TestCreateHandler(t *testing.T) {
 			contentType: "text/plain",
 			body:        originalURL,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().Generate(mock.Anything, originalURL, mock.Anything).Return(shortID, nil)
+				m.EXPECT().Generate(mock.Anything, originalURL, mock.Anything, mock.Anything).Return(shortID, nil)
 			},
 			expectedStatus: http.StatusCreated,
 			expectedBody:   expectedShortURL,
@@ -91,11 +93,20 @@ func TestCreateHandler(t *testing.T) {
 			contentType: "text/plain",
 			body:        originalURL,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().Generate(mock.Anything, originalURL, mock.Anything).Return(shortID, service.ErrURLConflict)
+				m.EXPECT().Generate(mock.Anything, originalURL, mock.Anything, mock.Anything).Return(shortID, service.ErrURLConflict)
 			},
 			expectedStatus: http.StatusConflict,
 			expectedBody:   expectedShortURL,
 		},
+		{
+			name:           "URL too long",
+			method:         http.MethodPost,
+			contentType:    "text/plain",
+			body:           "https://example.com/" + strings.Repeat("a", 64),
+			mockSetup:      func(m *urlshorterservice.MockURLShorterService) {},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "url is longer than 64 characters",
+		},
 	}
 
 	for _, test := range tests {
@@ -124,3 +135,28 @@ func TestCreateHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateHandlerAuditRequestID(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+
+	mockService := new(urlshorterservice.MockURLShorterService)
+	mockService.EXPECT().Generate(mock.Anything, "https://practicum.yandex.ru", "test-user-123", mock.Anything).Return("test", nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://practicum.yandex.ru"))
+	req.Header.Set("Content-Type", "text/plain")
+
+	ctx := middleware.SetUserID(req.Context(), "test-user-123")
+	ctx = requestid.NewContext(ctx, "req-42")
+	req = req.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+
+	mockAuditPublisher := audit.NewMockPublisher()
+	h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), mockAuditPublisher)
+	h.CreateHandler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, mockAuditPublisher.Events, 1) {
+		assert.Equal(t, "req-42", mockAuditPublisher.Events[0].RequestID)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/middleware"
 )
 
@@ -11,25 +12,31 @@ import (
 func (h *handler) DeleteURLsHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok || userID == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		h.writeProblem(w, r, errUnauthorized)
 
 		return
 	}
 
 	var shortURLs []string
 	if err := json.NewDecoder(r.Body).Decode(&shortURLs); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		h.writeProblem(w, r, errInvalidJSON)
 
 		return
 	}
 
 	if len(shortURLs) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
+		h.writeProblem(w, r, errEmptyBatch)
 
 		return
 	}
 
-	h.urlShorterService.DeleteURLsAsync(shortURLs, userID)
+	h.urlShorterService.DeleteURLsAsync(r.Context(), shortURLs, userID)
 
 	w.WriteHeader(http.StatusAccepted)
+
+	for _, shortURL := range shortURLs {
+		h.auditPublisher.Publish(h.newAuditEvent(r, audit.ActionDelete, "", &userID).
+			WithShortCode(shortURL).
+			WithStatus(http.StatusAccepted))
+	}
 }
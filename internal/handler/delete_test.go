@@ -46,7 +46,7 @@ func TestDeleteURLsHandler(t *testing.T) {
 			body:        `["6qxTVvsy", "RTfd56hn", "Jlfd67ds"]`,
 			userID:      userID,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().DeleteURLsAsync(mock.Anything, userID).Return().Maybe()
+				m.EXPECT().DeleteURLsAsync(mock.Anything, mock.Anything, userID).Return().Maybe()
 			},
 			expectedStatus: http.StatusAccepted,
 		},
@@ -93,7 +93,7 @@ func TestDeleteURLsHandler(t *testing.T) {
 			body:        `["6qxTVvsy"]`,
 			userID:      userID,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().DeleteURLsAsync(mock.Anything, userID).Return().Maybe()
+				m.EXPECT().DeleteURLsAsync(mock.Anything, mock.Anything, userID).Return().Maybe()
 			},
 			expectedStatus: http.StatusAccepted,
 		},
@@ -104,7 +104,7 @@ func TestDeleteURLsHandler(t *testing.T) {
 			body:        `["url1", "url2", "url3", "url4", "url5"]`,
 			userID:      userID,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().DeleteURLsAsync(mock.Anything, userID).Return().Maybe()
+				m.EXPECT().DeleteURLsAsync(mock.Anything, mock.Anything, userID).Return().Maybe()
 			},
 			expectedStatus: http.StatusAccepted,
 		},
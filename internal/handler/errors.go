@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlpolicy"
+	"go.uber.org/zap"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:url-shorter:problem:"
+)
+
+// Машиночитаемые коды ошибок API.
+const (
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeInvalidBody          = "invalid_body"
+	codeInvalidJSON          = "invalid_json"
+	codeInvalidURL           = "invalid_url"
+	codeURLTooLong           = urlpolicy.CodeTooLong
+	codeEmptyBatch           = "empty_batch"
+	codeTooManyPasswords     = "too_many_passwords"
+	codeMissingCorrelationID = "missing_correlation_id"
+	codeUnauthorized         = "unauthorized"
+	codeNotFound             = "not_found"
+	codeCampaignNotFound     = "campaign_not_found"
+	codeURLConflict          = "url_conflict"
+	codeURLDeleted           = "url_deleted"
+	codeURLExhausted         = "url_exhausted"
+	codeURLExpired           = "url_expired"
+	codeURLNotActive         = "url_not_active"
+	codeURLBlocked           = "url_blocked"
+	codeValidation           = "validation_failed"
+	codeInvalidQuery         = "invalid_query"
+	codeInvalidPath          = "invalid_path"
+	codeMethodNotAllowed     = "method_not_allowed"
+	codeInvalidCursor        = "invalid_cursor"
+	codeNotConfigured        = "not_configured"
+	codeProbeBlocked         = "probe_blocked"
+	codeInternal             = "internal_error"
+)
+
+// apiError описывает ошибку, которую можно безопасно показать клиенту.
+type apiError struct {
+	status int
+	code   string
+	detail string
+}
+
+func newAPIError(status int, code, detail string) *apiError {
+	return &apiError{status: status, code: code, detail: detail}
+}
+
+// Error возвращает текстовое описание ошибки.
+func (e *apiError) Error() string {
+	return e.detail
+}
+
+// Ошибки уровня HTTP-запроса.
+var (
+	errUnsupportedMediaType = newAPIError(http.StatusBadRequest, codeUnsupportedMediaType, "unsupported media type")
+	errReadBody             = newAPIError(http.StatusBadRequest, codeInvalidBody, "error reading request body")
+	errInvalidJSON          = newAPIError(http.StatusBadRequest, codeInvalidJSON, "error parsing JSON")
+	errInvalidURL           = newAPIError(http.StatusBadRequest, codeInvalidURL, "url not correct")
+	errEmptyBatch           = newAPIError(http.StatusBadRequest, codeEmptyBatch, "empty batch")
+	errMissingCorrelationID = newAPIError(http.StatusBadRequest, codeMissingCorrelationID, "correlation_id is required")
+	errUnauthorized         = newAPIError(http.StatusUnauthorized, codeUnauthorized, "user is not authenticated")
+	errIDNotFound           = newAPIError(http.StatusBadRequest, codeNotFound, "ID not found")
+	errInvalidPath          = newAPIError(http.StatusBadRequest, codeInvalidPath, "path can not be forwarded")
+	errMethodNotAllowed     = newAPIError(http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed for this link")
+	errURLNotFound          = newAPIError(http.StatusNotFound, codeNotFound, "URL not found")
+	errAdminUnauthorized    = newAPIError(http.StatusUnauthorized, codeUnauthorized, "admin token required")
+	errAuditNotConfigured   = newAPIError(http.StatusNotFound, codeNotConfigured, "audit store is not configured")
+	errProbeBlocked         = newAPIError(http.StatusTooManyRequests, codeProbeBlocked, "too many requests for unknown IDs")
+	errInternal             = newAPIError(http.StatusInternalServerError, codeInternal, "internal server error")
+)
+
+// newURLTooLongError создает ошибку для URL длиннее limit байт.
+func newURLTooLongError(limit int) *apiError {
+	return newAPIError(http.StatusRequestEntityTooLarge, codeURLTooLong,
+		fmt.Sprintf("url is longer than %d characters", limit))
+}
+
+// newTooManyPasswordsError создает ошибку для пакета, в котором больше limit ссылок с паролем.
+func newTooManyPasswordsError(limit int) *apiError {
+	return newAPIError(http.StatusBadRequest, codeTooManyPasswords,
+		fmt.Sprintf("batch may contain at most %d password-protected links", limit))
+}
+
+// writeProblem отправляет ошибку клиенту в формате application/problem+json.
+func (h *handler) writeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	problem := h.problemFor(r, err)
+
+	body, marshalErr := json.Marshal(problem)
+	if marshalErr != nil {
+		h.log(r.Context()).Error("failed to marshal problem response", zap.Error(marshalErr))
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	w.Write(body)
+}
+
+// writeTextError отправляет ошибку в формате text/plain.
+// Используется устаревшим эндпоинтом POST /, клиенты которого ожидают текстовый ответ.
+func (h *handler) writeTextError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := h.problemFor(r, err)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(problem.Status)
+	w.Write([]byte(problem.Detail))
+}
+
+// problemFor сопоставляет ошибку с описанием для клиента.
+// Внутренние ошибки логируются вместе с идентификатором запроса и не раскрываются клиенту.
+// Ответы 5xx, выбранные намеренно (например, ссылка еще не начала действовать), не логируются.
+func (h *handler) problemFor(r *http.Request, err error) model.Problem {
+	requestID := requestid.FromContext(r.Context())
+	apiErr := toAPIError(err)
+
+	if apiErr == errInternal {
+		h.log(r.Context()).Error("internal error while handling request",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+	}
+
+	return model.Problem{
+		Type:      problemTypePrefix + apiErr.code,
+		Title:     http.StatusText(apiErr.status),
+		Status:    apiErr.status,
+		Detail:    apiErr.detail,
+		Instance:  r.URL.Path,
+		Code:      apiErr.code,
+		RequestID: requestID,
+	}
+}
+
+// toAPIError преобразует ошибки сервисного и репозиторного слоев в ошибки API.
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		code := validationErr.Code
+		if code == "" {
+			code = codeValidation
+		}
+
+		status := http.StatusBadRequest
+		if code == codeURLTooLong {
+			status = http.StatusRequestEntityTooLarge
+		}
+
+		return newAPIError(status, code, validationErr.Reason)
+	}
+
+	switch {
+	case errors.Is(err, audit.ErrInvalidCursor):
+		return newAPIError(http.StatusBadRequest, codeInvalidCursor, "invalid cursor")
+	case errors.Is(err, service.ErrValidation):
+		return newAPIError(http.StatusBadRequest, codeValidation, "request validation failed")
+	case errors.Is(err, service.ErrURLConflict), errors.Is(err, repository.ErrURLAlreadyExists):
+		return newAPIError(http.StatusConflict, codeURLConflict, "URL already shortened")
+	case errors.Is(err, service.ErrURLDeleted), errors.Is(err, repository.ErrDeleted):
+		return newAPIError(http.StatusGone, codeURLDeleted, "URL has been deleted")
+	case errors.Is(err, service.ErrURLExhausted), errors.Is(err, repository.ErrExhausted):
+		return newAPIError(http.StatusGone, codeURLExhausted, "URL has no uses left")
+	case errors.Is(err, service.ErrURLExpired):
+		return newAPIError(http.StatusGone, codeURLExpired, "URL has expired")
+	case errors.Is(err, service.ErrURLNotActive):
+		return newAPIError(http.StatusServiceUnavailable, codeURLNotActive, "URL is not active yet")
+	case errors.Is(err, service.ErrCampaignNotFound):
+		return newAPIError(http.StatusNotFound, codeCampaignNotFound, "campaign not found")
+	case errors.Is(err, service.ErrURLBlocked):
+		return newAPIError(http.StatusForbidden, codeURLBlocked, "URL is blocked")
+	case errors.Is(err, service.ErrFindShortCode), errors.Is(err, repository.ErrNotFound):
+		return errIDNotFound
+	}
+
+	return errInternal
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+	"github.com/MarkelovSergey/url-shorter/internal/config"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWriteProblem(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedDetail string
+	}{
+		{
+			name:           "api error is rendered as is",
+			err:            errInvalidJSON,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeInvalidJSON,
+			expectedDetail: "error parsing JSON",
+		},
+		{
+			name:           "validation error keeps its code",
+			err:            fmt.Errorf("wrapped: %w", service.NewValidationError("url", "url_private_address", "url points to a private address")),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "url_private_address",
+			expectedDetail: "url points to a private address",
+		},
+		{
+			name:           "too long url",
+			err:            service.NewValidationError("url", codeURLTooLong, "url is longer than 8192 characters"),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   codeURLTooLong,
+			expectedDetail: "url is longer than 8192 characters",
+		},
+		{
+			name:           "conflict",
+			err:            fmt.Errorf("%w: %w", service.ErrURLConflict, repository.ErrURLAlreadyExists),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   codeURLConflict,
+			expectedDetail: "URL already shortened",
+		},
+		{
+			name:           "deleted",
+			err:            service.ErrURLDeleted,
+			expectedStatus: http.StatusGone,
+			expectedCode:   codeURLDeleted,
+			expectedDetail: "URL has been deleted",
+		},
+		{
+			name:           "blocked",
+			err:            fmt.Errorf("%w: domain \"evil.example\" is blocked", service.ErrURLBlocked),
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   codeURLBlocked,
+			expectedDetail: "URL is blocked",
+		},
+		{
+			name:           "not found",
+			err:            fmt.Errorf("%w: abc", service.ErrFindShortCode),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeNotFound,
+			expectedDetail: "ID not found",
+		},
+		{
+			name:           "internal error is not echoed",
+			err:            errors.New("pq: connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   codeInternal,
+			expectedDetail: "internal server error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := New(cfg, new(urlshorterservice.MockURLShorterService), new(healthservice.MockHealthService),
+				zap.NewNop(), audit.NewMockPublisher())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+			req = req.WithContext(requestid.NewContext(req.Context(), "req-42"))
+			w := httptest.NewRecorder()
+
+			h.writeProblem(w, req, test.err)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+
+			var problem model.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, test.expectedStatus, problem.Status)
+			assert.Equal(t, test.expectedCode, problem.Code)
+			assert.Equal(t, test.expectedDetail, problem.Detail)
+			assert.Equal(t, problemTypePrefix+test.expectedCode, problem.Type)
+			assert.Equal(t, "/api/user/urls", problem.Instance)
+			assert.Equal(t, "req-42", problem.RequestID)
+		})
+	}
+}
+
+func TestWriteTextError(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+	h := New(cfg, new(urlshorterservice.MockURLShorterService), new(healthservice.MockHealthService),
+		zap.NewNop(), audit.NewMockPublisher())
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+
+	h.writeTextError(w, req, errors.New("storage is down"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "internal server error", w.Body.String())
+}
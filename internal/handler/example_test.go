@@ -74,7 +74,7 @@ func Example_createHandler() {
 
 	// Настраиваем мок для генерации короткой ссылки
 	setup.mockURLService.EXPECT().
-		Generate(mock.Anything, "https://practicum.yandex.ru", mock.Anything).
+		Generate(mock.Anything, "https://practicum.yandex.ru", mock.Anything, mock.Anything).
 		Return("abc123", nil)
 
 	// Создаём запрос с URL в теле
@@ -107,7 +107,7 @@ func Example_createAPIHandler() {
 
 	// Настраиваем мок для генерации короткой ссылки
 	setup.mockURLService.EXPECT().
-		Generate(mock.Anything, "https://practicum.yandex.ru", mock.Anything).
+		Generate(mock.Anything, "https://practicum.yandex.ru", mock.Anything, mock.Anything).
 		Return("xyz789", nil)
 
 	// Создаём JSON-запрос
@@ -146,7 +146,7 @@ func Example_createBatchHandler() {
 
 	// Настраиваем мок для генерации батча коротких ссылок
 	setup.mockURLService.EXPECT().
-		GenerateBatch(mock.Anything, []string{"https://example.com", "https://google.com"}, mock.Anything).
+		GenerateBatch(mock.Anything, []model.Request{{URL: "https://example.com"}, {URL: "https://google.com"}}, mock.Anything).
 		Return([]string{"short1", "short2"}, nil)
 
 	// Создаём батч-запрос
@@ -191,7 +191,10 @@ func Example_readHandler() {
 	// Настраиваем мок для получения оригинального URL
 	setup.mockURLService.EXPECT().
 		GetOriginalURL(mock.Anything, "abc123").
-		Return("https://practicum.yandex.ru", nil)
+		Return(model.Redirect{OriginalURL: "https://practicum.yandex.ru"}, nil)
+	setup.mockURLService.EXPECT().
+		RecordClick(mock.Anything, "abc123").
+		Return(nil)
 
 	// Создаём GET-запрос к короткой ссылке
 	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
@@ -221,7 +224,7 @@ func Example_getUserURLsHandler() {
 		{ShortURL: "xyz789", OriginalURL: "https://google.com"},
 	}
 	setup.mockURLService.EXPECT().
-		GetUserURLs(mock.Anything, "user-123").
+		GetUserURLs(mock.Anything, "user-123", mock.Anything).
 		Return(userURLs, nil)
 
 	// Создаём запрос
@@ -261,7 +264,7 @@ func Example_deleteURLsHandler() {
 
 	// Настраиваем мок для асинхронного удаления URL
 	setup.mockURLService.EXPECT().
-		DeleteURLsAsync([]string{"abc123", "xyz789"}, "user-123").
+		DeleteURLsAsync(mock.Anything, []string{"abc123", "xyz789"}, "user-123").
 		Return()
 
 	// Создаём запрос с массивом коротких URL для удаления
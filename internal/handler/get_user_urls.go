@@ -2,26 +2,34 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/middleware"
 	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/go-chi/chi/v5"
 )
 
 // GetUserURLsHandler обрабатывает запрос на получение списка URL пользователя.
+// Параметры tag (можно повторять или перечислять через запятую) оставляют ссылки
+// со всеми указанными тегами, параметр q - ссылки со всеми словами в заголовке или заметках,
+// параметр campaign - ссылки кампании.
 func (h *handler) GetUserURLsHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok || userID == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		h.writeProblem(w, r, errUnauthorized)
 
 		return
 	}
 
-	records, err := h.urlShorterService.GetUserURLs(r.Context(), userID)
+	records, err := h.urlShorterService.GetUserURLs(r.Context(), userID, parseURLFilter(r))
 	if err != nil {
-		h.logger.Error("Failed to get user URLs: " + err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		h.writeProblem(w, r, err)
 
 		return
 	}
@@ -34,23 +42,143 @@ func (h *handler) GetUserURLsHandler(w http.ResponseWriter, r *http.Request) {
 
 	response := make([]model.UserURLResponse, 0, len(records))
 	for _, record := range records {
-		shortURL, err := url.JoinPath(h.config.Server.BaseURL, record.ShortURL)
+		item, err := h.userURLResponse(record)
 		if err != nil {
-			h.logger.Error("Failed to join URL: " + err.Error())
+			h.log(r.Context()).Error("Failed to join URL: " + err.Error())
 
 			continue
 		}
 
-		response = append(response, model.UserURLResponse{
-			ShortURL:    shortURL,
-			OriginalURL: record.OriginalURL,
-		})
+		response = append(response, item)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	h.writeJSON(w, r, http.StatusOK, response)
+}
+
+// GetUserURLHandler обрабатывает запрос на получение одной ссылки пользователя
+// с метаданными: GET /api/user/urls/{id}.
+func (h *handler) GetUserURLHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok || userID == "" {
+		h.writeProblem(w, r, errUnauthorized)
+
+		return
+	}
+
+	record, err := h.urlShorterService.GetUserURL(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrFindShortCode) {
+			err = errURLNotFound
+		}
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	response, err := h.userURLResponse(record)
+	if err != nil {
+		h.writeProblem(w, r, err)
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode response: " + err.Error())
+		return
 	}
+
+	h.writeJSON(w, r, http.StatusOK, response)
+}
+
+// UpdateUserURLHandler обрабатывает запрос на изменение заголовка, заметок, тегов и кампании ссылки:
+// PATCH /api/user/urls/{id}. Не переданные поля не меняются.
+func (h *handler) UpdateUserURLHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/json" {
+		h.writeProblem(w, r, errUnsupportedMediaType)
+
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok || userID == "" {
+		h.writeProblem(w, r, errUnauthorized)
+
+		return
+	}
+
+	var update model.LinkUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		h.writeProblem(w, r, errInvalidJSON)
+
+		return
+	}
+	defer r.Body.Close()
+
+	record, err := h.urlShorterService.UpdateUserURL(r.Context(), userID, chi.URLParam(r, "id"), update)
+	if err != nil {
+		if errors.Is(err, service.ErrFindShortCode) {
+			err = errURLNotFound
+		}
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	response, err := h.userURLResponse(record)
+	if err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, response)
+
+	h.auditPublisher.Publish(h.newAuditEvent(r, audit.ActionUpdate, record.OriginalURL, &userID).
+		WithShortCode(record.ShortURL).
+		WithStatus(http.StatusOK))
+}
+
+// parseURLFilter читает условия отбора ссылок из параметров запроса.
+func parseURLFilter(r *http.Request) model.URLFilter {
+	query := r.URL.Query()
+
+	var filter model.URLFilter
+	for _, value := range query["tag"] {
+		filter.Tags = append(filter.Tags, strings.Split(value, ",")...)
+	}
+	filter.Query = query.Get("q")
+	filter.CampaignID = query.Get("campaign")
+
+	return filter
+}
+
+// userURLResponse формирует описание ссылки для владельца.
+// Время, неизвестное для старых записей файлового хранилища, не выводится.
+func (h *handler) userURLResponse(record model.URLRecord) (model.UserURLResponse, error) {
+	shortURL, err := url.JoinPath(h.config.Server.BaseURL, record.ShortURL)
+	if err != nil {
+		return model.UserURLResponse{}, err
+	}
+
+	var remaining *int64
+	if record.MaxClicks > 0 {
+		left := max(record.MaxClicks-record.ClickCount, 0)
+		remaining = &left
+	}
+
+	return model.UserURLResponse{
+		ShortURL:        shortURL,
+		OriginalURL:     record.OriginalURL,
+		UserID:          record.UserID,
+		IsDeleted:       record.IsDeleted,
+		CreatedAt:       optionalTime(record.CreatedAt),
+		UpdatedAt:       optionalTime(record.UpdatedAt),
+		ClickCount:      record.ClickCount,
+		RemainingClicks: remaining,
+		Protected:       record.PasswordHash != "",
+		LinkAttributes:  record.LinkAttributes,
+	}, nil
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
 }
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+	"github.com/MarkelovSergey/url-shorter/internal/config"
+	"github.com/MarkelovSergey/url-shorter/internal/middleware"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetUserURLHandler(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+
+	userID := "test-user-123"
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		userID         string
+		mockSetup      func(*urlshorterservice.MockURLShorterService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "link with metadata",
+			userID: userID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetUserURL(mock.Anything, userID, "abc").Return(model.URLRecord{
+					ShortURL:    "abc",
+					OriginalURL: "https://example.com",
+					UserID:      userID,
+					IsDeleted:   true,
+					CreatedAt:   createdAt,
+					UpdatedAt:   createdAt,
+					ClickCount:  3,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"short_url":"http://localhost:8080/abc","original_url":"https://example.com",` +
+				`"user_id":"test-user-123","is_deleted":true,"created_at":"2025-01-02T03:04:05Z",` +
+				`"updated_at":"2025-01-02T03:04:05Z","click_count":3}`,
+		},
+		{
+			name:   "legacy record without timestamps",
+			userID: userID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetUserURL(mock.Anything, userID, "abc").Return(model.URLRecord{
+					ShortURL:    "abc",
+					OriginalURL: "https://example.com",
+					UserID:      userID,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"short_url":"http://localhost:8080/abc","original_url":"https://example.com",` +
+				`"user_id":"test-user-123","is_deleted":false,"click_count":0}`,
+		},
+		{
+			name:   "limited protected link shows remaining uses",
+			userID: userID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetUserURL(mock.Anything, userID, "abc").Return(model.URLRecord{
+					ShortURL:       "abc",
+					OriginalURL:    "https://example.com",
+					UserID:         userID,
+					ClickCount:     7,
+					PasswordHash:   "hash",
+					LinkAttributes: model.LinkAttributes{MaxClicks: 5},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"short_url":"http://localhost:8080/abc","original_url":"https://example.com",` +
+				`"user_id":"test-user-123","is_deleted":false,"click_count":7,"remaining_clicks":0,` +
+				`"protected":true,"max_clicks":5}`,
+		},
+		{
+			name:   "unknown or foreign link",
+			userID: userID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetUserURL(mock.Anything, userID, "abc").
+					Return(model.URLRecord{}, fmt.Errorf("%w: abc", service.ErrFindShortCode))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing user ID",
+			mockSetup:      func(m *urlshorterservice.MockURLShorterService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockService := new(urlshorterservice.MockURLShorterService)
+			test.mockSetup(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls/abc", nil)
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", "abc")
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+			if test.userID != "" {
+				ctx = middleware.SetUserID(ctx, test.userID)
+			}
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+
+			h := New(cfg, mockService, new(healthservice.MockHealthService), logger, audit.NewMockPublisher())
+			h.GetUserURLHandler(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetUserURLsHandlerFilter(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+	userID := "test-user-123"
+
+	mockService := new(urlshorterservice.MockURLShorterService)
+	mockService.EXPECT().
+		GetUserURLs(mock.Anything, userID, model.URLFilter{Tags: []string{"work", "q3", "docs"}, Query: "report"}).
+		Return([]model.URLRecord{{
+			ShortURL:       "abc",
+			OriginalURL:    "https://example.com",
+			UserID:         userID,
+			LinkAttributes: model.LinkAttributes{Title: "Report", Tags: []string{"docs", "q3", "work"}},
+		}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/user/urls?tag=work,q3&tag=docs&q=report", nil)
+	req = req.WithContext(middleware.SetUserID(req.Context(), userID))
+	w := httptest.NewRecorder()
+
+	h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), audit.NewMockPublisher())
+	h.GetUserURLsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"short_url":"http://localhost:8080/abc","original_url":"https://example.com",`+
+		`"user_id":"test-user-123","is_deleted":false,"click_count":0,`+
+		`"title":"Report","tags":["docs","q3","work"]}]`, w.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateUserURLHandler(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+	userID := "test-user-123"
+	title := "New title"
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		mockSetup      func(*urlshorterservice.MockURLShorterService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "partial update",
+			contentType: "application/json",
+			body:        `{"title":"New title"}`,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().UpdateUserURL(mock.Anything, userID, "abc", model.LinkUpdate{Title: &title}).
+					Return(model.URLRecord{
+						ShortURL:       "abc",
+						OriginalURL:    "https://example.com",
+						UserID:         userID,
+						LinkAttributes: model.LinkAttributes{Title: title, Tags: []string{"work"}},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"short_url":"http://localhost:8080/abc","original_url":"https://example.com",` +
+				`"user_id":"test-user-123","is_deleted":false,"click_count":0,"title":"New title","tags":["work"]}`,
+		},
+		{
+			name:        "invalid attributes",
+			contentType: "application/json",
+			body:        `{"tags":["a,b"]}`,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().UpdateUserURL(mock.Anything, userID, "abc", mock.Anything).
+					Return(model.URLRecord{}, service.NewValidationError("tags", "invalid_tag", `tag "a,b" is not allowed`))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "deleted link",
+			contentType: "application/json",
+			body:        `{"notes":""}`,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().UpdateUserURL(mock.Anything, userID, "abc", mock.Anything).
+					Return(model.URLRecord{}, service.ErrURLDeleted)
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:        "unknown link",
+			contentType: "application/json",
+			body:        `{"notes":""}`,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().UpdateUserURL(mock.Anything, userID, "abc", mock.Anything).
+					Return(model.URLRecord{}, fmt.Errorf("%w: abc", service.ErrFindShortCode))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid JSON",
+			contentType:    "application/json",
+			body:           `{"title":`,
+			mockSetup:      func(m *urlshorterservice.MockURLShorterService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unsupported content type",
+			contentType:    "text/plain",
+			body:           `{"title":"x"}`,
+			mockSetup:      func(m *urlshorterservice.MockURLShorterService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockService := new(urlshorterservice.MockURLShorterService)
+			test.mockSetup(mockService)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/user/urls/abc", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", test.contentType)
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", "abc")
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+			req = req.WithContext(middleware.SetUserID(ctx, userID))
+
+			w := httptest.NewRecorder()
+
+			publisher := audit.NewMockPublisher()
+			h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), publisher)
+			h.UpdateUserURLHandler(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, w.Body.String())
+			}
+			if w.Code == http.StatusOK {
+				require.Len(t, publisher.Events, 1)
+				assert.Equal(t, audit.ActionUpdate, publisher.Events[0].Action)
+				assert.Equal(t, "abc", publisher.Events[0].ShortCode)
+				assert.Equal(t, userID, *publisher.Events[0].UserID)
+			} else {
+				assert.Empty(t, publisher.Events)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
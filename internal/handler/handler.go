@@ -1,8 +1,20 @@
 package handler
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
 	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/config"
+	"github.com/MarkelovSergey/url-shorter/internal/enumguard"
+	"github.com/MarkelovSergey/url-shorter/internal/middleware"
+	"github.com/MarkelovSergey/url-shorter/internal/ratelimit"
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
+	"github.com/MarkelovSergey/url-shorter/internal/service/campaignservice"
 	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
 	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
 	"go.uber.org/zap"
@@ -15,6 +27,63 @@ type handler struct {
 	healthService     healthservice.HealthService
 	logger            *zap.Logger
 	auditPublisher    audit.Publisher
+	auditStore        audit.Store
+	adminToken        string
+	enumGuard         *enumguard.Guard
+	campaignService   campaignservice.CampaignService
+	passwordLimiter   ratelimit.Limiter
+	linkAccessKey     []byte
+	comingSoonPage    []byte
+	clock             urlshorterservice.Clock
+}
+
+// Option задает необязательную зависимость обработчика.
+type Option func(*handler)
+
+// WithAuditStore подключает хранилище событий аудита для административного API.
+// Доступ к API разрешен только с токеном adminToken.
+func WithAuditStore(store audit.Store, adminToken string) Option {
+	return func(h *handler) {
+		h.auditStore = store
+		h.adminToken = adminToken
+	}
+}
+
+// WithEnumerationGuard включает обнаружение перебора коротких кодов в ReadHandler.
+func WithEnumerationGuard(guard *enumguard.Guard) Option {
+	return func(h *handler) {
+		h.enumGuard = guard
+	}
+}
+
+// WithCampaignService подключает сервис кампаний для эндпоинтов /api/user/campaigns.
+func WithCampaignService(campaignService campaignservice.CampaignService) Option {
+	return func(h *handler) {
+		h.campaignService = campaignService
+	}
+}
+
+// WithPasswordAttemptLimiter задает ограничитель попыток ввода пароля ссылок.
+// По умолчанию попытки считаются в памяти процесса.
+func WithPasswordAttemptLimiter(limiter ratelimit.Limiter) Option {
+	return func(h *handler) {
+		h.passwordLimiter = limiter
+	}
+}
+
+// WithComingSoonPage задает HTML-страницу для переходов по ссылкам, которые еще не начали действовать.
+func WithComingSoonPage(page []byte) Option {
+	return func(h *handler) {
+		h.comingSoonPage = page
+	}
+}
+
+// WithClock задает источник времени для заголовков перенаправления и шаблонов UTM.
+// Должен совпадать с источником времени сервиса (urlshorterservice.WithClock).
+func WithClock(clock urlshorterservice.Clock) Option {
+	return func(h *handler) {
+		h.clock = clock
+	}
 }
 
 // New создает новый экземпляр обработчика с заданными зависимостями.
@@ -25,6 +94,67 @@ func New(
 	healthService healthservice.HealthService,
 	logger *zap.Logger,
 	auditPublisher audit.Publisher,
+	opts ...Option,
 ) *handler {
-	return &handler{config, urlShorterService, healthService, logger, auditPublisher}
+	h := &handler{
+		config:            config,
+		urlShorterService: urlShorterService,
+		healthService:     healthService,
+		logger:            logger,
+		auditPublisher:    auditPublisher,
+		passwordLimiter:   ratelimit.NewMemoryLimiter(),
+		linkAccessKey:     []byte(config.LinkPassword.CookieSecret),
+		clock:             urlshorterservice.SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if len(h.linkAccessKey) == 0 {
+		h.linkAccessKey = make([]byte, 32)
+		rand.Read(h.linkAccessKey)
+	}
+
+	return h
+}
+
+// validateURL проверяет длину и схему URL назначения из запроса.
+// Длина проверяется до разбора, чтобы слишком длинный URL всегда давал 413,
+// независимо от хранилища.
+func (h *handler) validateURL(raw string) error {
+	if limit := h.config.URLPolicy.MaxLength; limit > 0 && len(raw) > limit {
+		return newURLTooLongError(limit)
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed == nil ||
+		(!strings.HasPrefix(raw, "http://") && !strings.HasPrefix(raw, "https://")) {
+		return errInvalidURL
+	}
+
+	return nil
+}
+
+// writeJSON отправляет ответ в формате application/json.
+func (h *handler) writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.log(r.Context()).Error("Failed to encode response: " + err.Error())
+	}
+}
+
+// log возвращает логгер, дополненный идентификатором текущего запроса.
+func (h *handler) log(ctx context.Context) *zap.Logger {
+	return requestid.Logger(ctx, h.logger)
+}
+
+// newAuditEvent создает событие аудита, связанное с текущим запросом:
+// заполняет идентификатор запроса и метаданные клиента.
+func (h *handler) newAuditEvent(r *http.Request, action audit.Action, url string, userID *string) audit.Event {
+	return audit.NewEvent(action, url, userID).
+		WithRequestID(requestid.FromContext(r.Context())).
+		WithClient(middleware.ClientIP(r), r.UserAgent(), r.Referer())
 }
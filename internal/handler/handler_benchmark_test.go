@@ -85,7 +85,7 @@ func BenchmarkReadHandler(b *testing.B) {
 
 	// Создаём URL напрямую через сервис
 	ctx := context.Background()
-	shortCode, err := service.Generate(ctx, "https://example.com/test", "test-user")
+	shortCode, err := service.Generate(ctx, "https://example.com/test", "test-user", model.LinkAttributes{})
 	if err != nil {
 		b.Fatalf("failed to generate URL: %v", err)
 	}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/middleware"
+	"github.com/MarkelovSergey/url-shorter/internal/ratelimit"
+	"github.com/MarkelovSergey/url-shorter/internal/service/linkpassword"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// linkAccessCookie - cookie, подтверждающий ввод пароля ссылки.
+	// Выдается с путем короткой ссылки, поэтому у каждой ссылки свой cookie.
+	linkAccessCookie = "link_access"
+	// maxPasswordFormSize - наибольший размер формы ввода пароля.
+	maxPasswordFormSize = 4 << 10
+)
+
+// passwordPage - страница ввода пароля. Форма отправляется на адрес текущей страницы.
+var passwordPage = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Password required</title>
+</head>
+<body>
+<form method="post">
+<h1>This link is password protected</h1>
+{{if .}}<p role="alert">{{.}}</p>{{end}}
+<label for="password">Password</label>
+<input id="password" name="password" type="password" autocomplete="current-password" required autofocus>
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+// unlockLink обрабатывает переход по ссылке, защищенной паролем, без cookie доступа.
+// GET и HEAD получают страницу ввода пароля. POST проверяет пароль: при успехе выдает
+// cookie доступа и отправляет клиента по той же ссылке, иначе снова показывает страницу.
+// Число попыток ввода ограничено для каждого клиента и ссылки. Если ограничитель недоступен,
+// пароль не проверяется (503): иначе сбой хранилища счетчиков открывал бы перебор паролей.
+func (h *handler) unlockLink(w http.ResponseWriter, r *http.Request, id, rawID, passwordHash string) {
+	if r.Method != http.MethodPost {
+		h.writePasswordPage(w, r, http.StatusOK, "")
+
+		return
+	}
+
+	retryAfter, allowed, err := h.allowPasswordAttempt(r, id)
+	if err != nil {
+		w.Header().Set("Retry-After", "1")
+		h.writePasswordPage(w, r, http.StatusServiceUnavailable, "The password can not be checked now. Try again later.")
+
+		return
+	}
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		h.writePasswordPage(w, r, http.StatusTooManyRequests, "Too many attempts. Try again later.")
+
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPasswordFormSize)
+	if err := r.ParseForm(); err != nil {
+		h.writePasswordPage(w, r, http.StatusBadRequest, "The form could not be read.")
+
+		return
+	}
+
+	if !linkpassword.Compare(passwordHash, r.PostForm.Get("password")) {
+		h.writePasswordPage(w, r, http.StatusForbidden, "Wrong password.")
+
+		return
+	}
+
+	if err := h.setLinkAccess(w, id, rawID); err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+}
+
+// allowPasswordAttempt расходует попытку ввода пароля клиента.
+// Возвращает ошибку, если ограничитель недоступен.
+func (h *handler) allowPasswordAttempt(r *http.Request, id string) (time.Duration, bool, error) {
+	cfg := h.config.LinkPassword
+	if cfg.MaxAttempts <= 0 || cfg.AttemptWindow <= 0 {
+		return 0, true, nil
+	}
+
+	limit := ratelimit.Limit{Rate: float64(cfg.MaxAttempts) / cfg.AttemptWindow.Seconds(), Burst: cfg.MaxAttempts}
+	decision, err := h.passwordLimiter.Allow(r.Context(), "link_password:"+middleware.ClientIP(r)+":"+id, limit)
+	if err != nil {
+		h.log(r.Context()).Error("password attempt limiter failed", zap.String("short_code", id), zap.Error(err))
+
+		return 0, false, err
+	}
+
+	return decision.RetryAfter, decision.Allowed, nil
+}
+
+// writePasswordPage отправляет страницу ввода пароля с сообщением message.
+func (h *handler) writePasswordPage(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if err := passwordPage.Execute(w, message); err != nil {
+		h.log(r.Context()).Error("failed to render password page", zap.Error(err))
+	}
+}
+
+// hasLinkAccess проверяет cookie доступа к ссылке id.
+func (h *handler) hasLinkAccess(r *http.Request, id string) bool {
+	for _, cookie := range r.CookiesNamed(linkAccessCookie) {
+		var claims jwt.RegisteredClaims
+		token, err := jwt.ParseWithClaims(cookie.Value, &claims, func(*jwt.Token) (any, error) {
+			return h.linkAccessKey, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+		if err == nil && token.Valid && claims.Subject == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setLinkAccess выдает подписанный cookie доступа к ссылке id на время LinkPassword.CookieTTL.
+func (h *handler) setLinkAccess(w http.ResponseWriter, id, rawID string) error {
+	ttl := h.config.LinkPassword.CookieTTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   id,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}).SignedString(h.linkAccessKey)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     linkAccessCookie,
+		Value:    value,
+		Path:     "/" + rawID,
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.config.Server.BaseURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+	"github.com/MarkelovSergey/url-shorter/internal/config"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/ratelimit"
+	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
+	"github.com/MarkelovSergey/url-shorter/internal/service/linkpassword"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReadHandlerPasswordProtected(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+	cfg.LinkPassword = config.LinkPasswordConfig{
+		CookieSecret:  "test-secret",
+		CookieTTL:     time.Hour,
+		MaxAttempts:   2,
+		AttemptWindow: time.Hour,
+	}
+
+	hash, err := linkpassword.Hash("s3cret")
+	require.NoError(t, err)
+
+	mockService := new(urlshorterservice.MockURLShorterService)
+	mockService.EXPECT().GetOriginalURL(mock.Anything, "abc").Return(model.Redirect{
+		OriginalURL:  "https://example.com/internal",
+		RedirectType: http.StatusMovedPermanently,
+		PasswordHash: hash,
+	}, nil)
+	mockService.EXPECT().GetOriginalURL(mock.Anything, "open").Return(model.Redirect{OriginalURL: "https://example.com"}, nil)
+	mockService.EXPECT().RecordClick(mock.Anything, "abc").Return(nil).Once()
+
+	publisher := audit.NewMockPublisher()
+	h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), publisher)
+
+	serve := func(method, target, password string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		var body *strings.Reader
+		if method == http.MethodPost {
+			body = strings.NewReader(url.Values{"password": {password}}.Encode())
+		} else {
+			body = strings.NewReader("")
+		}
+
+		req := httptest.NewRequest(method, target, body)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if method == http.MethodPost {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+
+		w := httptest.NewRecorder()
+		h.ReadHandler(w, req)
+
+		return w
+	}
+
+	t.Run("prompt is shown without access cookie", func(t *testing.T) {
+		w := serve(http.MethodGet, "/abc?x=1", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.Empty(t, w.Header().Get("Location"))
+		assert.Contains(t, w.Body.String(), `<form method="post">`)
+		assert.NotContains(t, w.Body.String(), "example.com")
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		w := serve(http.MethodPost, "/abc", "guess")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Wrong password.")
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	var access *http.Cookie
+	t.Run("right password issues a cookie scoped to the code", func(t *testing.T) {
+		w := serve(http.MethodPost, "/abc?x=1", "s3cret")
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/abc?x=1", w.Header().Get("Location"))
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		access = cookies[0]
+		assert.Equal(t, "/abc", access.Path)
+		assert.True(t, access.HttpOnly)
+	})
+
+	t.Run("attempts are limited per client", func(t *testing.T) {
+		w := serve(http.MethodPost, "/abc", "s3cret")
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+
+	t.Run("cookie unlocks the redirect", func(t *testing.T) {
+		require.NotNil(t, access)
+		w := serve(http.MethodGet, "/abc", "", access)
+
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "https://example.com/internal", w.Header().Get("Location"))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"), "protected redirects are not cached")
+		assert.Len(t, publisher.Events, 1)
+	})
+
+	t.Run("forged cookie is rejected", func(t *testing.T) {
+		w := serve(http.MethodGet, "/abc", "", &http.Cookie{Name: linkAccessCookie, Value: access.Value + "x"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Location"))
+	})
+
+	t.Run("cookie of another code is rejected", func(t *testing.T) {
+		other := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), publisher)
+		rec := httptest.NewRecorder()
+		require.NoError(t, other.setLinkAccess(rec, "xyz", "xyz"))
+
+		w := serve(http.MethodGet, "/abc", "", rec.Result().Cookies()[0])
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST to a link without password", func(t *testing.T) {
+		w := serve(http.MethodPost, "/open", "s3cret")
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+// failingLimiter имитирует недоступное хранилище счетчиков попыток.
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("database is down")
+}
+
+func TestReadHandlerPasswordLimiterFailure(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+	cfg.LinkPassword = config.LinkPasswordConfig{MaxAttempts: 5, AttemptWindow: time.Minute}
+
+	hash, err := linkpassword.Hash("s3cret")
+	require.NoError(t, err)
+
+	mockService := new(urlshorterservice.MockURLShorterService)
+	mockService.EXPECT().GetOriginalURL(mock.Anything, "abc").Return(model.Redirect{
+		OriginalURL:  "https://example.com/internal",
+		PasswordHash: hash,
+	}, nil)
+
+	h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), audit.NewMockPublisher(),
+		WithPasswordAttemptLimiter(failingLimiter{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/abc", strings.NewReader(url.Values{"password": {"s3cret"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ReadHandler(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "password is not checked without the limiter")
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Empty(t, w.Result().Cookies())
+	mockService.AssertExpectations(t)
+}
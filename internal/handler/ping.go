@@ -1,18 +1,14 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
-
-	"go.uber.org/zap"
 )
 
 // PingHandler проверяет доступность базы данных.
 func (h *handler) PingHandler(w http.ResponseWriter, r *http.Request) {
 	if err := h.healthService.Ping(r.Context()); err != nil {
-		h.logger.Error("health check failed", zap.Error(err))
-
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		h.writeProblem(w, r, fmt.Errorf("health check failed: %w", err))
 
 		return
 	}
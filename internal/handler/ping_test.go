@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/config"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
 	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
 	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
 	"github.com/stretchr/testify/assert"
@@ -50,7 +52,7 @@ func TestPingHandler(t *testing.T) {
 				m.EXPECT().Ping(context.Background()).Return(errors.New("database connection failed"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   http.StatusText(http.StatusInternalServerError),
+			expectedBody:   "internal server error",
 		},
 	}
 
@@ -69,7 +71,16 @@ func TestPingHandler(t *testing.T) {
 			h.PingHandler(w, req)
 
 			assert.Equal(t, test.expectedStatus, w.Code)
-			assert.Equal(t, test.expectedBody, w.Body.String())
+
+			if test.expectedStatus == http.StatusOK {
+				assert.Equal(t, test.expectedBody, w.Body.String())
+			} else {
+				var problem model.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &problem)
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedBody, problem.Detail)
+				assert.NotContains(t, w.Body.String(), "database connection failed")
+			}
 
 			mockHealthService.AssertExpectations(t)
 		})
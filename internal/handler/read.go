@@ -2,40 +2,307 @@ package handler
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/audit"
+	"github.com/MarkelovSergey/url-shorter/internal/enumguard"
+	"github.com/MarkelovSergey/url-shorter/internal/middleware"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
 	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/passthrough"
+	"github.com/MarkelovSergey/url-shorter/internal/service/utmtemplate"
+	"go.uber.org/zap"
 )
 
 // ReadHandler обрабатывает запрос на перенаправление по короткой ссылке.
+// Короткий код - первый сегмент пути. Остаток пути допустим только для ссылок
+// с включенной пересылкой: /{id}/rest?x=1 ведет на destination/rest?x=1.
+// Запрос HEAD получает тот же ответ, но не учитывается как переход.
+// Для ссылок с паролем переход выполняется только с cookie доступа, который выдается
+// после ввода пароля (см. unlockLink); POST принимается только такими ссылками.
 func (h *handler) ReadHandler(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
+	if h.enumGuard != nil {
+		if verdict := h.enumGuard.Check(middleware.ClientIP(r)); verdict.Blocked {
+			h.writeProbeBlocked(w, r, verdict)
 
-	if len(parts) != 2 {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("ID not found"))
+			return
+		}
+	}
+
+	rawID, rest, hasRest := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+	id, unescapeErr := url.PathUnescape(rawID)
+	if unescapeErr != nil || id == "" {
+		h.handleReadMiss(w, r, "", errIDNotFound)
+
+		return
+	}
+
+	redirect, err := h.urlShorterService.GetOriginalURL(r.Context(), id)
+	if isNotFound(err) {
+		h.handleReadMiss(w, r, id, err)
+
+		return
+	}
+
+	if err == nil && hasRest && !redirect.Passthrough {
+		h.handleReadMiss(w, r, id, errIDNotFound)
+
+		return
+	}
+
+	if h.enumGuard != nil && (err == nil || isKnownLink(err)) {
+		h.enumGuard.Record(middleware.ClientIP(r), true)
+	}
+
+	var notActive *service.NotActiveError
+	if errors.As(err, &notActive) {
+		h.writeComingSoon(w, r, notActive.ActiveFrom)
 
 		return
 	}
 
-	id := parts[len(parts)-1]
-	u, err := h.urlShorterService.GetOriginalURL(r.Context(), id)
+	if errors.Is(err, service.ErrURLExpired) {
+		h.auditPublisher.Publish(h.newAuditEvent(r, audit.ActionFollowExpired, "", nil).
+			WithShortCode(id).
+			WithStatus(http.StatusGone))
+	}
+
 	if err != nil {
-		if errors.Is(err, service.ErrURLDeleted) {
-			w.WriteHeader(http.StatusGone)
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	if redirect.PasswordHash != "" && (r.Method == http.MethodPost || !h.hasLinkAccess(r, id)) {
+		h.unlockLink(w, r, id, rawID, redirect.PasswordHash)
+
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		w.Header().Set("Allow", "GET, HEAD")
+		h.writeProblem(w, r, errMethodNotAllowed)
+
+		return
+	}
+
+	if hasRest {
+		rest = "/" + rest
+	}
+
+	target, err := h.destination(r, id, rest, redirect)
+	if err != nil {
+		h.writeProblem(w, r, err)
+
+		return
+	}
+
+	status := h.redirectStatus(redirect)
+	if r.Method != http.MethodHead {
+		if err := h.recordFollow(r, id, redirect, status); err != nil {
+			h.writeProblem(w, r, err)
+
 			return
 		}
+	}
 
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("ID not found"))
+	h.setCacheHeaders(w, status, redirect, h.clock.Now())
+	http.Redirect(w, r, target, status)
+}
+
+// recordFollow учитывает переход по ссылке и публикует событие аудита.
+// Возвращает service.ErrURLExhausted, если переходы по ссылке закончились.
+// Для ссылок с ограничением числа переходов любая ошибка учета отменяет переход,
+// иначе при сбое хранилища ограничение не действовало бы; для остальных ссылок
+// ошибка учета только записывается в журнал.
+func (h *handler) recordFollow(r *http.Request, id string, redirect model.Redirect, status int) error {
+	if err := h.urlShorterService.RecordClick(r.Context(), id); err != nil {
+		if errors.Is(err, service.ErrURLExhausted) || redirect.MaxClicks > 0 {
+			return err
+		}
+
+		h.log(r.Context()).Warn("failed to record click", zap.String("short_code", id), zap.Error(err))
+	}
+
+	var followerID *string
+	if userID, ok := middleware.GetUserID(r.Context()); ok && middleware.IsAuthenticated(r.Context()) {
+		followerID = &userID
+	}
+
+	h.auditPublisher.Publish(h.newAuditEvent(r, audit.ActionFollow, redirect.OriginalURL, followerID).
+		WithShortCode(id).
+		WithStatus(status))
+
+	return nil
+}
+
+// redirectStatus возвращает код перенаправления ссылки или код по умолчанию из конфигурации.
+func (h *handler) redirectStatus(redirect model.Redirect) int {
+	if redirect.RedirectType != 0 {
+		return redirect.RedirectType
+	}
+
+	if h.config.Redirect.Status != 0 {
+		return h.config.Redirect.Status
+	}
+
+	return http.StatusTemporaryRedirect
+}
+
+// setCacheHeaders задает Cache-Control и Expires для перенаправления.
+// Кешировать можно только постоянные перенаправления (301, 308): их назначение не меняется,
+// а повторные переходы из кеша не доходят до сервиса и не учитываются.
+// Срок кеширования ограничен настройкой и временем истечения ссылки.
+// Временные перенаправления используются для изменяемых и отслеживаемых ссылок и не кешируются,
+// как и перенаправления ссылок с паролем или ограничением числа переходов:
+// иначе повторный переход обошел бы проверку cookie или учет переходов.
+func (h *handler) setCacheHeaders(w http.ResponseWriter, status int, redirect model.Redirect, now time.Time) {
+	var maxAge time.Duration
+	cacheable := redirect.PasswordHash == "" && redirect.MaxClicks == 0
+	if cacheable && (status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect) {
+		maxAge = h.config.Redirect.CacheMaxAge
+		if redirect.ExpiresAt != nil {
+			maxAge = min(maxAge, redirect.ExpiresAt.Sub(now))
+		}
+	}
+
+	maxAge = maxAge.Truncate(time.Second)
+	if maxAge <= 0 {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Expires", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.FormatInt(int64(maxAge/time.Second), 10))
+	w.Header().Set("Expires", now.Add(maxAge).UTC().Format(http.TimeFormat))
+}
+
+// destination собирает адрес перехода: для ссылок с пересылкой добавляет к URL назначения
+// остаток пути rest и параметры запроса, затем параметры шаблона ссылки.
+// Если шаблон применить не удалось, он пропускается.
+func (h *handler) destination(r *http.Request, id, rest string, redirect model.Redirect) (string, error) {
+	target := redirect.OriginalURL
+	if redirect.Passthrough {
+		joined, err := passthrough.Join(target, rest, r.URL.RawQuery)
+		if err != nil {
+			if errors.Is(err, passthrough.ErrUnsafePath) {
+				return "", errInvalidPath
+			}
+
+			return "", err
+		}
+
+		target = joined
+	}
+
+	u, err := utmtemplate.Apply(target, redirect.UTMParams, redirect.UTMOverride, utmtemplate.Vars{
+		ShortCode: id,
+		Date:      h.clock.Now(),
+		Campaign:  redirect.CampaignName,
+	})
+	if err != nil {
+		h.log(r.Context()).Warn("failed to apply UTM template",
+			zap.String("short_code", id), zap.Error(err))
+
+		return target, nil
+	}
+
+	return u, nil
+}
+
+// writeComingSoon отвечает на переход по ссылке, которая начнет действовать в activeFrom:
+// перенаправляет на Redirect.ComingSoonURL, отдает страницу WithComingSoonPage
+// или сообщает об ошибке url_not_active. Retry-After указывает время до начала действия.
+func (h *handler) writeComingSoon(w http.ResponseWriter, r *http.Request, activeFrom time.Time) {
+	w.Header().Set("Cache-Control", "no-store")
+	if wait := activeFrom.Sub(h.clock.Now()); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	}
+
+	if target := h.config.Redirect.ComingSoonURL; target != "" {
+		http.Redirect(w, r, target, http.StatusFound)
+
+		return
+	}
+
+	if h.comingSoonPage != nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if r.Method != http.MethodHead {
+			w.Write(h.comingSoonPage)
+		}
+
+		return
+	}
+
+	h.writeProblem(w, r, service.ErrURLNotActive)
+}
+
+// handleReadMiss отвечает на запрос несуществующего кода.
+// При включенном обнаружении перебора ответ замедляется или клиент блокируется.
+func (h *handler) handleReadMiss(w http.ResponseWriter, r *http.Request, id string, err error) {
+	if h.enumGuard == nil {
+		h.writeProblem(w, r, err)
 
 		return
 	}
 
-	h.auditPublisher.Publish(audit.NewEvent(audit.ActionFollow, u, nil))
+	clientIP := middleware.ClientIP(r)
+	verdict := h.enumGuard.Record(clientIP, false)
+
+	if verdict.NewlyBlocked {
+		h.log(r.Context()).Warn("client blocked for probing short codes",
+			zap.String("client_ip", clientIP),
+			zap.Duration("duration", verdict.RetryAfter),
+		)
+
+		h.auditPublisher.Publish(h.newAuditEvent(r, audit.ActionProbeBlock, "", nil).
+			WithShortCode(id).
+			WithStatus(http.StatusTooManyRequests))
+	}
+
+	if verdict.Blocked {
+		h.writeProbeBlocked(w, r, verdict)
+
+		return
+	}
+
+	if verdict.Delay > 0 {
+		timer := time.NewTimer(verdict.Delay)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+
+			return
+		}
+	}
+
+	h.writeProblem(w, r, err)
+}
+
+func (h *handler) writeProbeBlocked(w http.ResponseWriter, r *http.Request, verdict enumguard.Verdict) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(verdict.RetryAfter.Seconds()))))
+	h.writeProblem(w, r, errProbeBlocked)
+}
+
+// isKnownLink проверяет, что код существует, хотя перейти по нему нельзя.
+func isKnownLink(err error) bool {
+	return errors.Is(err, service.ErrURLDeleted) ||
+		errors.Is(err, service.ErrURLExhausted) ||
+		errors.Is(err, service.ErrURLExpired) ||
+		errors.Is(err, service.ErrURLNotActive) ||
+		errors.Is(err, service.ErrURLBlocked)
+}
 
-	http.Redirect(w, r, u, http.StatusTemporaryRedirect)
+// isNotFound проверяет, что запрошенный код не существует.
+func isNotFound(err error) bool {
+	return errors.Is(err, service.ErrFindShortCode) || errors.Is(err, repository.ErrNotFound)
 }
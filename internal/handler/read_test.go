@@ -1,18 +1,27 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/audit"
 	"github.com/MarkelovSergey/url-shorter/internal/config"
+	"github.com/MarkelovSergey/url-shorter/internal/enumguard"
+	"github.com/MarkelovSergey/url-shorter/internal/middleware"
+	"github.com/MarkelovSergey/url-shorter/internal/model"
 	"github.com/MarkelovSergey/url-shorter/internal/service"
 	"github.com/MarkelovSergey/url-shorter/internal/service/healthservice"
 	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestReadHandler(t *testing.T) {
@@ -44,25 +53,83 @@ func TestReadHandler(t *testing.T) {
 			method: http.MethodGet,
 			path:   "/" + shortID,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(originalURL, nil)
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{OriginalURL: originalURL}, nil)
+				m.EXPECT().RecordClick(mock.Anything, shortID).Return(nil)
 			},
 			expectedStatus: http.StatusTemporaryRedirect,
 			expectedURL:    originalURL,
 		},
 		{
-			name:           "Invalid path format",
-			method:         http.MethodGet,
-			path:           "/some/invalid/path",
-			mockSetup:      func(m *urlshorterservice.MockURLShorterService) {},
+			name:   "UTM template is merged into the destination",
+			method: http.MethodGet,
+			path:   "/" + shortID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{
+					ShortURL:     shortID,
+					OriginalURL:  originalURL + "/?utm_source=site&page=2",
+					UTMParams:    map[string]string{"utm_source": "mail", "utm_campaign": "{campaign}", "utm_content": "{short_code}"},
+					CampaignName: "spring",
+				}, nil)
+				m.EXPECT().RecordClick(mock.Anything, shortID).Return(nil)
+			},
+			expectedStatus: http.StatusTemporaryRedirect,
+			expectedURL:    originalURL + "/?utm_source=site&page=2&utm_campaign=spring&utm_content=test",
+		},
+		{
+			name:   "path after the code without passthrough",
+			method: http.MethodGet,
+			path:   "/some/invalid/path",
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, "some").Return(model.Redirect{OriginalURL: originalURL}, nil)
+			},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "ID not found",
 		},
+		{
+			name:   "query is ignored without passthrough",
+			method: http.MethodGet,
+			path:   "/" + shortID + "?x=1",
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{OriginalURL: originalURL}, nil)
+				m.EXPECT().RecordClick(mock.Anything, shortID).Return(nil)
+			},
+			expectedStatus: http.StatusTemporaryRedirect,
+			expectedURL:    originalURL,
+		},
+		{
+			name:   "passthrough forwards path and query",
+			method: http.MethodGet,
+			path:   "/" + shortID + "/learn/go%20course/?x=1&lang=ru",
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{
+					OriginalURL: originalURL + "/catalog?lang=en&utm_source=site",
+					UTMParams:   map[string]string{"utm_source": "mail"},
+					Passthrough: true,
+				}, nil)
+				m.EXPECT().RecordClick(mock.Anything, shortID).Return(nil)
+			},
+			expectedStatus: http.StatusTemporaryRedirect,
+			expectedURL:    originalURL + "/catalog/learn/go%20course/?utm_source=site&x=1&lang=ru",
+		},
+		{
+			name:   "passthrough refuses to leave the destination path",
+			method: http.MethodGet,
+			path:   "/" + shortID + "/%2e%2e/admin",
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{
+					OriginalURL: originalURL + "/catalog",
+					Passthrough: true,
+				}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "path can not be forwarded",
+		},
 		{
 			name:   "ID not found",
 			method: http.MethodGet,
 			path:   "/" + shortID,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return("", service.ErrFindShortCode)
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{}, service.ErrFindShortCode)
 			},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "ID not found",
@@ -72,10 +139,62 @@ func TestReadHandler(t *testing.T) {
 			method: http.MethodGet,
 			path:   "/" + shortID,
 			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
-				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return("", service.ErrURLDeleted)
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{}, service.ErrURLDeleted)
+			},
+			expectedStatus: http.StatusGone,
+			expectedBody:   "URL has been deleted",
+		},
+		{
+			name:   "exhausted link returns 410 Gone",
+			method: http.MethodGet,
+			path:   "/" + shortID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{}, service.ErrURLExhausted)
+			},
+			expectedStatus: http.StatusGone,
+			expectedBody:   "URL has no uses left",
+		},
+		{
+			name:   "click accounting failure blocks limited link",
+			method: http.MethodGet,
+			path:   "/" + shortID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{OriginalURL: originalURL, MaxClicks: 1}, nil)
+				m.EXPECT().RecordClick(mock.Anything, shortID).Return(errors.New("disk full"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:   "click accounting failure does not block unlimited link",
+			method: http.MethodGet,
+			path:   "/" + shortID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{OriginalURL: originalURL}, nil)
+				m.EXPECT().RecordClick(mock.Anything, shortID).Return(errors.New("disk full"))
+			},
+			expectedStatus: http.StatusTemporaryRedirect,
+			expectedURL:    originalURL,
+		},
+		{
+			name:   "expired link returns 410 Gone",
+			method: http.MethodGet,
+			path:   "/" + shortID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{}, service.ErrURLExpired)
 			},
 			expectedStatus: http.StatusGone,
-			expectedBody:   "",
+			expectedBody:   "URL has expired",
+		},
+		{
+			name:   "last use taken by a concurrent request",
+			method: http.MethodGet,
+			path:   "/" + shortID,
+			mockSetup: func(m *urlshorterservice.MockURLShorterService) {
+				m.EXPECT().GetOriginalURL(mock.Anything, shortID).Return(model.Redirect{OriginalURL: originalURL, MaxClicks: 1}, nil)
+				m.EXPECT().RecordClick(mock.Anything, shortID).Return(service.ErrURLExhausted)
+			},
+			expectedStatus: http.StatusGone,
+			expectedBody:   "URL has no uses left",
 		},
 	}
 
@@ -96,7 +215,11 @@ func TestReadHandler(t *testing.T) {
 			assert.Equal(t, test.expectedStatus, w.Code)
 
 			if test.expectedBody != "" {
-				assert.Equal(t, test.expectedBody, w.Body.String())
+				var problem model.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &problem)
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedBody, problem.Detail)
+				assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
 			}
 
 			if test.expectedURL != "" {
@@ -107,3 +230,318 @@ func TestReadHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestReadHandlerNotActive(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	activeFrom := now.Add(90 * time.Second)
+
+	tests := []struct {
+		name           string
+		comingSoonURL  string
+		page           []byte
+		expectedStatus int
+		expectedType   string
+		expectedURL    string
+	}{
+		{
+			name:           "problem by default",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedType:   problemContentType,
+		},
+		{
+			name:           "coming soon page",
+			page:           []byte("<h1>Coming soon</h1>"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedType:   "text/html; charset=utf-8",
+		},
+		{
+			name:           "fallback URL wins over page",
+			comingSoonURL:  "https://example.com/soon",
+			page:           []byte("<h1>Coming soon</h1>"),
+			expectedStatus: http.StatusFound,
+			expectedURL:    "https://example.com/soon",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.New("", "http://localhost:8080", "", "", "", "")
+			cfg.Redirect.ComingSoonURL = test.comingSoonURL
+
+			mockService := new(urlshorterservice.MockURLShorterService)
+			mockService.EXPECT().GetOriginalURL(mock.Anything, "abc").
+				Return(model.Redirect{}, &service.NotActiveError{ActiveFrom: activeFrom})
+
+			clock := urlshorterservice.NewMockClock(t)
+			clock.EXPECT().Now().Return(now)
+
+			opts := []Option{WithClock(clock)}
+			if test.page != nil {
+				opts = append(opts, WithComingSoonPage(test.page))
+			}
+			core, logs := observer.New(zapcore.ErrorLevel)
+			h := New(cfg, mockService, new(healthservice.MockHealthService), zap.New(core), audit.NewMockPublisher(), opts...)
+
+			w := httptest.NewRecorder()
+			h.ReadHandler(w, httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			assert.Equal(t, "90", w.Header().Get("Retry-After"))
+			assert.Zero(t, logs.Len(), "a scheduled link is not an internal error")
+			if test.expectedType != "" {
+				assert.Equal(t, test.expectedType, w.Header().Get("Content-Type"))
+			}
+			if test.expectedURL != "" {
+				assert.Equal(t, test.expectedURL, w.Header().Get("Location"))
+			}
+			if test.page != nil && test.expectedURL == "" {
+				assert.Equal(t, string(test.page), w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReadHandlerAuditFollower(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+
+	// Получаем действительный cookie аутентификации через мидлвар.
+	issue := httptest.NewRecorder()
+	middleware.Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+		ServeHTTP(issue, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := issue.Result().Cookies()
+
+	tests := []struct {
+		name         string
+		withCookie   bool
+		expectUserID bool
+	}{
+		{
+			name:         "follower with cookie is recorded",
+			withCookie:   true,
+			expectUserID: true,
+		},
+		{
+			name:         "anonymous follower is recorded without user",
+			withCookie:   false,
+			expectUserID: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockService := new(urlshorterservice.MockURLShorterService)
+			mockService.EXPECT().GetOriginalURL(mock.Anything, "test").Return(model.Redirect{OriginalURL: "https://practicum.yandex.ru"}, nil)
+			mockService.EXPECT().RecordClick(mock.Anything, "test").Return(nil)
+
+			mockAuditPublisher := audit.NewMockPublisher()
+			h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), mockAuditPublisher)
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Referer", "https://example.com/page")
+			if test.withCookie {
+				for _, c := range cookies {
+					req.AddCookie(c)
+				}
+			}
+
+			w := httptest.NewRecorder()
+			middleware.Auth(http.HandlerFunc(h.ReadHandler)).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+			if assert.Len(t, mockAuditPublisher.Events, 1) {
+				event := mockAuditPublisher.Events[0]
+				assert.Equal(t, audit.ActionFollow, event.Action)
+				assert.Equal(t, "test", event.ShortCode)
+				assert.Equal(t, "https://example.com/page", event.Referer)
+				assert.Equal(t, http.StatusTemporaryRedirect, event.Status)
+				assert.Equal(t, audit.SchemaVersion, event.SchemaVersion)
+				assert.Equal(t, test.expectUserID, event.UserID != nil)
+			}
+		})
+	}
+}
+
+func TestReadHandlerAuditFollowExpired(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+
+	mockService := new(urlshorterservice.MockURLShorterService)
+	mockService.EXPECT().GetOriginalURL(mock.Anything, "old").Return(model.Redirect{}, service.ErrURLExpired)
+
+	publisher := audit.NewMockPublisher()
+	h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), publisher)
+
+	w := httptest.NewRecorder()
+	h.ReadHandler(w, httptest.NewRequest(http.MethodGet, "/old", nil))
+
+	assert.Equal(t, http.StatusGone, w.Code)
+	require.Len(t, publisher.Events, 1)
+	assert.Equal(t, audit.ActionFollowExpired, publisher.Events[0].Action)
+	assert.Equal(t, "old", publisher.Events[0].ShortCode)
+	assert.Equal(t, http.StatusGone, publisher.Events[0].Status)
+}
+
+func TestReadHandlerEnumerationGuard(t *testing.T) {
+	cfg := config.New("localhost:8080", "http://localhost:8080", "", "", "", "")
+
+	mockService := new(urlshorterservice.MockURLShorterService)
+	mockService.EXPECT().GetOriginalURL(mock.Anything, "known").Return(model.Redirect{OriginalURL: "https://practicum.yandex.ru"}, nil)
+	mockService.EXPECT().RecordClick(mock.Anything, "known").Return(nil)
+	mockService.EXPECT().GetOriginalURL(mock.Anything, mock.Anything).Return(model.Redirect{}, service.ErrFindShortCode)
+
+	publisher := audit.NewMockPublisher()
+	guard := enumguard.New(enumguard.Options{MinRequests: 4, BlockRatio: 0.75, DelayRatio: 0.7})
+	h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), publisher,
+		WithEnumerationGuard(guard))
+
+	send := func(remoteAddr, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ReadHandler(w, req)
+
+		return w
+	}
+
+	// Клиент с высокой долей попаданий не блокируется.
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusTemporaryRedirect, send("10.0.0.1:1000", "/known").Code)
+	}
+	assert.Equal(t, http.StatusBadRequest, send("10.0.0.1:1000", "/missing").Code)
+
+	// Сканер блокируется после трех промахов из четырех запросов.
+	assert.Equal(t, http.StatusTemporaryRedirect, send("10.0.0.2:1000", "/known").Code)
+	for _, code := range []string{"/a1", "/a2"} {
+		assert.Equal(t, http.StatusBadRequest, send("10.0.0.2:1000", code).Code)
+	}
+
+	blocked := send("10.0.0.2:1000", "/a3")
+	assert.Equal(t, http.StatusTooManyRequests, blocked.Code)
+	assert.NotEmpty(t, blocked.Header().Get("Retry-After"))
+
+	var problem model.Problem
+	assert.NoError(t, json.Unmarshal(blocked.Body.Bytes(), &problem))
+	assert.Equal(t, codeProbeBlocked, problem.Code)
+
+	// Заблокированный клиент не получает редирект даже на существующий код.
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.2:1000", "/known").Code)
+
+	var blocks []audit.Event
+	for _, event := range publisher.Events {
+		if event.Action == audit.ActionProbeBlock {
+			blocks = append(blocks, event)
+		}
+	}
+	if assert.Len(t, blocks, 1) {
+		assert.Equal(t, "10.0.0.2", blocks[0].ClientIP)
+		assert.Equal(t, "a3", blocks[0].ShortCode)
+	}
+}
+
+func TestReadHandlerRedirectStatusAndCaching(t *testing.T) {
+	cfg := config.New("", "http://localhost:8080", "", "", "", "")
+	cfg.Redirect = config.RedirectConfig{Status: http.StatusFound, CacheMaxAge: time.Hour}
+
+	soon := time.Now().Add(10 * time.Minute)
+	expired := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name           string
+		method         string
+		redirect       model.Redirect
+		expectedStatus int
+		expectedCache  string
+		expectClick    bool
+	}{
+		{
+			name:           "default status from config is not cached",
+			method:         http.MethodGet,
+			redirect:       model.Redirect{OriginalURL: "https://example.com"},
+			expectedStatus: http.StatusFound,
+			expectedCache:  "no-store",
+			expectClick:    true,
+		},
+		{
+			name:           "permanent redirect is cached",
+			method:         http.MethodGet,
+			redirect:       model.Redirect{OriginalURL: "https://example.com", RedirectType: http.StatusMovedPermanently},
+			expectedStatus: http.StatusMovedPermanently,
+			expectedCache:  "public, max-age=3600",
+			expectClick:    true,
+		},
+		{
+			name:   "cache lifetime is limited by expiry",
+			method: http.MethodGet,
+			redirect: model.Redirect{
+				OriginalURL:  "https://example.com",
+				RedirectType: http.StatusPermanentRedirect,
+				ExpiresAt:    &soon,
+			},
+			expectedStatus: http.StatusPermanentRedirect,
+			expectedCache:  "public, max-age=599",
+			expectClick:    true,
+		},
+		{
+			name:   "expired permanent redirect is not cached",
+			method: http.MethodGet,
+			redirect: model.Redirect{
+				OriginalURL:  "https://example.com",
+				RedirectType: http.StatusMovedPermanently,
+				ExpiresAt:    &expired,
+			},
+			expectedStatus: http.StatusMovedPermanently,
+			expectedCache:  "no-store",
+			expectClick:    true,
+		},
+		{
+			name:           "temporary per-link status",
+			method:         http.MethodGet,
+			redirect:       model.Redirect{OriginalURL: "https://example.com", RedirectType: http.StatusTemporaryRedirect},
+			expectedStatus: http.StatusTemporaryRedirect,
+			expectedCache:  "no-store",
+			expectClick:    true,
+		},
+		{
+			name:           "HEAD does not record a click",
+			method:         http.MethodHead,
+			redirect:       model.Redirect{OriginalURL: "https://example.com", RedirectType: http.StatusMovedPermanently},
+			expectedStatus: http.StatusMovedPermanently,
+			expectedCache:  "public, max-age=3600",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockService := new(urlshorterservice.MockURLShorterService)
+			mockService.EXPECT().GetOriginalURL(mock.Anything, "abc").Return(test.redirect, nil)
+			if test.expectClick {
+				mockService.EXPECT().RecordClick(mock.Anything, "abc").Return(nil)
+			}
+
+			publisher := audit.NewMockPublisher()
+			h := New(cfg, mockService, new(healthservice.MockHealthService), zap.NewNop(), publisher)
+
+			w := httptest.NewRecorder()
+			h.ReadHandler(w, httptest.NewRequest(test.method, "/abc", nil))
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+			assert.Equal(t, test.expectedCache, w.Header().Get("Cache-Control"))
+
+			expires, err := http.ParseTime(w.Header().Get("Expires"))
+			require.NoError(t, err)
+			if test.expectedCache == "no-store" {
+				assert.True(t, expires.Before(time.Now()))
+			} else {
+				assert.True(t, expires.After(time.Now()))
+			}
+
+			mockService.AssertExpectations(t)
+			if !test.expectClick {
+				mockService.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+				assert.Empty(t, publisher.Events)
+			}
+		})
+	}
+}
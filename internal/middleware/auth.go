@@ -5,6 +5,8 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
@@ -16,7 +18,10 @@ const (
 
 type contextKey string
 
-const userIDKey contextKey = "userID"
+const (
+	userIDKey        contextKey = "userID"
+	authenticatedKey contextKey = "authenticated"
+)
 
 // UserClaims содержит данные пользователя для JWT-токена.
 type UserClaims struct {
@@ -26,6 +31,19 @@ type UserClaims struct {
 
 // Auth - мидлвар для аутентификации пользователей через JWT.
 func Auth(next http.Handler) http.Handler {
+	return authenticate(next, nil)
+}
+
+// AuthWithAudit - мидлвар аутентификации, который публикует событие auth_issue
+// при выдаче нового токена. Предназначен для маршрутов, которым нужен пользователь;
+// для анонимных маршрутов используется Auth, чтобы не публиковать событие на каждого посетителя.
+func AuthWithAudit(publisher audit.Publisher) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authenticate(next, publisher)
+	}
+}
+
+func authenticate(next http.Handler, publisher audit.Publisher) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var userID string
 
@@ -36,7 +54,9 @@ func Auth(next http.Handler) http.Handler {
 			}
 		}
 
-		if userID == "" {
+		authenticated := userID != ""
+
+		if !authenticated {
 			userID = uuid.New().String()
 			tokenString, err := generateJWT(userID)
 			if err != nil {
@@ -51,9 +71,16 @@ func Auth(next http.Handler) http.Handler {
 				Path:     "/",
 				HttpOnly: true,
 			})
+
+			if publisher != nil {
+				publisher.Publish(audit.NewEvent(audit.ActionAuthIssue, "", &userID).
+					WithRequestID(requestid.FromContext(r.Context())).
+					WithClient(ClientIP(r), r.UserAgent(), r.Referer()))
+			}
 		}
 
 		ctx := SetUserID(r.Context(), userID)
+		ctx = context.WithValue(ctx, authenticatedKey, authenticated)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -65,6 +92,14 @@ func GetUserID(ctx context.Context) (string, bool) {
 	return userID, ok
 }
 
+// IsAuthenticated сообщает, был ли ID пользователя получен из действительного токена.
+// Для пользователей, которым токен выдан в текущем запросе, возвращает false.
+func IsAuthenticated(ctx context.Context) bool {
+	authenticated, _ := ctx.Value(authenticatedKey).(bool)
+
+	return authenticated
+}
+
 // SetUserID устанавливает ID пользователя в контекст.
 func SetUserID(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, userIDKey, userID)
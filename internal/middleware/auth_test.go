@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthWithAudit(t *testing.T) {
+	token, err := generateJWT("existing-user")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                  string
+		cookie                *http.Cookie
+		expectedUserID        string
+		expectedAuthenticated bool
+		expectedEvents        int
+	}{
+		{
+			name:                  "valid cookie keeps user",
+			cookie:                &http.Cookie{Name: cookieName, Value: token},
+			expectedUserID:        "existing-user",
+			expectedAuthenticated: true,
+			expectedEvents:        0,
+		},
+		{
+			name:                  "missing cookie issues new token",
+			cookie:                nil,
+			expectedAuthenticated: false,
+			expectedEvents:        1,
+		},
+		{
+			name:                  "invalid cookie issues new token",
+			cookie:                &http.Cookie{Name: cookieName, Value: "broken"},
+			expectedAuthenticated: false,
+			expectedEvents:        1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			publisher := audit.NewMockPublisher()
+
+			var (
+				userID        string
+				authenticated bool
+			)
+			handler := AuthWithAudit(publisher)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, _ = GetUserID(r.Context())
+				authenticated = IsAuthenticated(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("User-Agent", "test-agent")
+			if test.cookie != nil {
+				req.AddCookie(test.cookie)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.NotEmpty(t, userID)
+			if test.expectedUserID != "" {
+				assert.Equal(t, test.expectedUserID, userID)
+			}
+			assert.Equal(t, test.expectedAuthenticated, authenticated)
+
+			require.Len(t, publisher.Events, test.expectedEvents)
+			if test.expectedEvents > 0 {
+				event := publisher.Events[0]
+				assert.Equal(t, audit.ActionAuthIssue, event.Action)
+				assert.Equal(t, userID, *event.UserID)
+				assert.Equal(t, "test-agent", event.UserAgent)
+				assert.Equal(t, "192.0.2.1", event.ClientIP)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
 	"go.uber.org/zap"
 )
 
@@ -38,6 +39,7 @@ func Logging(logger *zap.Logger) func(next http.Handler) http.Handler {
 			dur := time.Since(start)
 
 			logger.Info("HTTP request",
+				zap.String(requestid.FieldName, requestid.FromContext(r.Context())),
 				zap.String("uri", r.RequestURI),
 				zap.String("method", r.Method),
 				zap.Duration("duration", dur),
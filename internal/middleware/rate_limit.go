@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/ratelimit"
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
+	"go.uber.org/zap"
+)
+
+// KeyFunc возвращает ключ, по которому считаются запросы клиента.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP считает запросы по IP-адресу клиента.
+func KeyByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// KeyByUser считает запросы по идентификатору пользователя.
+// Для клиентов без действующего токена используется IP-адрес:
+// иначе каждый запрос без cookie получал бы новую корзину.
+func KeyByUser(r *http.Request) string {
+	if userID, ok := GetUserID(r.Context()); ok && IsAuthenticated(r.Context()) {
+		return "user:" + userID
+	}
+
+	return KeyByIP(r)
+}
+
+// KeyByAPIKey считает запросы по API-ключу из заголовка header.
+// Учитываются только известные ключи keys: для неизвестного или отсутствующего
+// ключа используется IP-адрес, иначе каждый новый ключ получал бы новую корзину.
+// В ключе корзины хранится хеш API-ключа, а не сам ключ.
+func KeyByAPIKey(header string, keys []string) KeyFunc {
+	known := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		known[key] = struct{}{}
+	}
+
+	return func(r *http.Request) string {
+		key := r.Header.Get(header)
+		if _, ok := known[key]; ok && key != "" {
+			sum := sha256.Sum256([]byte(key))
+
+			return "key:" + hex.EncodeToString(sum[:])
+		}
+
+		return KeyByIP(r)
+	}
+}
+
+// RateLimit ограничивает частоту запросов группы маршрутов group.
+// Добавляет заголовки RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset и RateLimit-Policy,
+// а при превышении лимита отвечает 429 с заголовком Retry-After.
+// Если ограничитель недоступен, отвечает 503: пропуск запросов позволил бы обойти ограничение.
+func RateLimit(
+	limiter ratelimit.Limiter,
+	group string,
+	limit ratelimit.Limit,
+	keyFunc KeyFunc,
+	logger *zap.Logger,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return next
+		}
+
+		policy := strconv.Itoa(limit.Burst) + ";w=" + strconv.Itoa(ceilSeconds(time.Duration(float64(limit.Burst)/limit.Rate*float64(time.Second))))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			decision, err := limiter.Allow(r.Context(), group+":"+keyFunc(r), limit)
+			if err != nil {
+				requestid.Logger(r.Context(), logger).Error("rate limiter failed", zap.String("group", group), zap.Error(err))
+				w.Header().Set("Retry-After", "1")
+				writeLimitProblem(w, r, http.StatusServiceUnavailable, "rate_limiter_unavailable", "rate limiter is unavailable")
+
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+			w.Header().Set("RateLimit-Policy", policy)
+
+			if !decision.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+				writeLimitProblem(w, r, http.StatusTooManyRequests, "rate_limited", "too many requests")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeLimitProblem отправляет ответ ограничителя в формате application/problem+json.
+func writeLimitProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	problem := model.Problem{
+		Type:      "urn:url-shorter:problem:" + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: requestid.FromContext(r.Context()),
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
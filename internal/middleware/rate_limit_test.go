@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// failingLimiter имитирует недоступное хранилище счетчиков.
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("database is down")
+}
+
+func TestRateLimit(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	limit := ratelimit.Limit{Rate: 1, Burst: 2}
+
+	handler := RateLimit(ratelimit.NewMemoryLimiter(), "create", limit, KeyByIP, zap.NewNop())(next)
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return w
+	}
+
+	first := send("10.0.0.1:1000")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=2", first.Header().Get("RateLimit-Policy"))
+
+	assert.Equal(t, http.StatusOK, send("10.0.0.1:1001").Code)
+
+	rejected := send("10.0.0.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, rejected.Code)
+	assert.Equal(t, "1", rejected.Header().Get("Retry-After"))
+	assert.Equal(t, "application/problem+json", rejected.Header().Get("Content-Type"))
+
+	var problem model.Problem
+	require.NoError(t, json.Unmarshal(rejected.Body.Bytes(), &problem))
+	assert.Equal(t, "rate_limited", problem.Code)
+
+	assert.Equal(t, http.StatusOK, send("10.0.0.2:1000").Code, "other client has its own bucket")
+}
+
+func TestRateLimitPassThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter ratelimit.Limiter
+		limit   ratelimit.Limit
+	}{
+		{
+			name:    "disabled limit",
+			limiter: failingLimiter{},
+			limit:   ratelimit.Limit{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+			w := httptest.NewRecorder()
+			RateLimit(tt.limiter, "redirect", tt.limit, KeyByIP, zap.NewNop())(next).
+				ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+			assert.True(t, called)
+			assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+		})
+	}
+}
+
+func TestRateLimitLimiterFailure(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	w := httptest.NewRecorder()
+	RateLimit(failingLimiter{}, "redirect", ratelimit.Limit{Rate: 1, Burst: 1}, KeyByIP, zap.NewNop())(next).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+	assert.False(t, called, "limiter failure must not let requests through")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	var problem model.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "rate_limiter_unavailable", problem.Code)
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyFunc KeyFunc
+		setup   func(r *http.Request) *http.Request
+		want    string
+	}{
+		{
+			name:    "authenticated user",
+			keyFunc: KeyByUser,
+			setup: func(r *http.Request) *http.Request {
+				ctx := context.WithValue(SetUserID(r.Context(), "u1"), authenticatedKey, true)
+				return r.WithContext(ctx)
+			},
+			want: "user:u1",
+		},
+		{
+			name:    "fresh anonymous user falls back to IP",
+			keyFunc: KeyByUser,
+			setup: func(r *http.Request) *http.Request {
+				return r.WithContext(SetUserID(r.Context(), "u2"))
+			},
+			want: "ip:192.0.2.1",
+		},
+		{
+			name:    "known API key is hashed",
+			keyFunc: KeyByAPIKey("X-API-Key", []string{"k1"}),
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("X-API-Key", "k1")
+				return r
+			},
+			want: "key:" + sha256Hex("k1"),
+		},
+		{
+			name:    "unknown API key falls back to IP",
+			keyFunc: KeyByAPIKey("X-API-Key", []string{"k1"}),
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("X-API-Key", strings.Repeat("x", 1024))
+				return r
+			},
+			want: "ip:192.0.2.1",
+		},
+		{
+			name:    "missing API key falls back to IP",
+			keyFunc: KeyByAPIKey("X-API-Key", []string{"k1"}),
+			setup:   func(r *http.Request) *http.Request { return r },
+			want:    "ip:192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.setup(httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.want, tt.keyFunc(req))
+		})
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
+	"github.com/google/uuid"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID - мидлвар, который принимает идентификатор запроса из заголовка X-Request-ID
+// или генерирует новый, сохраняет его в контексте и возвращает в заголовке ответа.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !isValidRequestID(id) {
+			id = uuid.New().String()
+		}
+
+		w.Header().Set(requestid.Header, id)
+
+		ctx := requestid.NewContext(r.Context(), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// isValidRequestID проверяет, что клиентский идентификатор безопасно писать в логи и заголовки.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		incomingID string
+		keepID     bool
+	}{
+		{
+			name:       "client ID is accepted",
+			incomingID: "req-123_abc.def",
+			keepID:     true,
+		},
+		{
+			name:       "missing ID is generated",
+			incomingID: "",
+			keepID:     false,
+		},
+		{
+			name:       "unsafe ID is replaced",
+			incomingID: "bad id\nwith newline",
+			keepID:     false,
+		},
+		{
+			name:       "too long ID is replaced",
+			incomingID: strings.Repeat("a", maxRequestIDLength+1),
+			keepID:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ctxID string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = requestid.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.incomingID != "" {
+				req.Header.Set(requestid.Header, test.incomingID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.NotEmpty(t, ctxID)
+			assert.Equal(t, ctxID, w.Header().Get(requestid.Header))
+
+			if test.keepID {
+				assert.Equal(t, test.incomingID, ctxID)
+			} else {
+				assert.NotEqual(t, test.incomingID, ctxID)
+			}
+		})
+	}
+}
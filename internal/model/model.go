@@ -1,9 +1,111 @@
 // Package model содержит модели данных приложения.
 package model
 
+import "time"
+
 // Request представляет запрос на создание короткой ссылки.
 type Request struct {
 	URL string `json:"url"`
+	LinkAttributes
+}
+
+// LinkAttributes - изменяемые владельцем свойства ссылки.
+type LinkAttributes struct {
+	Title      string   `json:"title,omitempty"`
+	Notes      string   `json:"notes,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	CampaignID string   `json:"campaign_id,omitempty"`
+	// UTMParams - шаблон параметров, добавляемых к URL назначения при переходе;
+	// дополняет шаблон кампании и имеет перед ним приоритет
+	UTMParams map[string]string `json:"utm_params,omitempty"`
+	// UTMOverride - что делать с параметром, уже указанным в URL назначения: keep (по умолчанию) или replace
+	UTMOverride string `json:"utm_override,omitempty"`
+	// Passthrough - переход по /{id}/путь?параметры ведет на URL назначения с тем же путем и параметрами
+	Passthrough bool `json:"passthrough,omitempty"`
+	// RedirectType - код перенаправления (301, 302, 307 или 308); 0 - код по умолчанию из конфигурации
+	RedirectType int `json:"redirect_type,omitempty"`
+	// MaxClicks - после скольких переходов ссылка перестает действовать; 0 - без ограничения
+	MaxClicks int64 `json:"max_clicks,omitempty"`
+	// ActiveFrom - время, с которого ссылка начинает действовать; nil - сразу после создания
+	ActiveFrom *time.Time `json:"active_from,omitempty"`
+	// ExpiresAt - время, после которого ссылка перестает действовать; nil - без срока
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Password - пароль для перехода; задается при создании, сохраняется только его хеш (URLRecord.PasswordHash)
+	Password string `json:"password,omitempty"`
+}
+
+// LinkUpdate - частичное изменение свойств ссылки; nil означает, что поле не меняется.
+// Пустой CampaignID убирает ссылку из кампании, нулевое время в ActiveFrom и ExpiresAt
+// снимает ограничение.
+type LinkUpdate struct {
+	Title        *string            `json:"title"`
+	Notes        *string            `json:"notes"`
+	Tags         *[]string          `json:"tags"`
+	CampaignID   *string            `json:"campaign_id"`
+	UTMParams    *map[string]string `json:"utm_params"`
+	UTMOverride  *string            `json:"utm_override"`
+	Passthrough  *bool              `json:"passthrough"`
+	RedirectType *int               `json:"redirect_type"`
+	MaxClicks    *int64             `json:"max_clicks"`
+	ActiveFrom   *time.Time         `json:"active_from"`
+	ExpiresAt    *time.Time         `json:"expires_at"`
+}
+
+// URLFilter - условия отбора ссылок пользователя.
+// Tags - ссылка должна иметь все перечисленные теги, Query - слова из заголовка или заметок,
+// CampaignID - ссылка должна входить в кампанию.
+type URLFilter struct {
+	Tags       []string
+	Query      string
+	CampaignID string
+}
+
+// Campaign - группа ссылок пользователя, например рекламная кампания.
+type Campaign struct {
+	ID          string `json:"id"`
+	UserID      string `json:"user_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// UTMParams - шаблон параметров, общий для ссылок кампании
+	UTMParams map[string]string `json:"utm_params,omitempty"`
+	CreatedAt time.Time         `json:"created_at,omitzero"`
+	UpdatedAt time.Time         `json:"updated_at,omitzero"`
+}
+
+// CampaignRequest - создание или частичное изменение кампании; nil означает, что поле не меняется.
+type CampaignRequest struct {
+	Name        *string            `json:"name"`
+	Description *string            `json:"description"`
+	UTMParams   *map[string]string `json:"utm_params"`
+}
+
+// CampaignStats - сводная статистика ссылок кампании.
+type CampaignStats struct {
+	Links        int64 `json:"links"`
+	ActiveLinks  int64 `json:"active_links"`
+	DeletedLinks int64 `json:"deleted_links"`
+	Clicks       int64 `json:"clicks"`
+}
+
+// Redirect - данные для перехода по короткой ссылке.
+type Redirect struct {
+	ShortURL    string
+	OriginalURL string
+	// UTMParams - шаблон ссылки, объединенный с шаблоном ее кампании
+	UTMParams   map[string]string
+	UTMOverride string
+	// CampaignName - название кампании ссылки для подстановки {campaign}
+	CampaignName string
+	// Passthrough - к URL назначения добавляются путь и параметры запроса
+	Passthrough bool
+	// RedirectType - код перенаправления ссылки; 0 - код по умолчанию
+	RedirectType int
+	// ExpiresAt - время, после которого ссылка перестает действовать
+	ExpiresAt *time.Time
+	// MaxClicks - ограничение числа переходов; 0 - без ограничения
+	MaxClicks int64
+	// PasswordHash - хеш пароля ссылки; пустой, если пароль не нужен
+	PasswordHash string
 }
 
 // Response представляет ответ с короткой ссылкой.
@@ -15,6 +117,7 @@ type Response struct {
 type BatchRequest struct {
 	CorrelationID string `json:"correlation_id"`
 	OriginalURL   string `json:"original_url"`
+	LinkAttributes
 }
 
 // BatchResponse представляет элемент батч-ответа
@@ -28,12 +131,46 @@ type URLRecord struct {
 	UUID        string `json:"uuid"`
 	ShortURL    string `json:"short_url"`
 	OriginalURL string `json:"original_url"`
-	UserID      string `json:"user_id"`
-	IsDeleted   bool   `json:"is_deleted"`
+	// NormalizedURL - канонический вид OriginalURL, по которому ищутся дубликаты
+	NormalizedURL string `json:"normalized_url,omitempty"`
+	// DedupKey - ключ поиска дубликатов в выбранной области; пустой, если дубликаты не ищутся
+	DedupKey  string `json:"dedup_key,omitempty"`
+	UserID    string `json:"user_id"`
+	IsDeleted bool   `json:"is_deleted"`
+	// CreatedAt - время создания; нулевое у записей, сохраненных в файл до появления поля
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	// UpdatedAt - время последнего изменения записи
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+	// ClickCount - число переходов по ссылке
+	ClickCount int64 `json:"click_count,omitempty"`
+	// PasswordHash - хеш пароля для перехода; пустой, если пароль не нужен
+	PasswordHash string `json:"password_hash,omitempty"`
+	LinkAttributes
 }
 
 // UserURLResponse представляет элемент ответа для получения URL пользователя
 type UserURLResponse struct {
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	UserID      string     `json:"user_id"`
+	IsDeleted   bool       `json:"is_deleted"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	ClickCount  int64      `json:"click_count"`
+	// RemainingClicks - сколько переходов осталось; nil, если число переходов не ограничено
+	RemainingClicks *int64 `json:"remaining_clicks,omitempty"`
+	// Protected - для перехода нужен пароль
+	Protected bool `json:"protected,omitempty"`
+	LinkAttributes
+}
+
+// Problem представляет описание ошибки в формате RFC 7807 (application/problem+json).
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
 }
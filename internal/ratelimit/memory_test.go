@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	limit := Limit{Rate: 1, Burst: 2}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    Decision
+	}{
+		{
+			name: "first request takes a token",
+			want: Decision{Allowed: true, Remaining: 1, Reset: time.Second},
+		},
+		{
+			name: "second request empties the bucket",
+			want: Decision{Allowed: true, Remaining: 0, Reset: 2 * time.Second},
+		},
+		{
+			name: "third request is rejected",
+			want: Decision{Allowed: false, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second},
+		},
+		{
+			name:    "bucket refills over time",
+			advance: time.Second,
+			want:    Decision{Allowed: true, Remaining: 0, Reset: 2 * time.Second},
+		},
+		{
+			name:    "bucket never exceeds burst",
+			advance: time.Hour,
+			want:    Decision{Allowed: true, Remaining: 1, Reset: time.Second},
+		},
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+
+			got, err := limiter.Allow(context.Background(), "client", limit)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMemoryLimiterKeysAreIndependent(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	first, err := limiter.Allow(context.Background(), "a", limit)
+	require.NoError(t, err)
+	second, err := limiter.Allow(context.Background(), "b", limit)
+	require.NoError(t, err)
+	again, err := limiter.Allow(context.Background(), "a", limit)
+	require.NoError(t, err)
+
+	assert.True(t, first.Allowed)
+	assert.True(t, second.Allowed)
+	assert.False(t, again.Allowed)
+}
+
+func TestMemoryLimiterSweepsRefilledBuckets(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	_, err := limiter.Allow(context.Background(), "idle", Limit{Rate: 1, Burst: 5})
+	require.NoError(t, err)
+
+	now = now.Add(2 * sweepInterval)
+	_, err = limiter.Allow(context.Background(), "active", Limit{Rate: 1, Burst: 5})
+	require.NoError(t, err)
+
+	assert.NotContains(t, limiter.buckets, "idle")
+	assert.Contains(t, limiter.buckets, "active")
+}
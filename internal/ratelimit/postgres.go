@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const (
+	// pruneInterval - период удаления неиспользуемых корзин.
+	pruneInterval = 10 * time.Minute
+	// pruneIdle - время простоя, после которого корзина удаляется.
+	// Должно превышать время восполнения самой большой корзины.
+	pruneIdle = time.Hour
+)
+
+// PostgresLimiter хранит корзины в таблице rate_limits, общей для всех реплик.
+// Корзина блокируется на время пересчета (SELECT ... FOR UPDATE), время берется из базы данных:
+// clock_timestamp(), а не now(), так как now() - время начала транзакции, и транзакция,
+// дождавшаяся блокировки, записала бы более раннее время, чем предыдущая.
+type PostgresLimiter struct {
+	pool   *pgxpool.Pool
+	logger *zap.Logger
+	done   chan struct{}
+	wg     *sync.WaitGroup
+}
+
+// NewPostgresLimiter создает ограничитель с хранением корзин в PostgreSQL
+// и запускает фоновое удаление неиспользуемых корзин.
+func NewPostgresLimiter(pool *pgxpool.Pool, logger *zap.Logger) *PostgresLimiter {
+	l := &PostgresLimiter{
+		pool:   pool,
+		logger: logger,
+		done:   make(chan struct{}),
+		wg:     &sync.WaitGroup{},
+	}
+
+	l.wg.Add(1)
+	go l.pruneLoop()
+
+	return l
+}
+
+// Allow расходует токен из корзины ключа.
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Decision, error) {
+	tx, err := l.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return Decision{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO rate_limits (key, tokens, updated_at) VALUES ($1, $2, clock_timestamp()) ON CONFLICT (key) DO NOTHING",
+		key, float64(limit.Burst))
+	if err != nil {
+		return Decision{}, err
+	}
+
+	var (
+		tokens    float64
+		updatedAt time.Time
+		now       time.Time
+	)
+	err = tx.QueryRow(ctx,
+		"SELECT tokens, updated_at, clock_timestamp() FROM rate_limits WHERE key = $1 FOR UPDATE",
+		key).Scan(&tokens, &updatedAt, &now)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	// Время корзины не должно идти назад, иначе один и тот же интервал восполнялся бы дважды.
+	if now.Before(updatedAt) {
+		now = updatedAt
+	}
+
+	decision, tokens := take(tokens, now.Sub(updatedAt), limit)
+
+	_, err = tx.Exec(ctx,
+		"UPDATE rate_limits SET tokens = $2, updated_at = $3 WHERE key = $1",
+		key, tokens, now)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Decision{}, err
+	}
+
+	return decision, nil
+}
+
+// Close останавливает фоновое удаление корзин.
+func (l *PostgresLimiter) Close() error {
+	close(l.done)
+	l.wg.Wait()
+
+	return nil
+}
+
+func (l *PostgresLimiter) pruneLoop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			_, err := l.pool.Exec(ctx,
+				"DELETE FROM rate_limits WHERE updated_at < now() - make_interval(secs => $1)",
+				pruneIdle.Seconds())
+			cancel()
+
+			if err != nil {
+				l.logger.Error("failed to prune rate limit buckets", zap.Error(err))
+			}
+		case <-l.done:
+			return
+		}
+	}
+}
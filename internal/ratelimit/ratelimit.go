@@ -0,0 +1,65 @@
+// Package ratelimit содержит ограничители частоты запросов на основе token bucket.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit описывает параметры token bucket.
+type Limit struct {
+	// Rate - скорость пополнения корзины, токенов в секунду
+	Rate float64
+	// Burst - емкость корзины, максимальное число запросов подряд
+	Burst int
+}
+
+// Enabled проверяет, задано ли ограничение.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Decision - результат проверки запроса ограничителем.
+type Decision struct {
+	// Allowed - запрос разрешен
+	Allowed bool
+	// Remaining - число запросов, которые можно выполнить сразу
+	Remaining int
+	// Reset - время до полного восполнения корзины
+	Reset time.Duration
+	// RetryAfter - время до появления следующего токена, если запрос отклонен
+	RetryAfter time.Duration
+}
+
+// Limiter расходует токен из корзины, связанной с ключом.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Decision, error)
+}
+
+// take пересчитывает корзину с учетом прошедшего времени и пытается взять токен.
+// Возвращает решение и новое число токенов.
+func take(tokens float64, elapsed time.Duration, limit Limit) (Decision, float64) {
+	burst := float64(limit.Burst)
+
+	if elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed.Seconds()*limit.Rate)
+	}
+
+	var decision Decision
+	if tokens >= 1 {
+		tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	decision.Remaining = int(math.Floor(tokens))
+	decision.Reset = seconds((burst - tokens) / limit.Rate)
+
+	return decision, tokens
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,103 @@
+// Package campaignrepository содержит репозиторий кампаний пользователя.
+package campaignrepository
+
+import (
+	"context"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/storage"
+	"github.com/google/uuid"
+)
+
+// CampaignRepository определяет интерфейс для работы с кампаниями.
+// Кампании другого пользователя не раскрываются: для них, как и для отсутствующих,
+// возвращается repository.ErrNotFound.
+type CampaignRepository interface {
+	Create(ctx context.Context, campaign model.Campaign) (model.Campaign, error)
+	Get(ctx context.Context, userID, id string) (model.Campaign, error)
+	List(ctx context.Context, userID string) ([]model.Campaign, error)
+	Update(ctx context.Context, campaign model.Campaign) (model.Campaign, error)
+	Delete(ctx context.Context, userID, id string) error
+	Stats(ctx context.Context, userID, id string) (model.CampaignStats, error)
+}
+
+type campaignRepository struct {
+	storage storage.CampaignStorage
+}
+
+// New создает новый экземпляр CampaignRepository.
+func New(storage storage.CampaignStorage) CampaignRepository {
+	return &campaignRepository{storage: storage}
+}
+
+// Create сохраняет новую кампанию, назначая ей идентификатор и время создания.
+func (r *campaignRepository) Create(ctx context.Context, campaign model.Campaign) (model.Campaign, error) {
+	now := time.Now().UTC()
+
+	campaign.ID = uuid.NewString()
+	campaign.CreatedAt = now
+	campaign.UpdatedAt = now
+
+	if err := r.storage.CreateCampaign(ctx, campaign); err != nil {
+		return model.Campaign{}, err
+	}
+
+	return campaign, nil
+}
+
+// Get возвращает кампанию пользователя.
+func (r *campaignRepository) Get(ctx context.Context, userID, id string) (model.Campaign, error) {
+	campaign, err := r.storage.FindCampaign(ctx, id)
+	if err != nil {
+		return model.Campaign{}, err
+	}
+
+	if campaign.UserID != userID {
+		return model.Campaign{}, repository.ErrNotFound
+	}
+
+	return campaign, nil
+}
+
+// List возвращает кампании пользователя.
+func (r *campaignRepository) List(ctx context.Context, userID string) ([]model.Campaign, error) {
+	return r.storage.FindCampaignsByUserID(ctx, userID)
+}
+
+// Update сохраняет название и описание кампании пользователя.
+func (r *campaignRepository) Update(ctx context.Context, campaign model.Campaign) (model.Campaign, error) {
+	current, err := r.Get(ctx, campaign.UserID, campaign.ID)
+	if err != nil {
+		return model.Campaign{}, err
+	}
+
+	current.Name = campaign.Name
+	current.Description = campaign.Description
+	current.UpdatedAt = time.Now().UTC()
+
+	if err := r.storage.UpdateCampaign(ctx, current); err != nil {
+		return model.Campaign{}, err
+	}
+
+	return current, nil
+}
+
+// Delete удаляет кампанию пользователя. Ссылки кампании остаются.
+func (r *campaignRepository) Delete(ctx context.Context, userID, id string) error {
+	if _, err := r.Get(ctx, userID, id); err != nil {
+		return err
+	}
+
+	return r.storage.DeleteCampaign(ctx, id)
+}
+
+// Stats возвращает статистику ссылок кампании пользователя.
+func (r *campaignRepository) Stats(ctx context.Context, userID, id string) (model.CampaignStats, error) {
+	if _, err := r.Get(ctx, userID, id); err != nil {
+		return model.CampaignStats{}, err
+	}
+
+	return r.storage.CampaignStats(ctx, id)
+}
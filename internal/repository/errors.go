@@ -13,4 +13,6 @@ var (
 	ErrURLAlreadyExists = errors.New("original URL already exists")
 	// ErrDeleted - URL был удален.
 	ErrDeleted = errors.New("url has been deleted")
+	// ErrExhausted - переходы по ссылке исчерпаны.
+	ErrExhausted = errors.New("url click limit reached")
 )
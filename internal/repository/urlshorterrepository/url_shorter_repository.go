@@ -4,20 +4,53 @@ package urlshorterrepository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/model"
 	"github.com/MarkelovSergey/url-shorter/internal/repository"
 	"github.com/MarkelovSergey/url-shorter/internal/storage"
 )
 
+// DedupScope - область, в которой одинаковые URL считаются дубликатами.
+type DedupScope string
+
+// Области поиска дубликатов.
+const (
+	// DedupGlobal - URL сокращается один раз для всех пользователей
+	DedupGlobal DedupScope = "global"
+	// DedupPerUser - каждый пользователь получает собственную короткую ссылку на URL
+	DedupPerUser DedupScope = "per_user"
+	// DedupNone - дубликаты не ищутся, каждый запрос создает новую ссылку
+	DedupNone DedupScope = "none"
+)
+
+// ErrUnknownDedupScope - неизвестная область поиска дубликатов.
+var ErrUnknownDedupScope = errors.New("unknown dedup scope")
+
+// ParseDedupScope разбирает область поиска дубликатов. Пустая строка означает DedupGlobal.
+func ParseDedupScope(value string) (DedupScope, error) {
+	switch scope := DedupScope(value); scope {
+	case "":
+		return DedupGlobal, nil
+	case DedupGlobal, DedupPerUser, DedupNone:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownDedupScope, value)
+	}
+}
+
 // URLShorterRepository определяет интерфейс для работы с сокращенными URL.
 type URLShorterRepository interface {
-	Add(ctx context.Context, shortCode, url, userID string) (string, error)
-	Find(ctx context.Context, shortCode string) (string, error)
-	AddBatch(ctx context.Context, urls map[string]string, userID string) ([]string, error)
-	GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error)
+	Add(ctx context.Context, record model.URLRecord) (string, error)
+	Find(ctx context.Context, shortCode string) (model.URLRecord, error)
+	AddBatch(ctx context.Context, records []model.URLRecord) ([]string, error)
+	GetUserURLs(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error)
+	GetUserURL(ctx context.Context, userID, shortCode string) (model.URLRecord, error)
+	UpdateAttributes(ctx context.Context, userID, shortCode string, attrs model.LinkAttributes) error
+	RecordClick(ctx context.Context, shortCode string) error
 	DeleteBatch(ctx context.Context, shortURLs []string, userID string) error
 }
 
@@ -25,14 +58,31 @@ type urlShorterRepository struct {
 	mu      *sync.Mutex
 	storage storage.Storage
 	counter int
+	scope   DedupScope
+}
+
+// Option настраивает репозиторий.
+type Option func(*urlShorterRepository)
+
+// WithDedupScope задает область поиска дубликатов; по умолчанию DedupGlobal.
+// Ключи уже сохраненных записей при смене области не пересчитываются.
+func WithDedupScope(scope DedupScope) Option {
+	return func(r *urlShorterRepository) {
+		r.scope = scope
+	}
 }
 
 // New создает новый экземпляр URLShorterRepository.
-func New(storage storage.Storage) URLShorterRepository {
+func New(storage storage.Storage, opts ...Option) URLShorterRepository {
 	repo := &urlShorterRepository{
 		mu:      &sync.Mutex{},
 		storage: storage,
 		counter: 0,
+		scope:   DedupGlobal,
+	}
+
+	for _, opt := range opts {
+		opt(repo)
 	}
 
 	records, err := storage.Load(context.Background())
@@ -47,30 +97,32 @@ func New(storage storage.Storage) URLShorterRepository {
 	return repo
 }
 
-// Add добавляет новый URL в хранилище.
-func (r *urlShorterRepository) Add(ctx context.Context, shortCode, url, userID string) (string, error) {
+// Add добавляет новую запись в хранилище. Идентификатор записи и ключ поиска
+// дубликатов назначает репозиторий. Если URL с тем же нормализованным видом уже
+// сохранен в области поиска дубликатов, возвращает его короткий код
+// и repository.ErrURLAlreadyExists.
+func (r *urlShorterRepository) Add(ctx context.Context, record model.URLRecord) (string, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	existingShortCode, err := r.storage.FindByOriginalURL(ctx, url)
-	if err == nil && existingShortCode != "" {
-		return existingShortCode, repository.ErrURLAlreadyExists
+	record = r.prepare(record, time.Now().UTC())
+	key := record.DedupKey
+
+	if key != "" {
+		existingShortCode, err := r.storage.FindByDedupKey(ctx, key)
+		if err == nil && existingShortCode != "" {
+			return existingShortCode, repository.ErrURLAlreadyExists
+		}
 	}
 
 	r.counter++
-
-	record := model.URLRecord{
-		UUID:        strconv.Itoa(r.counter),
-		ShortURL:    shortCode,
-		OriginalURL: url,
-		UserID:      userID,
-	}
+	record.UUID = strconv.Itoa(r.counter)
 
 	if err := r.storage.Append(ctx, record); err != nil {
 		r.counter--
 
-		if errors.Is(err, repository.ErrURLAlreadyExists) {
-			existingShortCode, findErr := r.storage.FindByOriginalURL(ctx, url)
+		if errors.Is(err, repository.ErrURLAlreadyExists) && key != "" {
+			existingShortCode, findErr := r.storage.FindByDedupKey(ctx, key)
 			if findErr == nil && existingShortCode != "" {
 				return existingShortCode, repository.ErrURLAlreadyExists
 			}
@@ -79,58 +131,74 @@ func (r *urlShorterRepository) Add(ctx context.Context, shortCode, url, userID s
 		return "", err
 	}
 
-	return shortCode, nil
+	return record.ShortURL, nil
 }
 
-// Find находит оригинальный URL по короткому коду.
-func (r *urlShorterRepository) Find(ctx context.Context, shortCode string) (string, error) {
-	originalURL, err := r.storage.FindByShortURL(ctx, shortCode)
+// Find находит запись по короткому коду для перехода.
+// Возвращает repository.ErrDeleted, если запись удалена.
+func (r *urlShorterRepository) Find(ctx context.Context, shortCode string) (model.URLRecord, error) {
+	record, err := r.storage.FindRecord(ctx, shortCode)
 	if err != nil {
-		return "", err
+		return model.URLRecord{}, err
+	}
+
+	if record.OriginalURL == "" {
+		return model.URLRecord{}, repository.ErrNotFound
 	}
 
-	if originalURL == "" {
-		return "", repository.ErrNotFound
+	if record.IsDeleted {
+		return model.URLRecord{}, repository.ErrDeleted
 	}
 
-	return originalURL, nil
+	return record, nil
 }
 
-// AddBatch добавляет несколько URL в хранилище пакетно.
-func (r *urlShorterRepository) AddBatch(ctx context.Context, urls map[string]string, userID string) ([]string, error) {
+// AddBatch добавляет несколько записей в хранилище пакетно.
+// Возвращает короткие коды в порядке записей; для уже сохраненных URL
+// и повторов внутри пакета возвращается существующий код.
+func (r *urlShorterRepository) AddBatch(ctx context.Context, records []model.URLRecord) ([]string, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if len(urls) == 0 {
+	if len(records) == 0 {
 		return []string{}, nil
 	}
 
-	shortCodes := make([]string, 0, len(urls))
-	records := make([]model.URLRecord, 0, len(urls))
+	shortCodes := make([]string, 0, len(records))
+	newRecords := make([]model.URLRecord, 0, len(records))
+	batchCodes := make(map[string]string, len(records))
+	now := time.Now().UTC()
 
-	for shortCode, url := range urls {
-		existingShortCode, err := r.storage.FindByOriginalURL(ctx, url)
-		if err == nil && existingShortCode != "" {
-			shortCodes = append(shortCodes, existingShortCode)
-			continue
-		}
+	for _, record := range records {
+		record = r.prepare(record, now)
+		key := record.DedupKey
 
-		r.counter++
+		if key != "" {
+			if shortCode, ok := batchCodes[key]; ok {
+				shortCodes = append(shortCodes, shortCode)
+				continue
+			}
+
+			existingShortCode, err := r.storage.FindByDedupKey(ctx, key)
+			if err == nil && existingShortCode != "" {
+				batchCodes[key] = existingShortCode
+				shortCodes = append(shortCodes, existingShortCode)
+				continue
+			}
 
-		record := model.URLRecord{
-			UUID:        strconv.Itoa(r.counter),
-			ShortURL:    shortCode,
-			OriginalURL: url,
-			UserID:      userID,
+			batchCodes[key] = record.ShortURL
 		}
 
-		records = append(records, record)
-		shortCodes = append(shortCodes, shortCode)
+		r.counter++
+		record.UUID = strconv.Itoa(r.counter)
+
+		newRecords = append(newRecords, record)
+		shortCodes = append(shortCodes, record.ShortURL)
 	}
 
-	if len(records) > 0 {
-		if err := r.storage.AppendBatch(ctx, records); err != nil {
-			r.counter -= len(records)
+	if len(newRecords) > 0 {
+		if err := r.storage.AppendBatch(ctx, newRecords); err != nil {
+			r.counter -= len(newRecords)
 			return nil, err
 		}
 	}
@@ -138,9 +206,61 @@ func (r *urlShorterRepository) AddBatch(ctx context.Context, urls map[string]str
 	return shortCodes, nil
 }
 
-// GetUserURLs возвращает все URL пользователя.
-func (r *urlShorterRepository) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
-	return r.storage.FindByUserID(ctx, userID)
+// prepare заполняет нормализованный URL и время создания, если они не заданы, и ключ поиска дубликатов.
+// В области DedupPerUser ключ включает идентификатор пользователя; пробел не встречается
+// ни в идентификаторе, ни в нормализованном URL, поэтому ключи разных пользователей не совпадают.
+func (r *urlShorterRepository) prepare(record model.URLRecord, now time.Time) model.URLRecord {
+	record.NormalizedURL = storage.NormalizedURL(record)
+
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = now
+	}
+	if record.UpdatedAt.IsZero() {
+		record.UpdatedAt = record.CreatedAt
+	}
+
+	switch r.scope {
+	case DedupNone:
+		record.DedupKey = ""
+	case DedupPerUser:
+		record.DedupKey = record.UserID + " " + record.NormalizedURL
+	default:
+		record.DedupKey = record.NormalizedURL
+	}
+
+	return record
+}
+
+// GetUserURLs возвращает URL пользователя, удовлетворяющие фильтру.
+func (r *urlShorterRepository) GetUserURLs(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error) {
+	return r.storage.FindByUserID(ctx, userID, filter)
+}
+
+// UpdateAttributes заменяет свойства ссылки пользователя.
+func (r *urlShorterRepository) UpdateAttributes(ctx context.Context, userID, shortCode string, attrs model.LinkAttributes) error {
+	return r.storage.UpdateAttributes(ctx, shortCode, userID, attrs)
+}
+
+// GetUserURL возвращает запись пользователя по короткому коду, включая удаленную.
+// Чужие записи не раскрываются: для них, как и для отсутствующих,
+// возвращается repository.ErrNotFound.
+func (r *urlShorterRepository) GetUserURL(ctx context.Context, userID, shortCode string) (model.URLRecord, error) {
+	record, err := r.storage.FindRecord(ctx, shortCode)
+	if err != nil {
+		return model.URLRecord{}, err
+	}
+
+	if record.UserID != userID {
+		return model.URLRecord{}, repository.ErrNotFound
+	}
+
+	return record, nil
+}
+
+// RecordClick учитывает переход по ссылке.
+// Возвращает repository.ErrExhausted, если переходы по ссылке исчерпаны.
+func (r *urlShorterRepository) RecordClick(ctx context.Context, shortCode string) error {
+	return r.storage.IncrementClicks(ctx, shortCode)
 }
 
 // DeleteBatch удаляет несколько URL пакетно.
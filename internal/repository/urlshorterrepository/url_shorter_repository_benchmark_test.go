@@ -6,9 +6,19 @@ import (
 	"testing"
 
 	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/storage"
 	"github.com/MarkelovSergey/url-shorter/internal/storage/memorystorage"
 )
 
+func benchmarkRecord(i int, userID string) model.URLRecord {
+	return model.URLRecord{
+		ShortURL:    fmt.Sprintf("short%d", i),
+		OriginalURL: fmt.Sprintf("https://example.com/%d", i),
+		UserID:      userID,
+	}
+}
+
 func BenchmarkRepositoryAdd(b *testing.B) {
 	storage := memorystorage.New()
 	repo := New(storage)
@@ -17,7 +27,7 @@ func BenchmarkRepositoryAdd(b *testing.B) {
 	b.ResetTimer()
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		_, _ = repo.Add(ctx, fmt.Sprintf("short%d", i), fmt.Sprintf("https://example.com/%d", i), "user1")
+		_, _ = repo.Add(ctx, benchmarkRecord(i, "user1"))
 	}
 }
 
@@ -27,7 +37,7 @@ func BenchmarkRepositoryFind(b *testing.B) {
 	ctx := context.Background()
 
 	for i := 0; i < 1000; i++ {
-		repo.Add(ctx, fmt.Sprintf("short%d", i), fmt.Sprintf("https://example.com/%d", i), "user1")
+		repo.Add(ctx, benchmarkRecord(i, "user1"))
 	}
 
 	b.ResetTimer()
@@ -58,13 +68,13 @@ func BenchmarkRepositoryAddBatch(b *testing.B) {
 				storage := memorystorage.New()
 				repo := New(storage)
 
-				urls := make(map[string]string, bm.batchSize)
+				records := make([]model.URLRecord, bm.batchSize)
 				for j := 0; j < bm.batchSize; j++ {
-					urls[fmt.Sprintf("short%d", j)] = fmt.Sprintf("https://example.com/%d", j)
+					records[j] = benchmarkRecord(j, "user1")
 				}
 				b.StartTimer()
 
-				_, _ = repo.AddBatch(ctx, urls, "user1")
+				_, _ = repo.AddBatch(ctx, records)
 			}
 		})
 	}
@@ -89,13 +99,13 @@ func BenchmarkRepositoryGetUserURLs(b *testing.B) {
 
 			for i := 0; i < bm.urlCount; i++ {
 				userID := fmt.Sprintf("user%d", i%bm.userCount)
-				repo.Add(ctx, fmt.Sprintf("short%d", i), fmt.Sprintf("https://example.com/%d", i), userID)
+				repo.Add(ctx, benchmarkRecord(i, userID))
 			}
 
 			b.ResetTimer()
 			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
-				_, _ = repo.GetUserURLs(ctx, "user1")
+				_, _ = repo.GetUserURLs(ctx, "user1", model.URLFilter{})
 			}
 		})
 	}
@@ -120,7 +130,7 @@ func BenchmarkRepositoryDeleteBatch(b *testing.B) {
 
 			shortURLs := make([]string, bm.deleteSize)
 			for j := 0; j < bm.totalCount; j++ {
-				repo.Add(ctx, fmt.Sprintf("short%d", j), fmt.Sprintf("https://example.com/%d", j), "user1")
+				repo.Add(ctx, benchmarkRecord(j, "user1"))
 				if j < bm.deleteSize {
 					shortURLs[j] = fmt.Sprintf("short%d", j)
 				}
@@ -165,9 +175,9 @@ func (m *mockStorageForBenchmark) AppendBatch(ctx context.Context, records []mod
 	return nil
 }
 
-func (m *mockStorageForBenchmark) FindByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+func (m *mockStorageForBenchmark) FindByDedupKey(ctx context.Context, dedupKey string) (string, error) {
 	for _, r := range m.records {
-		if r.OriginalURL == originalURL {
+		if storage.DedupKey(r) == dedupKey {
 			return r.ShortURL, nil
 		}
 	}
@@ -181,7 +191,14 @@ func (m *mockStorageForBenchmark) FindByShortURL(ctx context.Context, shortURL s
 	return "", nil
 }
 
-func (m *mockStorageForBenchmark) FindByUserID(ctx context.Context, userID string) ([]model.URLRecord, error) {
+func (m *mockStorageForBenchmark) FindRecord(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	if r, ok := m.records[shortURL]; ok {
+		return r, nil
+	}
+	return model.URLRecord{}, repository.ErrNotFound
+}
+
+func (m *mockStorageForBenchmark) FindByUserID(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error) {
 	result := make([]model.URLRecord, 0)
 	for _, r := range m.records {
 		if r.UserID == userID {
@@ -191,6 +208,26 @@ func (m *mockStorageForBenchmark) FindByUserID(ctx context.Context, userID strin
 	return result, nil
 }
 
+func (m *mockStorageForBenchmark) UpdateAttributes(ctx context.Context, shortURL, userID string, attrs model.LinkAttributes) error {
+	r, ok := m.records[shortURL]
+	if !ok || r.UserID != userID {
+		return repository.ErrNotFound
+	}
+	r.LinkAttributes = attrs
+	m.records[shortURL] = r
+	return nil
+}
+
+func (m *mockStorageForBenchmark) IncrementClicks(ctx context.Context, shortURL string) error {
+	r, ok := m.records[shortURL]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	r.ClickCount++
+	m.records[shortURL] = r
+	return nil
+}
+
 func (m *mockStorageForBenchmark) DeleteBatch(ctx context.Context, shortURLs []string, userID string) error {
 	for _, url := range shortURLs {
 		if r, ok := m.records[url]; ok && r.UserID == userID {
@@ -207,7 +244,7 @@ func BenchmarkRepositoryFindWithMapStorage(b *testing.B) {
 	ctx := context.Background()
 
 	for i := 0; i < 10000; i++ {
-		repo.Add(ctx, fmt.Sprintf("short%d", i), fmt.Sprintf("https://example.com/%d", i), "user1")
+		repo.Add(ctx, benchmarkRecord(i, "user1"))
 	}
 
 	b.ResetTimer()
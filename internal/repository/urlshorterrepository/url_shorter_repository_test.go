@@ -0,0 +1,219 @@
+package urlshorterrepository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/storage"
+	"github.com/MarkelovSergey/url-shorter/internal/storage/filestorage"
+	"github.com/MarkelovSergey/url-shorter/internal/storage/memorystorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddDedupScope(t *testing.T) {
+	storages := map[string]func(t *testing.T) storage.Storage{
+		"memory": func(t *testing.T) storage.Storage { return memorystorage.New() },
+		"file": func(t *testing.T) storage.Storage {
+			return filestorage.New(filepath.Join(t.TempDir(), "urls.json"))
+		},
+	}
+
+	tests := []struct {
+		name  string
+		scope DedupScope
+		// ожидаемые ошибки для: тот же пользователь, другой пользователь
+		sameUserErr  error
+		otherUserErr error
+	}{
+		{name: "global", scope: DedupGlobal, sameUserErr: repository.ErrURLAlreadyExists, otherUserErr: repository.ErrURLAlreadyExists},
+		{name: "per user", scope: DedupPerUser, sameUserErr: repository.ErrURLAlreadyExists},
+		{name: "none", scope: DedupNone},
+	}
+
+	for storageName, newStorage := range storages {
+		for _, tt := range tests {
+			t.Run(storageName+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := New(newStorage(t), WithDedupScope(tt.scope))
+
+				record := func(shortCode, userID string) model.URLRecord {
+					return model.URLRecord{
+						ShortURL:      shortCode,
+						OriginalURL:   "https://Example.com",
+						NormalizedURL: "https://example.com/",
+						UserID:        userID,
+					}
+				}
+
+				first, err := repo.Add(ctx, record("aaa", "user-a"))
+				require.NoError(t, err)
+				assert.Equal(t, "aaa", first)
+
+				code, err := repo.Add(ctx, record("bbb", "user-a"))
+				assertAddResult(t, tt.sameUserErr, "aaa", "bbb", code, err)
+
+				code, err = repo.Add(ctx, record("ccc", "user-b"))
+				assertAddResult(t, tt.otherUserErr, "aaa", "ccc", code, err)
+			})
+		}
+	}
+}
+
+func assertAddResult(t *testing.T, wantErr error, existing, created, code string, err error) {
+	t.Helper()
+
+	if wantErr != nil {
+		require.ErrorIs(t, err, wantErr)
+		assert.Equal(t, existing, code)
+
+		return
+	}
+
+	require.NoError(t, err)
+	assert.Equal(t, created, code)
+}
+
+func TestAddBatchDedupScope(t *testing.T) {
+	ctx := context.Background()
+	repo := New(memorystorage.New(), WithDedupScope(DedupPerUser))
+
+	_, err := repo.Add(ctx, model.URLRecord{ShortURL: "aaa", OriginalURL: "https://example.com/", UserID: "user-a"})
+	require.NoError(t, err)
+
+	codes, err := repo.AddBatch(ctx, []model.URLRecord{
+		{ShortURL: "bbb", OriginalURL: "https://example.com/", UserID: "user-b"},
+		{ShortURL: "ccc", OriginalURL: "https://example.com/", UserID: "user-b"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bbb", "bbb"}, codes)
+
+	userURLs, err := repo.GetUserURLs(ctx, "user-b", model.URLFilter{})
+	require.NoError(t, err)
+	require.Len(t, userURLs, 1)
+	assert.Equal(t, "bbb", userURLs[0].ShortURL)
+}
+
+func TestGetUserURL(t *testing.T) {
+	storages := map[string]func(t *testing.T) storage.Storage{
+		"memory": func(t *testing.T) storage.Storage { return memorystorage.New() },
+		"file": func(t *testing.T) storage.Storage {
+			return filestorage.New(filepath.Join(t.TempDir(), "urls.json"))
+		},
+	}
+
+	for storageName, newStorage := range storages {
+		t.Run(storageName, func(t *testing.T) {
+			ctx := context.Background()
+			repo := New(newStorage(t))
+
+			_, err := repo.Add(ctx, model.URLRecord{ShortURL: "aaa", OriginalURL: "https://example.com/", UserID: "user-a"})
+			require.NoError(t, err)
+
+			record, err := repo.GetUserURL(ctx, "user-a", "aaa")
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com/", record.OriginalURL)
+			assert.False(t, record.CreatedAt.IsZero())
+			assert.Equal(t, record.CreatedAt, record.UpdatedAt)
+
+			require.NoError(t, repo.DeleteBatch(ctx, []string{"aaa"}, "user-a"))
+
+			record, err = repo.GetUserURL(ctx, "user-a", "aaa")
+			require.NoError(t, err)
+			assert.True(t, record.IsDeleted)
+			assert.False(t, record.UpdatedAt.Before(record.CreatedAt))
+
+			_, err = repo.GetUserURL(ctx, "user-b", "aaa")
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+
+			_, err = repo.GetUserURL(ctx, "user-a", "missing")
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+		})
+	}
+}
+
+func TestGetUserURLsFilter(t *testing.T) {
+	storages := map[string]func(t *testing.T) storage.Storage{
+		"memory": func(t *testing.T) storage.Storage { return memorystorage.New() },
+		"file": func(t *testing.T) storage.Storage {
+			return filestorage.New(filepath.Join(t.TempDir(), "urls.json"))
+		},
+	}
+
+	tests := []struct {
+		name   string
+		filter model.URLFilter
+		want   []string
+	}{
+		{name: "no filter", want: []string{"aaa", "bbb", "ccc"}},
+		{name: "single tag", filter: model.URLFilter{Tags: []string{"work"}}, want: []string{"aaa", "bbb"}},
+		{name: "all tags required", filter: model.URLFilter{Tags: []string{"work", "q3"}}, want: []string{"bbb"}},
+		{name: "words in title and notes", filter: model.URLFilter{Query: "Quarterly, REPORT"}, want: []string{"bbb"}},
+		{name: "words and tags", filter: model.URLFilter{Query: "report", Tags: []string{"home"}}, want: []string{"ccc"}},
+		{name: "unknown word", filter: model.URLFilter{Query: "missing"}, want: []string{}},
+	}
+
+	for storageName, newStorage := range storages {
+		ctx := context.Background()
+		repo := New(newStorage(t), WithDedupScope(DedupNone))
+
+		records := []model.URLRecord{
+			{ShortURL: "aaa", OriginalURL: "https://example.com/a", UserID: "user-a",
+				LinkAttributes: model.LinkAttributes{Title: "Team wiki", Tags: []string{"work"}}},
+			{ShortURL: "bbb", OriginalURL: "https://example.com/b", UserID: "user-a",
+				LinkAttributes: model.LinkAttributes{Title: "Quarterly report", Notes: "draft", Tags: []string{"q3", "work"}}},
+			{ShortURL: "ccc", OriginalURL: "https://example.com/c", UserID: "user-a",
+				LinkAttributes: model.LinkAttributes{Notes: "old report", Tags: []string{"home"}}},
+			{ShortURL: "ddd", OriginalURL: "https://example.com/d", UserID: "user-b",
+				LinkAttributes: model.LinkAttributes{Title: "Quarterly report", Tags: []string{"work"}}},
+		}
+		for _, record := range records {
+			_, err := repo.Add(ctx, record)
+			require.NoError(t, err)
+		}
+
+		for _, tt := range tests {
+			t.Run(storageName+"/"+tt.name, func(t *testing.T) {
+				found, err := repo.GetUserURLs(ctx, "user-a", tt.filter)
+				require.NoError(t, err)
+
+				codes := make([]string, 0, len(found))
+				for _, record := range found {
+					codes = append(codes, record.ShortURL)
+				}
+				assert.Equal(t, tt.want, codes)
+			})
+		}
+
+		t.Run(storageName+"/update reindexes words", func(t *testing.T) {
+			err := repo.UpdateAttributes(ctx, "user-a", "aaa", model.LinkAttributes{Title: "Annual report"})
+			require.NoError(t, err)
+
+			found, err := repo.GetUserURLs(ctx, "user-a", model.URLFilter{Query: "wiki"})
+			require.NoError(t, err)
+			assert.Empty(t, found)
+
+			found, err = repo.GetUserURLs(ctx, "user-a", model.URLFilter{Query: "annual"})
+			require.NoError(t, err)
+			require.Len(t, found, 1)
+			assert.Equal(t, "aaa", found[0].ShortURL)
+
+			err = repo.UpdateAttributes(ctx, "user-b", "aaa", model.LinkAttributes{})
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+		})
+	}
+}
+
+func TestParseDedupScope(t *testing.T) {
+	for value, want := range map[string]DedupScope{"": DedupGlobal, "global": DedupGlobal, "per_user": DedupPerUser, "none": DedupNone} {
+		scope, err := ParseDedupScope(value)
+		require.NoError(t, err)
+		assert.Equal(t, want, scope)
+	}
+
+	_, err := ParseDedupScope("per-user")
+	assert.ErrorIs(t, err, ErrUnknownDedupScope)
+}
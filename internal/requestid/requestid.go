@@ -0,0 +1,41 @@
+// Package requestid содержит функции для передачи идентификатора запроса через контекст.
+// Идентификатор связывает записи логов, события аудита и ответы одного запроса.
+package requestid
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// Header - HTTP-заголовок, в котором передается идентификатор запроса.
+const Header = "X-Request-ID"
+
+// FieldName - имя поля с идентификатором запроса в логах.
+const FieldName = "request_id"
+
+type contextKey struct{}
+
+// NewContext возвращает контекст с идентификатором запроса.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext извлекает идентификатор запроса из контекста.
+// Возвращает пустую строку, если идентификатор не задан.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+
+	return id
+}
+
+// Logger возвращает логгер, дополненный идентификатором запроса из контекста.
+// Если идентификатор не задан, возвращает исходный логгер.
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	id := FromContext(ctx)
+	if id == "" {
+		return logger
+	}
+
+	return logger.With(zap.String(FieldName, id))
+}
@@ -0,0 +1,176 @@
+// Package campaignservice содержит бизнес-логику кампаний - групп ссылок пользователя.
+package campaignservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/repository/campaignrepository"
+	"github.com/MarkelovSergey/url-shorter/internal/repository/urlshorterrepository"
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/utmtemplate"
+)
+
+// Ограничения свойств кампании.
+const (
+	maxNameLength        = 100
+	maxDescriptionLength = 1000
+)
+
+// Коды нарушений в свойствах кампании.
+const (
+	CodeNameRequired       = "campaign_name_required"
+	CodeNameTooLong        = "campaign_name_too_long"
+	CodeDescriptionTooLong = "campaign_description_too_long"
+)
+
+// CampaignService определяет интерфейс сервиса кампаний.
+// Для отсутствующих и чужих кампаний методы возвращают service.ErrCampaignNotFound.
+type CampaignService interface {
+	Create(ctx context.Context, userID string, req model.CampaignRequest) (model.Campaign, error)
+	Get(ctx context.Context, userID, id string) (model.Campaign, error)
+	List(ctx context.Context, userID string) ([]model.Campaign, error)
+	Update(ctx context.Context, userID, id string, req model.CampaignRequest) (model.Campaign, error)
+	Delete(ctx context.Context, userID, id string, deleteLinks bool) error
+	Stats(ctx context.Context, userID, id string) (model.CampaignStats, error)
+}
+
+type campaignService struct {
+	campaignRepo   campaignrepository.CampaignRepository
+	urlShorterRepo urlshorterrepository.URLShorterRepository
+}
+
+// New создает новый экземпляр CampaignService.
+func New(
+	campaignRepo campaignrepository.CampaignRepository,
+	urlShorterRepo urlshorterrepository.URLShorterRepository,
+) CampaignService {
+	return &campaignService{
+		campaignRepo:   campaignRepo,
+		urlShorterRepo: urlShorterRepo,
+	}
+}
+
+// Create создает кампанию пользователя. Название обязательно.
+func (s *campaignService) Create(ctx context.Context, userID string, req model.CampaignRequest) (model.Campaign, error) {
+	campaign, err := applyRequest(model.Campaign{UserID: userID}, req)
+	if err != nil {
+		return model.Campaign{}, err
+	}
+
+	return s.campaignRepo.Create(ctx, campaign)
+}
+
+// Get возвращает кампанию пользователя.
+func (s *campaignService) Get(ctx context.Context, userID, id string) (model.Campaign, error) {
+	campaign, err := s.campaignRepo.Get(ctx, userID, id)
+
+	return campaign, notFound(err, id)
+}
+
+// List возвращает кампании пользователя в порядке создания.
+func (s *campaignService) List(ctx context.Context, userID string) ([]model.Campaign, error) {
+	return s.campaignRepo.List(ctx, userID)
+}
+
+// Update изменяет название, описание и шаблон параметров кампании. Не переданные поля не меняются.
+func (s *campaignService) Update(ctx context.Context, userID, id string, req model.CampaignRequest) (model.Campaign, error) {
+	campaign, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return model.Campaign{}, err
+	}
+
+	campaign, err = applyRequest(campaign, req)
+	if err != nil {
+		return model.Campaign{}, err
+	}
+
+	campaign, err = s.campaignRepo.Update(ctx, campaign)
+
+	return campaign, notFound(err, id)
+}
+
+// Delete удаляет кампанию. Если deleteLinks задан, ссылки кампании помечаются
+// удаленными так же, как при DELETE /api/user/urls; иначе они только исключаются из кампании.
+func (s *campaignService) Delete(ctx context.Context, userID, id string, deleteLinks bool) error {
+	if _, err := s.Get(ctx, userID, id); err != nil {
+		return err
+	}
+
+	if deleteLinks {
+		records, err := s.urlShorterRepo.GetUserURLs(ctx, userID, model.URLFilter{CampaignID: id})
+		if err != nil {
+			return fmt.Errorf("failed to list links of campaign %s: %w", id, err)
+		}
+
+		shortCodes := make([]string, 0, len(records))
+		for _, record := range records {
+			if !record.IsDeleted {
+				shortCodes = append(shortCodes, record.ShortURL)
+			}
+		}
+
+		if err := s.urlShorterRepo.DeleteBatch(ctx, shortCodes, userID); err != nil {
+			return fmt.Errorf("failed to delete links of campaign %s: %w", id, err)
+		}
+	}
+
+	return notFound(s.campaignRepo.Delete(ctx, userID, id), id)
+}
+
+// Stats возвращает число ссылок кампании и сумму переходов по ним.
+func (s *campaignService) Stats(ctx context.Context, userID, id string) (model.CampaignStats, error) {
+	stats, err := s.campaignRepo.Stats(ctx, userID, id)
+
+	return stats, notFound(err, id)
+}
+
+// applyRequest переносит изменения в кампанию и проверяет результат.
+func applyRequest(campaign model.Campaign, req model.CampaignRequest) (model.Campaign, error) {
+	if req.Name != nil {
+		campaign.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		campaign.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.UTMParams != nil {
+		campaign.UTMParams = *req.UTMParams
+	}
+
+	if campaign.Name == "" {
+		return campaign, service.NewValidationError("name", CodeNameRequired, "campaign name is required")
+	}
+
+	if utf8.RuneCountInString(campaign.Name) > maxNameLength {
+		return campaign, service.NewValidationError("name", CodeNameTooLong,
+			fmt.Sprintf("campaign name is longer than %d characters", maxNameLength))
+	}
+
+	if utf8.RuneCountInString(campaign.Description) > maxDescriptionLength {
+		return campaign, service.NewValidationError("description", CodeDescriptionTooLong,
+			fmt.Sprintf("campaign description is longer than %d characters", maxDescriptionLength))
+	}
+
+	if err := utmtemplate.Validate("utm_params", campaign.UTMParams); err != nil {
+		return campaign, err
+	}
+	if len(campaign.UTMParams) == 0 {
+		campaign.UTMParams = nil
+	}
+
+	return campaign, nil
+}
+
+// notFound заменяет repository.ErrNotFound на service.ErrCampaignNotFound.
+func notFound(err error, id string) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: %s", service.ErrCampaignNotFound, id)
+	}
+
+	return err
+}
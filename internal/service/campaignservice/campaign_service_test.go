@@ -0,0 +1,148 @@
+package campaignservice
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository/campaignrepository"
+	"github.com/MarkelovSergey/url-shorter/internal/repository/urlshorterrepository"
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlshorterservice"
+	"github.com/MarkelovSergey/url-shorter/internal/storage/memorystorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestServices() (CampaignService, urlshorterservice.URLShorterService) {
+	storage := memorystorage.New()
+	urlRepo := urlshorterrepository.New(storage)
+	campaignRepo := campaignrepository.New(storage)
+
+	return New(campaignRepo, urlRepo),
+		urlshorterservice.New(urlRepo, nil, zap.NewNop(), urlshorterservice.WithCampaigns(campaignRepo))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCreateValidates(t *testing.T) {
+	campaigns, _ := newTestServices()
+
+	tests := []struct {
+		name string
+		req  model.CampaignRequest
+		code string
+	}{
+		{name: "missing name", req: model.CampaignRequest{}, code: CodeNameRequired},
+		{name: "blank name", req: model.CampaignRequest{Name: ptr("  ")}, code: CodeNameRequired},
+		{name: "long name", req: model.CampaignRequest{Name: ptr(strings.Repeat("n", 101))}, code: CodeNameTooLong},
+		{
+			name: "long description",
+			req:  model.CampaignRequest{Name: ptr("Spring"), Description: ptr(strings.Repeat("d", 1001))},
+			code: CodeDescriptionTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := campaigns.Create(context.Background(), "user1", tt.req)
+
+			var validationErr *service.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.code, validationErr.Code)
+		})
+	}
+}
+
+func TestCampaignOwnership(t *testing.T) {
+	ctx := context.Background()
+	campaigns, urls := newTestServices()
+
+	campaign, err := campaigns.Create(ctx, "user1", model.CampaignRequest{Name: ptr(" Spring ")})
+	require.NoError(t, err)
+	assert.NotEmpty(t, campaign.ID)
+	assert.Equal(t, "Spring", campaign.Name)
+	assert.False(t, campaign.CreatedAt.IsZero())
+
+	_, err = campaigns.Get(ctx, "user2", campaign.ID)
+	assert.ErrorIs(t, err, service.ErrCampaignNotFound)
+
+	_, err = campaigns.Update(ctx, "user2", campaign.ID, model.CampaignRequest{Name: ptr("Stolen")})
+	assert.ErrorIs(t, err, service.ErrCampaignNotFound)
+
+	assert.ErrorIs(t, campaigns.Delete(ctx, "user2", campaign.ID, true), service.ErrCampaignNotFound)
+
+	t.Run("links of other users can not join", func(t *testing.T) {
+		_, err := urls.Generate(ctx, "https://example.com/", "user2", model.LinkAttributes{CampaignID: campaign.ID})
+
+		var validationErr *service.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, urlshorterservice.CodeUnknownCampaign, validationErr.Code)
+	})
+
+	t.Run("batch reports the failing item", func(t *testing.T) {
+		_, err := urls.GenerateBatch(ctx, []model.Request{
+			{URL: "https://example.com/1", LinkAttributes: model.LinkAttributes{CampaignID: campaign.ID}},
+			{URL: "https://example.com/2", LinkAttributes: model.LinkAttributes{CampaignID: "missing"}},
+		}, "user1")
+
+		var validationErr *service.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "urls[1]", validationErr.Field)
+		assert.Equal(t, urlshorterservice.CodeUnknownCampaign, validationErr.Code)
+	})
+}
+
+func TestCampaignStatsAndDelete(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		deleteLinks bool
+	}{
+		{name: "keep links", deleteLinks: false},
+		{name: "delete links", deleteLinks: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			campaigns, urls := newTestServices()
+
+			campaign, err := campaigns.Create(ctx, "user1", model.CampaignRequest{Name: ptr("Spring")})
+			require.NoError(t, err)
+
+			codes, err := urls.GenerateBatch(ctx, []model.Request{
+				{URL: "https://example.com/1", LinkAttributes: model.LinkAttributes{CampaignID: campaign.ID}},
+				{URL: "https://example.com/2"},
+			}, "user1")
+			require.NoError(t, err)
+
+			_, err = urls.UpdateUserURL(ctx, "user1", codes[1], model.LinkUpdate{CampaignID: &campaign.ID})
+			require.NoError(t, err)
+
+			for range 3 {
+				require.NoError(t, urls.RecordClick(ctx, codes[0]))
+			}
+
+			stats, err := campaigns.Stats(ctx, "user1", campaign.ID)
+			require.NoError(t, err)
+			assert.Equal(t, model.CampaignStats{Links: 2, ActiveLinks: 2, Clicks: 3}, stats)
+
+			require.NoError(t, campaigns.Delete(ctx, "user1", campaign.ID, tt.deleteLinks))
+
+			_, err = campaigns.Get(ctx, "user1", campaign.ID)
+			assert.ErrorIs(t, err, service.ErrCampaignNotFound)
+
+			for _, code := range codes {
+				record, err := urls.GetUserURL(ctx, "user1", code)
+				require.NoError(t, err)
+				assert.Empty(t, record.CampaignID)
+				assert.Equal(t, tt.deleteLinks, record.IsDeleted)
+			}
+		})
+	}
+}
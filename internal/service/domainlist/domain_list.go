@@ -0,0 +1,127 @@
+// Package domainlist содержит списки запрещенных и разрешенных доменов.
+// Списки загружаются из файлов или по HTTP и периодически перечитываются без перезапуска.
+//
+// Формат списка - одна запись в строке, строки с # игнорируются:
+//
+//	example.com        - точное совпадение имени хоста
+//	*.example.com      - любой поддомен example.com
+//	/^phish[0-9]+\./   - регулярное выражение для имени хоста
+//
+// Имена хостов в записях и проверяемых URL сравниваются в ASCII-записи (Punycode),
+// поэтому запись в любой форме совпадает с хостом в любой форме.
+// Регулярные выражения применяются к ASCII-записи хоста.
+package domainlist
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlnorm"
+)
+
+// ErrInvalidEntry - запись списка не удалось разобрать.
+var ErrInvalidEntry = errors.New("invalid domain list entry")
+
+// List - разобранный список доменов.
+type List struct {
+	exact    map[string]struct{}
+	suffixes []string
+	patterns []*regexp.Regexp
+}
+
+// Parse разбирает список доменов.
+func Parse(r io.Reader) (*List, error) {
+	list := &List{exact: make(map[string]struct{})}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		if err := list.add(entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (l *List) add(entry string) error {
+	switch {
+	case len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/"):
+		pattern, err := regexp.Compile(entry[1 : len(entry)-1])
+		if err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrInvalidEntry, entry, err)
+		}
+		l.patterns = append(l.patterns, pattern)
+	case strings.HasPrefix(entry, "*."):
+		suffix, err := normalizeHost(entry[1:])
+		if err != nil || len(suffix) < 2 || strings.Contains(suffix[1:], "*") {
+			return fmt.Errorf("%w: %q", ErrInvalidEntry, entry)
+		}
+		l.suffixes = append(l.suffixes, suffix)
+	default:
+		host, err := normalizeHost(entry)
+		if err != nil || strings.ContainsAny(host, "*/ ") {
+			return fmt.Errorf("%w: %q", ErrInvalidEntry, entry)
+		}
+		l.exact[host] = struct{}{}
+	}
+
+	return nil
+}
+
+// merge добавляет в список записи другого списка.
+func (l *List) merge(other *List) {
+	for host := range other.exact {
+		l.exact[host] = struct{}{}
+	}
+	l.suffixes = append(l.suffixes, other.suffixes...)
+	l.patterns = append(l.patterns, other.patterns...)
+}
+
+// Match проверяет, что имя хоста совпадает с одной из записей списка.
+func (l *List) Match(host string) bool {
+	host, err := normalizeHost(host)
+	if err != nil {
+		return false
+	}
+
+	if _, ok := l.exact[host]; ok {
+		return true
+	}
+
+	for _, suffix := range l.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+
+	for _, pattern := range l.patterns {
+		if pattern.MatchString(host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Len возвращает число записей в списке.
+func (l *List) Len() int {
+	return len(l.exact) + len(l.suffixes) + len(l.patterns)
+}
+
+// normalizeHost приводит имя хоста к нижнему регистру и ASCII-записи и убирает завершающую точку.
+func normalizeHost(host string) (string, error) {
+	return urlnorm.HostToASCII(strings.TrimSuffix(host, "."))
+}
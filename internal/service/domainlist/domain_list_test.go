@@ -0,0 +1,89 @@
+package domainlist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMatch(t *testing.T) {
+	list, err := Parse(strings.NewReader(`
+# phishing
+evil.example
+Bad.Example.
+*.phish.example
+/^login-[a-z]+\.example$/
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 4, list.Len())
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "evil.example", want: true},
+		{host: "EVIL.example.", want: true},
+		{host: "bad.example", want: true},
+		{host: "sub.evil.example", want: false},
+		{host: "a.phish.example", want: true},
+		{host: "a.b.phish.example", want: true},
+		{host: "phish.example", want: false},
+		{host: "notphish.example", want: false},
+		{host: "login-bank.example", want: true},
+		{host: "login-bank.example.org", want: false},
+		{host: "good.example", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.want, list.Match(tt.host))
+		})
+	}
+}
+
+func TestListMatchIDNA(t *testing.T) {
+	list, err := Parse(strings.NewReader(`
+xn--mnchen-3ya.de
+*.bücher.example
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "münchen.de", want: true},
+		{host: "MÜNCHEN.de", want: true},
+		{host: "xn--mnchen-3ya.de", want: true},
+		{host: "shop.bücher.example", want: true},
+		{host: "shop.xn--bcher-kva.example", want: true},
+		{host: "munchen.de", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.want, list.Match(tt.host))
+		})
+	}
+}
+
+func TestParseInvalidEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "bad regex", input: "ok.example\n/[a-/\n"},
+		{name: "bare wildcard", input: "*.\n"},
+		{name: "wildcard in the middle", input: "*.a.*.example\n"},
+		{name: "wildcard without dot", input: "*example.com\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			assert.ErrorIs(t, err, ErrInvalidEntry)
+		})
+	}
+}
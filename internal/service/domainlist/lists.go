@@ -0,0 +1,253 @@
+package domainlist
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlpolicy"
+	"go.uber.org/zap"
+)
+
+// Коды нарушений.
+const (
+	CodeDomainBlocked    = "url_domain_blocked"
+	CodeDomainNotAllowed = "url_domain_not_allowed"
+)
+
+const (
+	defaultLoadTimeout = 10 * time.Second
+	maxRemoteListSize  = 32 << 20
+)
+
+// Options содержит источники списков и период их перечитывания.
+// Источник - путь к файлу или URL с схемой http или https.
+type Options struct {
+	// Blocklist - источники запрещенных доменов
+	Blocklist []string
+	// Allowlist - источники разрешенных доменов; если заданы, разрешены только домены из них
+	Allowlist []string
+	// ReloadInterval - период проверки источников на изменения; 0 отключает перечитывание
+	ReloadInterval time.Duration
+	// HTTPClient - клиент для загрузки списков по HTTP
+	HTTPClient *http.Client
+}
+
+// Lists проверяет домены URL по спискам запрещенных и разрешенных доменов.
+// Реализует urlpolicy.Policy. Списки заменяются атомарно, поэтому проверки
+// не блокируются перечитыванием.
+type Lists struct {
+	opts        Options
+	block       atomic.Pointer[List]
+	allow       atomic.Pointer[List]
+	fingerprint [sha256.Size]byte
+	reloadMu    *sync.Mutex
+	logger      *zap.Logger
+	stop        chan struct{}
+	done        chan struct{}
+	closeOnce   *sync.Once
+}
+
+var _ urlpolicy.Policy = (*Lists)(nil)
+
+// New загружает списки доменов и, если задан ReloadInterval, запускает их перечитывание.
+// Возвращает ошибку, если хотя бы один источник не удалось загрузить или разобрать.
+func New(opts Options, logger *zap.Logger) (*Lists, error) {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: defaultLoadTimeout}
+	}
+
+	l := &Lists{
+		opts:      opts,
+		reloadMu:  &sync.Mutex{},
+		logger:    logger,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		closeOnce: &sync.Once{},
+	}
+
+	if _, err := l.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	if opts.ReloadInterval > 0 {
+		go l.reloadLoop()
+	} else {
+		close(l.done)
+	}
+
+	return l, nil
+}
+
+// Check проверяет домен URL по спискам.
+func (l *Lists) Check(_ context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return service.NewValidationError("url", urlpolicy.CodeInvalidURL, "url not correct")
+	}
+
+	host, err := normalizeHost(u.Hostname())
+	if err != nil {
+		return service.NewValidationError("url", urlpolicy.CodeInvalidURL, "url not correct")
+	}
+
+	if block := l.block.Load(); block != nil && block.Match(host) {
+		return service.NewValidationError("url", CodeDomainBlocked, fmt.Sprintf("domain %q is blocked", host))
+	}
+
+	if allow := l.allow.Load(); allow != nil && !allow.Match(host) {
+		return service.NewValidationError("url", CodeDomainNotAllowed, fmt.Sprintf("domain %q is not allowed", host))
+	}
+
+	return nil
+}
+
+// Reload перечитывает источники и заменяет списки, если их содержимое изменилось.
+// При ошибке действующие списки сохраняются. Возвращает true, если списки заменены.
+func (l *Lists) Reload(ctx context.Context) (bool, error) {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	blockData, err := l.fetchAll(ctx, l.opts.Blocklist)
+	if err != nil {
+		return false, err
+	}
+	allowData, err := l.fetchAll(ctx, l.opts.Allowlist)
+	if err != nil {
+		return false, err
+	}
+
+	hash := sha256.New()
+	for _, data := range append(blockData, allowData...) {
+		hash.Write(data)
+		hash.Write([]byte{0})
+	}
+
+	var fingerprint [sha256.Size]byte
+	copy(fingerprint[:], hash.Sum(nil))
+	if l.block.Load() != nil && fingerprint == l.fingerprint {
+		return false, nil
+	}
+
+	block, err := parseAll(l.opts.Blocklist, blockData)
+	if err != nil {
+		return false, err
+	}
+	allow, err := parseAll(l.opts.Allowlist, allowData)
+	if err != nil {
+		return false, err
+	}
+
+	l.block.Store(block)
+	if len(l.opts.Allowlist) > 0 {
+		l.allow.Store(allow)
+	}
+	l.fingerprint = fingerprint
+
+	l.logger.Info("domain lists loaded",
+		zap.Int("blocked", block.Len()),
+		zap.Int("allowed", allow.Len()),
+	)
+
+	return true, nil
+}
+
+// Close останавливает перечитывание списков.
+func (l *Lists) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.stop)
+	})
+	<-l.done
+
+	return nil
+}
+
+func (l *Lists) reloadLoop() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.opts.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), defaultLoadTimeout)
+			if _, err := l.Reload(ctx); err != nil {
+				l.logger.Warn("failed to reload domain lists, keeping previous version", zap.Error(err))
+			}
+			cancel()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+func (l *Lists) fetchAll(ctx context.Context, sources []string) ([][]byte, error) {
+	result := make([][]byte, 0, len(sources))
+	for _, source := range sources {
+		data, err := l.fetch(ctx, source)
+		if err != nil {
+			return nil, fmt.Errorf("domain list %s: %w", source, err)
+		}
+
+		result = append(result, data)
+	}
+
+	return result, nil
+}
+
+// fetch читает источник из файла или загружает его по HTTP.
+func (l *Lists) fetch(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := l.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteListSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRemoteListSize {
+		return nil, fmt.Errorf("list is larger than %d bytes", maxRemoteListSize)
+	}
+
+	return data, nil
+}
+
+func parseAll(sources []string, contents [][]byte) (*List, error) {
+	result := &List{exact: make(map[string]struct{})}
+	for i, data := range contents {
+		list, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("domain list %s: %w", sources[i], err)
+		}
+
+		result.merge(list)
+	}
+
+	return result, nil
+}
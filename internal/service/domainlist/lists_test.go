@@ -0,0 +1,145 @@
+package domainlist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func writeList(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func checkCode(t *testing.T, lists *Lists, rawURL string) string {
+	t.Helper()
+
+	err := lists.Check(context.Background(), rawURL)
+	if err == nil {
+		return ""
+	}
+
+	var validationErr *service.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	return validationErr.Code
+}
+
+func TestListsCheck(t *testing.T) {
+	dir := t.TempDir()
+	blockPath := filepath.Join(dir, "block.txt")
+	allowPath := filepath.Join(dir, "allow.txt")
+	writeList(t, blockPath, "evil.corp.example\n")
+	writeList(t, allowPath, "*.corp.example\ncorp.example\n")
+
+	lists, err := New(Options{Blocklist: []string{blockPath}, Allowlist: []string{allowPath}}, zap.NewNop())
+	require.NoError(t, err)
+	defer lists.Close()
+
+	assert.Empty(t, checkCode(t, lists, "https://corp.example/"))
+	assert.Empty(t, checkCode(t, lists, "https://wiki.corp.example/page"))
+	assert.Equal(t, CodeDomainBlocked, checkCode(t, lists, "https://evil.corp.example/"))
+	assert.Equal(t, CodeDomainNotAllowed, checkCode(t, lists, "https://example.com/"))
+}
+
+func TestListsBlocklistOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "block.txt")
+	writeList(t, path, "evil.example\n")
+
+	lists, err := New(Options{Blocklist: []string{path}}, zap.NewNop())
+	require.NoError(t, err)
+	defer lists.Close()
+
+	assert.Empty(t, checkCode(t, lists, "https://example.com/"))
+	assert.Equal(t, CodeDomainBlocked, checkCode(t, lists, "http://EVIL.example:8080/x"))
+}
+
+func TestListsCheckIDNA(t *testing.T) {
+	dir := t.TempDir()
+	blockPath := filepath.Join(dir, "block.txt")
+	allowPath := filepath.Join(dir, "allow.txt")
+	writeList(t, blockPath, "xn--mnchen-3ya.de\n")
+	writeList(t, allowPath, "münchen.de\nbücher.example\n")
+
+	lists, err := New(Options{Blocklist: []string{blockPath}}, zap.NewNop())
+	require.NoError(t, err)
+	defer lists.Close()
+
+	assert.Equal(t, CodeDomainBlocked, checkCode(t, lists, "http://münchen.de/"), "punycode entry blocks Unicode host")
+	assert.Equal(t, CodeDomainBlocked, checkCode(t, lists, "http://xn--mnchen-3ya.de/"))
+
+	allowed, err := New(Options{Allowlist: []string{allowPath}}, zap.NewNop())
+	require.NoError(t, err)
+	defer allowed.Close()
+
+	assert.Empty(t, checkCode(t, allowed, "https://xn--bcher-kva.example/"), "Unicode entry allows punycode host")
+	assert.Empty(t, checkCode(t, allowed, "https://bücher.example/"))
+	assert.Equal(t, CodeDomainNotAllowed, checkCode(t, allowed, "https://bucher.example/"))
+}
+
+func TestListsReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "block.txt")
+	writeList(t, path, "old.example\n")
+
+	lists, err := New(Options{Blocklist: []string{path}}, zap.NewNop())
+	require.NoError(t, err)
+	defer lists.Close()
+
+	changed, err := lists.Reload(context.Background())
+	require.NoError(t, err)
+	assert.False(t, changed, "unchanged sources must not replace lists")
+
+	writeList(t, path, "new.example\n")
+	changed, err = lists.Reload(context.Background())
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, checkCode(t, lists, "https://old.example/"))
+	assert.Equal(t, CodeDomainBlocked, checkCode(t, lists, "https://new.example/"))
+
+	writeList(t, path, "/[broken/\n")
+	_, err = lists.Reload(context.Background())
+	require.ErrorIs(t, err, ErrInvalidEntry)
+	assert.Equal(t, CodeDomainBlocked, checkCode(t, lists, "https://new.example/"), "previous lists must be kept")
+
+	require.NoError(t, os.Remove(path))
+	_, err = lists.Reload(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, CodeDomainBlocked, checkCode(t, lists, "https://new.example/"))
+}
+
+func TestListsHTTPSource(t *testing.T) {
+	var body atomic.Value
+	body.Store("remote.example\n")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	lists, err := New(Options{Blocklist: []string{server.URL + "/block.txt"}}, zap.NewNop())
+	require.NoError(t, err)
+	defer lists.Close()
+
+	assert.Equal(t, CodeDomainBlocked, checkCode(t, lists, "https://remote.example/"))
+
+	body.Store("*.remote.example\n")
+	changed, err := lists.Reload(context.Background())
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, checkCode(t, lists, "https://remote.example/"))
+	assert.Equal(t, CodeDomainBlocked, checkCode(t, lists, "https://www.remote.example/"))
+}
+
+func TestNewFailsOnMissingSource(t *testing.T) {
+	_, err := New(Options{Blocklist: []string{filepath.Join(t.TempDir(), "missing.txt")}}, zap.NewNop())
+	assert.Error(t, err)
+}
@@ -1,7 +1,10 @@
 // Package service содержит бизнес-логику приложения.
 package service
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // Ошибки сервисного слоя.
 var (
@@ -15,4 +18,54 @@ var (
 	ErrURLConflict = errors.New("URL already shortened")
 	// ErrURLDeleted - URL был удален.
 	ErrURLDeleted = errors.New("URL has been deleted")
+	// ErrURLExhausted - переходы по ссылке исчерпаны.
+	ErrURLExhausted = errors.New("URL has no uses left")
+	// ErrURLExpired - срок действия ссылки истек.
+	ErrURLExpired = errors.New("URL has expired")
+	// ErrURLNotActive - ссылка еще не начала действовать.
+	ErrURLNotActive = errors.New("URL is not active yet")
+	// ErrURLBlocked - URL назначения запрещен политикой.
+	ErrURLBlocked = errors.New("URL is blocked")
+	// ErrCampaignNotFound - кампания не найдена или принадлежит другому пользователю.
+	ErrCampaignNotFound = errors.New("campaign not found")
+	// ErrValidation - входные данные не прошли проверку.
+	ErrValidation = errors.New("validation failed")
 )
+
+// ValidationError описывает нарушение правил валидации входных данных.
+// Code - стабильный машиночитаемый код, Reason - описание для клиента.
+type ValidationError struct {
+	Field  string
+	Code   string
+	Reason string
+}
+
+// NewValidationError создает новую ошибку валидации.
+func NewValidationError(field, code, reason string) *ValidationError {
+	return &ValidationError{Field: field, Code: code, Reason: reason}
+}
+
+// Error возвращает текстовое описание ошибки.
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+// Unwrap позволяет сопоставлять ошибку с ErrValidation через errors.Is.
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// NotActiveError сообщает, что ссылка начнет действовать в ActiveFrom.
+type NotActiveError struct {
+	ActiveFrom time.Time
+}
+
+// Error возвращает текстовое описание ошибки.
+func (e *NotActiveError) Error() string {
+	return ErrURLNotActive.Error() + ": active from " + e.ActiveFrom.Format(time.RFC3339)
+}
+
+// Unwrap позволяет сопоставлять ошибку с ErrURLNotActive через errors.Is.
+func (e *NotActiveError) Unwrap() error {
+	return ErrURLNotActive
+}
@@ -0,0 +1,45 @@
+// Package linkpassword защищает короткие ссылки паролем.
+// Пароль хранится в виде хеша bcrypt; открытый пароль нигде не сохраняется.
+package linkpassword
+
+import (
+	"fmt"
+
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CodePasswordTooLong - пароль длиннее, чем позволяет bcrypt.
+const CodePasswordTooLong = "password_too_long"
+
+// maxLength - наибольшая длина пароля в байтах, которую учитывает bcrypt.
+const maxLength = 72
+
+// Validate проверяет пароль. field - имя поля в ошибке валидации.
+func Validate(field, password string) error {
+	if len(password) > maxLength {
+		return service.NewValidationError(field, CodePasswordTooLong,
+			fmt.Sprintf("%s is longer than %d bytes", field, maxLength))
+	}
+
+	return nil
+}
+
+// Hash возвращает хеш пароля для хранения. Для пустого пароля возвращает пустую строку.
+func Hash(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash link password: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+// Compare сообщает, соответствует ли пароль хешу.
+func Compare(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,35 @@
+package linkpassword
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashAndCompare(t *testing.T) {
+	hash, err := Hash("s3cret")
+	require.NoError(t, err)
+	assert.NotContains(t, hash, "s3cret")
+
+	assert.True(t, Compare(hash, "s3cret"))
+	assert.False(t, Compare(hash, "S3cret"))
+	assert.False(t, Compare(hash, ""))
+	assert.False(t, Compare("", "s3cret"))
+
+	empty, err := Hash("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("password", ""))
+	assert.NoError(t, Validate("password", strings.Repeat("p", 72)))
+
+	var validationErr *service.ValidationError
+	require.ErrorAs(t, Validate("password", strings.Repeat("p", 73)), &validationErr)
+	assert.Equal(t, CodePasswordTooLong, validationErr.Code)
+	assert.Equal(t, "password", validationErr.Field)
+}
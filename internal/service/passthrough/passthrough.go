@@ -0,0 +1,116 @@
+// Package passthrough переносит путь и параметры запроса к короткой ссылке в URL назначения:
+// переход по /{id}/rest?x=1 ведет на destination/rest?x=1.
+package passthrough
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrUnsafePath - остаток пути содержит сегменты, выводящие за пределы пути назначения.
+var ErrUnsafePath = errors.New("unsafe passthrough path")
+
+// Join добавляет к destination остаток пути rest (в экранированном виде, начиная с "/")
+// и параметры rawQuery. Пустые сегменты пропускаются, сегменты "." и ".." отклоняются,
+// поэтому результат остается на хосте и внутри пути destination.
+// Параметры запроса добавляются после параметров destination и заменяют одноименные.
+func Join(destination, rest, rawQuery string) (string, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	if rest != "" {
+		if err := joinPath(u, rest); err != nil {
+			return "", err
+		}
+	}
+
+	if rawQuery != "" {
+		u.RawQuery = mergeQuery(u.RawQuery, rawQuery)
+	}
+
+	return u.String(), nil
+}
+
+// joinPath дописывает сегменты rest к пути u, сохраняя их экранирование.
+func joinPath(u *url.URL, rest string) error {
+	var rawSegments, segments []string
+	for _, raw := range strings.Split(strings.TrimPrefix(rest, "/"), "/") {
+		segment, err := url.PathUnescape(raw)
+		if err != nil {
+			return ErrUnsafePath
+		}
+
+		if segment == "" {
+			continue
+		}
+
+		if hasDotSegment(segment) {
+			return ErrUnsafePath
+		}
+
+		rawSegments = append(rawSegments, raw)
+		segments = append(segments, segment)
+	}
+
+	rawPath := strings.TrimSuffix(u.EscapedPath(), "/")
+	path := strings.TrimSuffix(u.Path, "/")
+	if len(segments) > 0 {
+		rawPath += "/" + strings.Join(rawSegments, "/")
+		path += "/" + strings.Join(segments, "/")
+	}
+
+	if strings.HasSuffix(rest, "/") {
+		rawPath += "/"
+		path += "/"
+	}
+
+	u.Path = path
+	u.RawPath = rawPath
+
+	return nil
+}
+
+// hasDotSegment сообщает, содержит ли сегмент переход по каталогам "." или "..",
+// в том числе между экранированными разделителями, например "..%2F..".
+func hasDotSegment(segment string) bool {
+	for _, part := range strings.FieldsFunc(segment, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == "." || part == ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeQuery добавляет параметры extra к base; параметры base с теми же именами удаляются.
+func mergeQuery(base, extra string) string {
+	if base == "" {
+		return extra
+	}
+
+	overridden := make(map[string]bool)
+	for _, part := range strings.Split(extra, "&") {
+		overridden[queryKey(part)] = true
+	}
+
+	var parts []string
+	for _, part := range strings.Split(base, "&") {
+		if !overridden[queryKey(part)] {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(append(parts, extra), "&")
+}
+
+func queryKey(part string) string {
+	key, _, _ := strings.Cut(part, "=")
+	if unescaped, err := url.QueryUnescape(key); err == nil {
+		return unescaped
+	}
+
+	return key
+}
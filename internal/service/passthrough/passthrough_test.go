@@ -0,0 +1,106 @@
+package passthrough
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		rest        string
+		rawQuery    string
+		want        string
+		wantErr     error
+	}{
+		{
+			name:        "nothing to forward",
+			destination: "https://example.com/docs?lang=en",
+			want:        "https://example.com/docs?lang=en",
+		},
+		{
+			name:        "path and query",
+			destination: "https://example.com/docs",
+			rest:        "/guide/intro",
+			rawQuery:    "x=1",
+			want:        "https://example.com/docs/guide/intro?x=1",
+		},
+		{
+			name:        "destination without path",
+			destination: "https://example.com",
+			rest:        "/a",
+			want:        "https://example.com/a",
+		},
+		{
+			name:        "trailing slashes are not doubled",
+			destination: "https://example.com/docs/",
+			rest:        "/a/",
+			want:        "https://example.com/docs/a/",
+		},
+		{
+			name:        "empty segments collapse",
+			destination: "https://example.com/docs",
+			rest:        "//evil.com/x",
+			want:        "https://example.com/docs/evil.com/x",
+		},
+		{
+			name:        "escaping is preserved",
+			destination: "https://example.com/files",
+			rest:        "/a%2Fb/c%20d",
+			want:        "https://example.com/files/a%2Fb/c%20d",
+		},
+		{
+			name:        "query params override destination params",
+			destination: "https://example.com/?lang=en&page=1#top",
+			rawQuery:    "page=2&q=go",
+			want:        "https://example.com/?lang=en&page=2&q=go#top",
+		},
+		{
+			name:        "parent segment",
+			destination: "https://example.com/docs",
+			rest:        "/../admin",
+			wantErr:     ErrUnsafePath,
+		},
+		{
+			name:        "escaped parent segment",
+			destination: "https://example.com/docs",
+			rest:        "/%2e%2e/admin",
+			wantErr:     ErrUnsafePath,
+		},
+		{
+			name:        "parent segment behind escaped slash",
+			destination: "https://example.com/docs",
+			rest:        "/x%2F..%2F..%2Fadmin",
+			wantErr:     ErrUnsafePath,
+		},
+		{
+			name:        "backslash traversal",
+			destination: "https://example.com/docs",
+			rest:        "/..%5Cadmin",
+			wantErr:     ErrUnsafePath,
+		},
+		{
+			name:        "invalid escape",
+			destination: "https://example.com/docs",
+			rest:        "/%zz",
+			wantErr:     ErrUnsafePath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Join(tt.destination, tt.rest, tt.rawQuery)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
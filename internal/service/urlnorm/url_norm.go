@@ -0,0 +1,274 @@
+// Package urlnorm приводит URL к каноническому виду, чтобы одинаковые адреса,
+// записанные по-разному, считались дубликатами.
+//
+// Нормализация: схема и хост в нижнем регистре, хост с национальными символами
+// в Punycode, без порта по умолчанию, с раскрытыми сегментами . и .. в пути и
+// единообразным процентным кодированием. Дополнительно можно сортировать
+// параметры запроса и удалять параметры отслеживания (utm_*, fbclid и т.п.).
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidURL - URL не удалось разобрать.
+var ErrInvalidURL = errors.New("url cannot be normalized")
+
+// DefaultTrackingParams - параметры отслеживания, удаляемые по умолчанию.
+// Шаблон, оканчивающийся на *, совпадает с любым параметром с этим префиксом.
+var DefaultTrackingParams = []string{"utm_*", "fbclid", "gclid", "yclid", "msclkid", "mc_cid", "mc_eid", "_openstat"}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// Options содержит необязательные шаги нормализации.
+type Options struct {
+	// SortQuery - сортировать параметры запроса по имени
+	SortQuery bool
+	// StripTracking - удалять параметры отслеживания
+	StripTracking bool
+	// TrackingParams - шаблоны параметров отслеживания; по умолчанию DefaultTrackingParams
+	TrackingParams []string
+}
+
+// Normalizer приводит URL к каноническому виду.
+type Normalizer struct {
+	opts Options
+}
+
+// New создает нормализатор с заданными настройками.
+func New(opts Options) *Normalizer {
+	if len(opts.TrackingParams) == 0 {
+		opts.TrackingParams = DefaultTrackingParams
+	}
+
+	patterns := make([]string, len(opts.TrackingParams))
+	for i, pattern := range opts.TrackingParams {
+		patterns[i] = strings.ToLower(pattern)
+	}
+	opts.TrackingParams = patterns
+
+	return &Normalizer{opts: opts}
+}
+
+// Normalize возвращает канонический вид URL.
+func (n *Normalizer) Normalize(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Scheme == "" || u.Opaque != "" || u.Host == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidURL, rawURL)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+
+	host, err := normalizeHost(u.Hostname())
+	if err != nil {
+		return "", fmt.Errorf("%w: %q: %w", ErrInvalidURL, rawURL, err)
+	}
+
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteString("://")
+
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+
+	b.WriteString(host)
+	if port := u.Port(); port != "" && port != defaultPorts[scheme] {
+		b.WriteByte(':')
+		b.WriteString(port)
+	}
+
+	path := removeDotSegments(normalizeEscapes(u.EscapedPath()))
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString(path)
+
+	if query := n.normalizeQuery(u.RawQuery); query != "" {
+		b.WriteByte('?')
+		b.WriteString(query)
+	}
+
+	if u.Fragment != "" {
+		b.WriteByte('#')
+		b.WriteString(normalizeEscapes(u.EscapedFragment()))
+	}
+
+	return b.String(), nil
+}
+
+// normalizeHost приводит хост к нижнему регистру и ASCII-записи.
+// IPv6-адреса записываются в каноническом сокращенном виде в квадратных скобках.
+func normalizeHost(host string) (string, error) {
+	if strings.Contains(host, ":") {
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return "", err
+		}
+
+		return "[" + addr.String() + "]", nil
+	}
+
+	return HostToASCII(host)
+}
+
+// HostToASCII приводит имя хоста к нижнему регистру и ASCII-записи (IDNA):
+// münchen.de и xn--mnchen-3ya.de дают один результат.
+func HostToASCII(host string) (string, error) {
+	return toASCII(norm.NFC.String(strings.ToLower(host)))
+}
+
+// normalizeQuery удаляет пустые и отслеживающие параметры и при необходимости сортирует их.
+// Порядок значений одного параметра сохраняется.
+func (n *Normalizer) normalizeQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	type param struct {
+		key  string
+		pair string
+	}
+
+	var params []param
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		pair = normalizeEscapes(pair)
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+
+		if n.opts.StripTracking && n.isTracking(key) {
+			continue
+		}
+
+		params = append(params, param{key: key, pair: pair})
+	}
+
+	if n.opts.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool { return params[i].key < params[j].key })
+	}
+
+	pairs := make([]string, len(params))
+	for i, p := range params {
+		pairs[i] = p.pair
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func (n *Normalizer) isTracking(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range n.opts.TrackingParams {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeEscapes записывает процентные последовательности заглавными буквами
+// и декодирует незарезервированные символы (RFC 3986, раздел 6.2.2.2).
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+
+			continue
+		}
+
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+		}
+		i += 2
+	}
+
+	return b.String()
+}
+
+// removeDotSegments раскрывает сегменты . и .. в пути (RFC 3986, раздел 5.2.4).
+// Повторяющиеся косые черты и завершающая косая черта сохраняются.
+func removeDotSegments(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	segments := strings.Split(path, "/")
+	out := make([]string, 0, len(segments))
+	last := len(segments) - 1
+
+	for i, segment := range segments {
+		switch segment {
+		case ".":
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, segment)
+
+			continue
+		}
+
+		if i == last {
+			out = append(out, "")
+		}
+	}
+
+	return strings.Join(out, "/")
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	default:
+		return c - '0'
+	}
+}
@@ -0,0 +1,111 @@
+package urlnorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		url  string
+		want string
+	}{
+		{name: "already canonical", url: "https://example.com/path?a=1", want: "https://example.com/path?a=1"},
+		{name: "case of scheme and host", url: "HTTP://Example.COM/Path", want: "http://example.com/Path"},
+		{name: "empty path", url: "http://example.com", want: "http://example.com/"},
+		{name: "default http port", url: "http://example.com:80/", want: "http://example.com/"},
+		{name: "default https port", url: "https://example.com:443/a", want: "https://example.com/a"},
+		{name: "non default port", url: "https://example.com:8443/a", want: "https://example.com:8443/a"},
+		{name: "http port on https", url: "https://example.com:80/", want: "https://example.com:80/"},
+		{name: "dot segments", url: "http://example.com/a/./b/../c", want: "http://example.com/a/c"},
+		{name: "dot segments above root", url: "http://example.com/../../a", want: "http://example.com/a"},
+		{name: "trailing dot segment", url: "http://example.com/a/b/..", want: "http://example.com/a/"},
+		{name: "trailing slash kept", url: "http://example.com/a/", want: "http://example.com/a/"},
+		{name: "double slash kept", url: "http://example.com/a//b", want: "http://example.com/a//b"},
+		{name: "escape case", url: "http://example.com/a%2fb%c3%a9", want: "http://example.com/a%2Fb%C3%A9"},
+		{name: "unreserved escapes decoded", url: "http://example.com/%7Euser/%41", want: "http://example.com/~user/A"},
+		{name: "encoded dot segment", url: "http://example.com/a/%2E%2E/b", want: "http://example.com/b"},
+		{name: "idn", url: "https://Пример.РФ/", want: "https://xn--e1afmkfd.xn--p1ai/"},
+		{name: "idn with ascii part", url: "http://bücher.example/", want: "http://xn--bcher-kva.example/"},
+		{name: "ipv6", url: "http://[2001:DB8:0:0::1]:80/", want: "http://[2001:db8::1]/"},
+		{name: "query order kept by default", url: "http://example.com/?b=2&a=1", want: "http://example.com/?b=2&a=1"},
+		{name: "empty query dropped", url: "http://example.com/?", want: "http://example.com/"},
+		{name: "empty pairs dropped", url: "http://example.com/?a=1&&b=2&", want: "http://example.com/?a=1&b=2"},
+		{name: "fragment kept", url: "http://example.com/#Section", want: "http://example.com/#Section"},
+		{
+			name: "sorted query",
+			opts: Options{SortQuery: true},
+			url:  "http://example.com/?b=2&a=1&b=1",
+			want: "http://example.com/?a=1&b=2&b=1",
+		},
+		{
+			name: "tracking kept by default",
+			url:  "http://example.com/?utm_source=x&id=1",
+			want: "http://example.com/?utm_source=x&id=1",
+		},
+		{
+			name: "tracking stripped",
+			opts: Options{StripTracking: true},
+			url:  "http://example.com/?UTM_Source=x&id=1&fbclid=abc&utm_campaign=y",
+			want: "http://example.com/?id=1",
+		},
+		{
+			name: "only tracking params",
+			opts: Options{StripTracking: true},
+			url:  "http://example.com/page?utm_medium=email",
+			want: "http://example.com/page",
+		},
+		{
+			name: "custom tracking params",
+			opts: Options{StripTracking: true, TrackingParams: []string{"ref", "src_*"}},
+			url:  "http://example.com/?ref=1&src_a=2&utm_source=3",
+			want: "http://example.com/?utm_source=3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.opts).Normalize(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeEquivalentURLs(t *testing.T) {
+	n := New(Options{})
+
+	variants := []string{"HTTP://Example.com/", "http://example.com", "http://example.com:80/", "http://EXAMPLE.com/./"}
+	for _, variant := range variants {
+		got, err := n.Normalize(variant)
+		require.NoError(t, err)
+		assert.Equal(t, "http://example.com/", got, variant)
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	for _, raw := range []string{"", "not a url", "example.com/path", "mailto:user@example.com", "http://[::1/"} {
+		_, err := New(Options{}).Normalize(raw)
+		assert.ErrorIs(t, err, ErrInvalidURL, raw)
+	}
+}
+
+func TestPunycodeEncode(t *testing.T) {
+	// Примеры из RFC 3492, раздел 7.1.
+	tests := map[string]string{
+		"ليهمابتكلموشعربي؟": "egbpdaj6bu4bxfgehfvwxn",
+		"他们为什么不说中文":         "ihqwcrb4cv8a8dqg056pqjye",
+		"bücher":            "bcher-kva",
+		"пример":            "e1afmkfd",
+	}
+
+	for input, want := range tests {
+		got, err := punycodeEncode(input)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, input)
+	}
+}
@@ -0,0 +1,300 @@
+// Package urlpolicy содержит правила проверки URL назначения перед сокращением.
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+)
+
+// Коды нарушений политики.
+const (
+	CodeInvalidURL       = "invalid_url"
+	CodeSchemeNotAllowed = "url_scheme_not_allowed"
+	CodeTooLong          = "url_too_long"
+	CodeUserInfo         = "url_userinfo_not_allowed"
+	CodePrivateAddress   = "url_private_address"
+	CodeInternalHost     = "url_internal_host"
+	CodeSelfLoop         = "url_self_loop"
+	CodeUnresolvable     = "url_unresolvable"
+)
+
+const (
+	defaultMaxLength      = 8192
+	defaultResolveTimeout = 2 * time.Second
+	validationField       = "url"
+)
+
+// internalSuffixes - зоны, которые не разрешаются в публичном DNS.
+var internalSuffixes = []string{".localhost", ".local", ".internal", ".intranet", ".lan", ".home.arpa", ".corp"}
+
+// reservedPrefixes - диапазоны, не покрытые методами netip.Addr, но недопустимые для ссылок.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// Policy проверяет URL назначения.
+// Нарушения возвращаются как *service.ValidationError.
+type Policy interface {
+	Check(ctx context.Context, rawURL string) error
+}
+
+// Resolver разрешает имя хоста в IP-адреса. Реализуется net.Resolver.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Options содержит настройки политики.
+type Options struct {
+	// AllowedSchemes - допустимые схемы; по умолчанию http и https
+	AllowedSchemes []string
+	// MaxLength - максимальная длина URL; по умолчанию 8192
+	MaxLength int
+	// AllowPrivate - разрешить IP-адреса частных и служебных сетей и внутренние имена хостов
+	AllowPrivate bool
+	// ResolveDNS - разрешать имя хоста и проверять полученные адреса
+	ResolveDNS bool
+	// Resolver - используемый резолвер; по умолчанию net.DefaultResolver
+	Resolver Resolver
+	// ResolveTimeout - таймаут разрешения имени
+	ResolveTimeout time.Duration
+	// BaseURL - адрес сервиса; ссылки на него запрещены, чтобы не создавать циклы
+	BaseURL string
+}
+
+type policy struct {
+	opts     Options
+	selfHost string
+}
+
+// New создает политику проверки URL с заданными настройками.
+func New(opts Options) Policy {
+	if len(opts.AllowedSchemes) == 0 {
+		opts.AllowedSchemes = []string{"http", "https"}
+	}
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = defaultMaxLength
+	}
+	if opts.Resolver == nil {
+		opts.Resolver = net.DefaultResolver
+	}
+	if opts.ResolveTimeout <= 0 {
+		opts.ResolveTimeout = defaultResolveTimeout
+	}
+
+	p := &policy{opts: opts}
+	if base, err := url.Parse(opts.BaseURL); err == nil {
+		p.selfHost = normalizeHost(base.Hostname())
+	}
+
+	return p
+}
+
+// Check проверяет URL по всем правилам политики.
+func (p *policy) Check(ctx context.Context, rawURL string) error {
+	if len(rawURL) > p.opts.MaxLength {
+		return violation(CodeTooLong, fmt.Sprintf("url is longer than %d characters", p.opts.MaxLength))
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return violation(CodeInvalidURL, "url not correct")
+	}
+
+	if !slices.Contains(p.opts.AllowedSchemes, strings.ToLower(u.Scheme)) {
+		return violation(CodeSchemeNotAllowed, fmt.Sprintf("scheme %q is not allowed", u.Scheme))
+	}
+
+	if u.User != nil {
+		return violation(CodeUserInfo, "credentials in url are not allowed")
+	}
+
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return violation(CodeInvalidURL, "url not correct")
+	}
+
+	if p.selfHost != "" && host == p.selfHost {
+		return violation(CodeSelfLoop, "url points to this service")
+	}
+
+	if p.opts.AllowPrivate {
+		return nil
+	}
+
+	if addr, ok := parseIPLiteral(host); ok {
+		if isRestricted(addr) {
+			return violation(CodePrivateAddress, "url points to a private or reserved address")
+		}
+
+		return nil
+	}
+
+	if isInternalHost(host) {
+		return violation(CodeInternalHost, "internal host names are not allowed")
+	}
+
+	if p.opts.ResolveDNS {
+		return p.checkResolved(ctx, host)
+	}
+
+	return nil
+}
+
+// checkResolved разрешает имя хоста и проверяет все полученные адреса.
+func (p *policy) checkResolved(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.ResolveTimeout)
+	defer cancel()
+
+	addrs, err := p.opts.Resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return violation(CodeUnresolvable, "url host cannot be resolved")
+	}
+
+	for _, ipAddr := range addrs {
+		addr, ok := netip.AddrFromSlice(ipAddr.IP)
+		if !ok || isRestricted(addr.Unmap()) {
+			return violation(CodePrivateAddress, "url host resolves to a private or reserved address")
+		}
+	}
+
+	return nil
+}
+
+// Chain объединяет политики: URL должен пройти каждую из них.
+func Chain(policies ...Policy) Policy {
+	return chain(policies)
+}
+
+type chain []Policy
+
+// Check проверяет URL всеми политиками по порядку и возвращает первое нарушение.
+func (c chain) Check(ctx context.Context, rawURL string) error {
+	for _, p := range c {
+		if err := p.Check(ctx, rawURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func violation(code, reason string) error {
+	return service.NewValidationError(validationField, code, reason)
+}
+
+// normalizeHost приводит имя хоста к нижнему регистру и убирает завершающую точку.
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// isInternalHost проверяет однокомпонентные имена и внутренние зоны.
+func isInternalHost(host string) bool {
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+
+	for _, suffix := range internalSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isRestricted проверяет, что адрес относится к частной, локальной или служебной сети.
+func isRestricted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+
+	if addr.Is4() && addr == netip.AddrFrom4([4]byte{255, 255, 255, 255}) {
+		return true
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIPLiteral распознает IP-адрес в имени хоста, включая устаревшие
+// записи IPv4, которые понимают браузеры: 2130706433, 0x7f.1, 0177.0.0.1.
+func parseIPLiteral(host string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.Unmap(), true
+	}
+
+	return parseLegacyIPv4(host)
+}
+
+// parseLegacyIPv4 разбирает IPv4 в формате inet_aton: от одной до четырех частей
+// в десятичной, восьмеричной или шестнадцатеричной записи, последняя часть
+// заполняет оставшиеся байты.
+func parseLegacyIPv4(host string) (netip.Addr, bool) {
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		if part == "" {
+			return netip.Addr{}, false
+		}
+
+		// Основание 0 распознает префиксы 0x и 0 (восьмеричная запись), как inet_aton.
+		// Префиксы 0b, 0o и разделители "_" браузеры не поддерживают.
+		if strings.Contains(part, "_") || (len(part) > 1 && strings.ContainsAny(part[1:2], "bBoO")) {
+			return netip.Addr{}, false
+		}
+
+		v, err := strconv.ParseUint(part, 0, 32)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+
+		values[i] = v
+	}
+
+	var ip uint32
+	last := len(values) - 1
+	for i, v := range values[:last] {
+		if v > 0xff {
+			return netip.Addr{}, false
+		}
+		ip |= uint32(v) << (24 - 8*i)
+	}
+
+	if values[last] >= 1<<(8*(4-last)) {
+		return netip.Addr{}, false
+	}
+	ip |= uint32(values[last])
+
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
@@ -0,0 +1,190 @@
+package urlshorterservice
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/linkpassword"
+	"github.com/MarkelovSergey/url-shorter/internal/service/utmtemplate"
+)
+
+// Ограничения свойств ссылки.
+const (
+	maxTitleLength = 255
+	maxNotesLength = 4096
+	maxTagLength   = 64
+	maxTags        = 20
+)
+
+// Коды нарушений в свойствах ссылки.
+const (
+	CodeTitleTooLong = "title_too_long"
+	CodeNotesTooLong = "notes_too_long"
+	CodeTagTooLong   = "tag_too_long"
+	CodeTooManyTags  = "too_many_tags"
+	CodeInvalidTag   = "invalid_tag"
+	// CodeUnknownCampaign - кампания не найдена среди кампаний пользователя
+	CodeUnknownCampaign = "unknown_campaign"
+	// CodeInvalidRedirectType - код перенаправления не из списка допустимых
+	CodeInvalidRedirectType = "invalid_redirect_type"
+	// CodeInvalidMaxClicks - отрицательное ограничение числа переходов
+	CodeInvalidMaxClicks = "invalid_max_clicks"
+	// CodeInvalidActiveWindow - ссылка должна начать действовать раньше, чем истечет ее срок
+	CodeInvalidActiveWindow = "invalid_active_window"
+)
+
+// IsRedirectStatus сообщает, можно ли использовать код для перенаправления по ссылке.
+func IsRedirectStatus(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+var errUnknownCampaign = service.NewValidationError("campaign_id", CodeUnknownCampaign, "campaign not found")
+
+// normalizeAttributes проверяет свойства ссылки и приводит их к хранимому виду:
+// убирает пробелы по краям, теги переводит в нижний регистр, сортирует и убирает повторы.
+func normalizeAttributes(attrs model.LinkAttributes) (model.LinkAttributes, error) {
+	attrs.Title = strings.TrimSpace(attrs.Title)
+	attrs.CampaignID = strings.TrimSpace(attrs.CampaignID)
+	if utf8.RuneCountInString(attrs.Title) > maxTitleLength {
+		return attrs, service.NewValidationError("title", CodeTitleTooLong,
+			fmt.Sprintf("title is longer than %d characters", maxTitleLength))
+	}
+
+	attrs.Notes = strings.TrimSpace(attrs.Notes)
+	if utf8.RuneCountInString(attrs.Notes) > maxNotesLength {
+		return attrs, service.NewValidationError("notes", CodeNotesTooLong,
+			fmt.Sprintf("notes are longer than %d characters", maxNotesLength))
+	}
+
+	tags := normalizeTags(attrs.Tags)
+	if len(tags) > maxTags {
+		return attrs, service.NewValidationError("tags", CodeTooManyTags,
+			fmt.Sprintf("a link can have at most %d tags", maxTags))
+	}
+
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return attrs, service.NewValidationError("tags", CodeTagTooLong,
+				fmt.Sprintf("tag is longer than %d characters", maxTagLength))
+		}
+
+		// Запятая разделяет теги в параметре tag при поиске.
+		if strings.Contains(tag, ",") || !utf8.ValidString(tag) {
+			return attrs, service.NewValidationError("tags", CodeInvalidTag,
+				fmt.Sprintf("tag %q is not allowed", tag))
+		}
+	}
+
+	attrs.Tags = tags
+
+	if err := utmtemplate.Validate("utm_params", attrs.UTMParams); err != nil {
+		return attrs, err
+	}
+	if len(attrs.UTMParams) == 0 {
+		attrs.UTMParams = nil
+	}
+
+	attrs.UTMOverride = strings.ToLower(strings.TrimSpace(attrs.UTMOverride))
+	if err := utmtemplate.ValidateOverride("utm_override", attrs.UTMOverride); err != nil {
+		return attrs, err
+	}
+
+	if attrs.RedirectType != 0 && !IsRedirectStatus(attrs.RedirectType) {
+		return attrs, service.NewValidationError("redirect_type", CodeInvalidRedirectType,
+			"redirect_type must be 301, 302, 307 or 308")
+	}
+
+	if attrs.MaxClicks < 0 {
+		return attrs, service.NewValidationError("max_clicks", CodeInvalidMaxClicks,
+			"max_clicks must not be negative")
+	}
+
+	attrs.ActiveFrom = normalizeTime(attrs.ActiveFrom)
+	attrs.ExpiresAt = normalizeTime(attrs.ExpiresAt)
+	if attrs.ActiveFrom != nil && attrs.ExpiresAt != nil && !attrs.ActiveFrom.Before(*attrs.ExpiresAt) {
+		return attrs, service.NewValidationError("active_from", CodeInvalidActiveWindow,
+			"active_from must be earlier than expires_at")
+	}
+
+	if err := linkpassword.Validate("password", attrs.Password); err != nil {
+		return attrs, err
+	}
+
+	return attrs, nil
+}
+
+// normalizeTags приводит теги к нижнему регистру, отбрасывает пустые и повторы.
+// Возвращает nil, если тегов нет.
+func normalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			result = append(result, tag)
+		}
+	}
+
+	slices.Sort(result)
+
+	return slices.Compact(result)
+}
+
+// normalizeTime переводит время в UTC; нулевое время означает отсутствие ограничения.
+func normalizeTime(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+
+	utc := t.UTC()
+
+	return &utc
+}
+
+// applyUpdate возвращает attrs с примененными изменениями.
+func applyUpdate(attrs model.LinkAttributes, update model.LinkUpdate) model.LinkAttributes {
+	if update.Title != nil {
+		attrs.Title = *update.Title
+	}
+	if update.Notes != nil {
+		attrs.Notes = *update.Notes
+	}
+	if update.Tags != nil {
+		attrs.Tags = *update.Tags
+	}
+	if update.CampaignID != nil {
+		attrs.CampaignID = *update.CampaignID
+	}
+	if update.UTMParams != nil {
+		attrs.UTMParams = *update.UTMParams
+	}
+	if update.UTMOverride != nil {
+		attrs.UTMOverride = *update.UTMOverride
+	}
+	if update.Passthrough != nil {
+		attrs.Passthrough = *update.Passthrough
+	}
+	if update.RedirectType != nil {
+		attrs.RedirectType = *update.RedirectType
+	}
+	if update.MaxClicks != nil {
+		attrs.MaxClicks = *update.MaxClicks
+	}
+	if update.ActiveFrom != nil {
+		attrs.ActiveFrom = update.ActiveFrom
+	}
+	if update.ExpiresAt != nil {
+		attrs.ExpiresAt = update.ExpiresAt
+	}
+
+	return attrs
+}
@@ -6,6 +6,7 @@ package urlshorterservice
 
 import (
 	"context"
+	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/model"
 	mock "github.com/stretchr/testify/mock"
@@ -39,8 +40,8 @@ func (_m *MockURLShorterService) EXPECT() *MockURLShorterService_Expecter {
 }
 
 // DeleteURLsAsync provides a mock function for the type MockURLShorterService
-func (_mock *MockURLShorterService) DeleteURLsAsync(shortURLs []string, userID string) {
-	_mock.Called(shortURLs, userID)
+func (_mock *MockURLShorterService) DeleteURLsAsync(ctx context.Context, shortURLs []string, userID string) {
+	_mock.Called(ctx, shortURLs, userID)
 	return
 }
 
@@ -50,71 +51,14 @@ type MockURLShorterService_DeleteURLsAsync_Call struct {
 }
 
 // DeleteURLsAsync is a helper method to define mock.On call
-//   - shortURLs []string
-//   - userID string
-func (_e *MockURLShorterService_Expecter) DeleteURLsAsync(shortURLs interface{}, userID interface{}) *MockURLShorterService_DeleteURLsAsync_Call {
-	return &MockURLShorterService_DeleteURLsAsync_Call{Call: _e.mock.On("DeleteURLsAsync", shortURLs, userID)}
-}
-
-func (_c *MockURLShorterService_DeleteURLsAsync_Call) Run(run func(shortURLs []string, userID string)) *MockURLShorterService_DeleteURLsAsync_Call {
-	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 []string
-		if args[0] != nil {
-			arg0 = args[0].([]string)
-		}
-		var arg1 string
-		if args[1] != nil {
-			arg1 = args[1].(string)
-		}
-		run(
-			arg0,
-			arg1,
-		)
-	})
-	return _c
-}
-
-func (_c *MockURLShorterService_DeleteURLsAsync_Call) Return() *MockURLShorterService_DeleteURLsAsync_Call {
-	_c.Call.Return()
-	return _c
-}
-
-func (_c *MockURLShorterService_DeleteURLsAsync_Call) RunAndReturn(run func(shortURLs []string, userID string)) *MockURLShorterService_DeleteURLsAsync_Call {
-	_c.Run(run)
-	return _c
-}
-
-// DeleteURLsBatch provides a mock function for the type MockURLShorterService
-func (_mock *MockURLShorterService) DeleteURLsBatch(ctx context.Context, shortURLs []string, userID string) error {
-	ret := _mock.Called(ctx, shortURLs, userID)
-
-	if len(ret) == 0 {
-		panic("no return value specified for DeleteURLsBatch")
-	}
-
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, []string, string) error); ok {
-		r0 = returnFunc(ctx, shortURLs, userID)
-	} else {
-		r0 = ret.Error(0)
-	}
-	return r0
-}
-
-// MockURLShorterService_DeleteURLsBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteURLsBatch'
-type MockURLShorterService_DeleteURLsBatch_Call struct {
-	*mock.Call
-}
-
-// DeleteURLsBatch is a helper method to define mock.On call
 //   - ctx context.Context
 //   - shortURLs []string
 //   - userID string
-func (_e *MockURLShorterService_Expecter) DeleteURLsBatch(ctx interface{}, shortURLs interface{}, userID interface{}) *MockURLShorterService_DeleteURLsBatch_Call {
-	return &MockURLShorterService_DeleteURLsBatch_Call{Call: _e.mock.On("DeleteURLsBatch", ctx, shortURLs, userID)}
+func (_e *MockURLShorterService_Expecter) DeleteURLsAsync(ctx interface{}, shortURLs interface{}, userID interface{}) *MockURLShorterService_DeleteURLsAsync_Call {
+	return &MockURLShorterService_DeleteURLsAsync_Call{Call: _e.mock.On("DeleteURLsAsync", ctx, shortURLs, userID)}
 }
 
-func (_c *MockURLShorterService_DeleteURLsBatch_Call) Run(run func(ctx context.Context, shortURLs []string, userID string)) *MockURLShorterService_DeleteURLsBatch_Call {
+func (_c *MockURLShorterService_DeleteURLsAsync_Call) Run(run func(ctx context.Context, shortURLs []string, userID string)) *MockURLShorterService_DeleteURLsAsync_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -137,19 +81,19 @@ func (_c *MockURLShorterService_DeleteURLsBatch_Call) Run(run func(ctx context.C
 	return _c
 }
 
-func (_c *MockURLShorterService_DeleteURLsBatch_Call) Return(err error) *MockURLShorterService_DeleteURLsBatch_Call {
-	_c.Call.Return(err)
+func (_c *MockURLShorterService_DeleteURLsAsync_Call) Return() *MockURLShorterService_DeleteURLsAsync_Call {
+	_c.Call.Return()
 	return _c
 }
 
-func (_c *MockURLShorterService_DeleteURLsBatch_Call) RunAndReturn(run func(ctx context.Context, shortURLs []string, userID string) error) *MockURLShorterService_DeleteURLsBatch_Call {
-	_c.Call.Return(run)
+func (_c *MockURLShorterService_DeleteURLsAsync_Call) RunAndReturn(run func(ctx context.Context, shortURLs []string, userID string)) *MockURLShorterService_DeleteURLsAsync_Call {
+	_c.Run(run)
 	return _c
 }
 
 // Generate provides a mock function for the type MockURLShorterService
-func (_mock *MockURLShorterService) Generate(ctx context.Context, url string, userID string) (string, error) {
-	ret := _mock.Called(ctx, url, userID)
+func (_mock *MockURLShorterService) Generate(ctx context.Context, url string, userID string, attrs model.LinkAttributes) (string, error) {
+	ret := _mock.Called(ctx, url, userID, attrs)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Generate")
@@ -157,16 +101,16 @@ func (_mock *MockURLShorterService) Generate(ctx context.Context, url string, us
 
 	var r0 string
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
-		return returnFunc(ctx, url, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, model.LinkAttributes) (string, error)); ok {
+		return returnFunc(ctx, url, userID, attrs)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = returnFunc(ctx, url, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, model.LinkAttributes) string); ok {
+		r0 = returnFunc(ctx, url, userID, attrs)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = returnFunc(ctx, url, userID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, model.LinkAttributes) error); ok {
+		r1 = returnFunc(ctx, url, userID, attrs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -182,11 +126,12 @@ type MockURLShorterService_Generate_Call struct {
 //   - ctx context.Context
 //   - url string
 //   - userID string
-func (_e *MockURLShorterService_Expecter) Generate(ctx interface{}, url interface{}, userID interface{}) *MockURLShorterService_Generate_Call {
-	return &MockURLShorterService_Generate_Call{Call: _e.mock.On("Generate", ctx, url, userID)}
+//   - attrs model.LinkAttributes
+func (_e *MockURLShorterService_Expecter) Generate(ctx interface{}, url interface{}, userID interface{}, attrs interface{}) *MockURLShorterService_Generate_Call {
+	return &MockURLShorterService_Generate_Call{Call: _e.mock.On("Generate", ctx, url, userID, attrs)}
 }
 
-func (_c *MockURLShorterService_Generate_Call) Run(run func(ctx context.Context, url string, userID string)) *MockURLShorterService_Generate_Call {
+func (_c *MockURLShorterService_Generate_Call) Run(run func(ctx context.Context, url string, userID string, attrs model.LinkAttributes)) *MockURLShorterService_Generate_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -200,10 +145,15 @@ func (_c *MockURLShorterService_Generate_Call) Run(run func(ctx context.Context,
 		if args[2] != nil {
 			arg2 = args[2].(string)
 		}
+		var arg3 model.LinkAttributes
+		if args[3] != nil {
+			arg3 = args[3].(model.LinkAttributes)
+		}
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3,
 		)
 	})
 	return _c
@@ -214,14 +164,14 @@ func (_c *MockURLShorterService_Generate_Call) Return(s string, err error) *Mock
 	return _c
 }
 
-func (_c *MockURLShorterService_Generate_Call) RunAndReturn(run func(ctx context.Context, url string, userID string) (string, error)) *MockURLShorterService_Generate_Call {
+func (_c *MockURLShorterService_Generate_Call) RunAndReturn(run func(ctx context.Context, url string, userID string, attrs model.LinkAttributes) (string, error)) *MockURLShorterService_Generate_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // GenerateBatch provides a mock function for the type MockURLShorterService
-func (_mock *MockURLShorterService) GenerateBatch(ctx context.Context, urls []string, userID string) ([]string, error) {
-	ret := _mock.Called(ctx, urls, userID)
+func (_mock *MockURLShorterService) GenerateBatch(ctx context.Context, links []model.Request, userID string) ([]string, error) {
+	ret := _mock.Called(ctx, links, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GenerateBatch")
@@ -229,18 +179,18 @@ func (_mock *MockURLShorterService) GenerateBatch(ctx context.Context, urls []st
 
 	var r0 []string
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, []string, string) ([]string, error)); ok {
-		return returnFunc(ctx, urls, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []model.Request, string) ([]string, error)); ok {
+		return returnFunc(ctx, links, userID)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, []string, string) []string); ok {
-		r0 = returnFunc(ctx, urls, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []model.Request, string) []string); ok {
+		r0 = returnFunc(ctx, links, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, []string, string) error); ok {
-		r1 = returnFunc(ctx, urls, userID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []model.Request, string) error); ok {
+		r1 = returnFunc(ctx, links, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -254,21 +204,21 @@ type MockURLShorterService_GenerateBatch_Call struct {
 
 // GenerateBatch is a helper method to define mock.On call
 //   - ctx context.Context
-//   - urls []string
+//   - links []model.Request
 //   - userID string
-func (_e *MockURLShorterService_Expecter) GenerateBatch(ctx interface{}, urls interface{}, userID interface{}) *MockURLShorterService_GenerateBatch_Call {
-	return &MockURLShorterService_GenerateBatch_Call{Call: _e.mock.On("GenerateBatch", ctx, urls, userID)}
+func (_e *MockURLShorterService_Expecter) GenerateBatch(ctx interface{}, links interface{}, userID interface{}) *MockURLShorterService_GenerateBatch_Call {
+	return &MockURLShorterService_GenerateBatch_Call{Call: _e.mock.On("GenerateBatch", ctx, links, userID)}
 }
 
-func (_c *MockURLShorterService_GenerateBatch_Call) Run(run func(ctx context.Context, urls []string, userID string)) *MockURLShorterService_GenerateBatch_Call {
+func (_c *MockURLShorterService_GenerateBatch_Call) Run(run func(ctx context.Context, links []model.Request, userID string)) *MockURLShorterService_GenerateBatch_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 []string
+		var arg1 []model.Request
 		if args[1] != nil {
-			arg1 = args[1].([]string)
+			arg1 = args[1].([]model.Request)
 		}
 		var arg2 string
 		if args[2] != nil {
@@ -288,28 +238,28 @@ func (_c *MockURLShorterService_GenerateBatch_Call) Return(strings []string, err
 	return _c
 }
 
-func (_c *MockURLShorterService_GenerateBatch_Call) RunAndReturn(run func(ctx context.Context, urls []string, userID string) ([]string, error)) *MockURLShorterService_GenerateBatch_Call {
+func (_c *MockURLShorterService_GenerateBatch_Call) RunAndReturn(run func(ctx context.Context, links []model.Request, userID string) ([]string, error)) *MockURLShorterService_GenerateBatch_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // GetOriginalURL provides a mock function for the type MockURLShorterService
-func (_mock *MockURLShorterService) GetOriginalURL(ctx context.Context, id string) (string, error) {
+func (_mock *MockURLShorterService) GetOriginalURL(ctx context.Context, id string) (model.Redirect, error) {
 	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetOriginalURL")
 	}
 
-	var r0 string
+	var r0 model.Redirect
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (model.Redirect, error)); ok {
 		return returnFunc(ctx, id)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) model.Redirect); ok {
 		r0 = returnFunc(ctx, id)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(model.Redirect)
 	}
 	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
 		r1 = returnFunc(ctx, id)
@@ -349,19 +299,91 @@ func (_c *MockURLShorterService_GetOriginalURL_Call) Run(run func(ctx context.Co
 	return _c
 }
 
-func (_c *MockURLShorterService_GetOriginalURL_Call) Return(s string, err error) *MockURLShorterService_GetOriginalURL_Call {
-	_c.Call.Return(s, err)
+func (_c *MockURLShorterService_GetOriginalURL_Call) Return(redirect model.Redirect, err error) *MockURLShorterService_GetOriginalURL_Call {
+	_c.Call.Return(redirect, err)
 	return _c
 }
 
-func (_c *MockURLShorterService_GetOriginalURL_Call) RunAndReturn(run func(ctx context.Context, id string) (string, error)) *MockURLShorterService_GetOriginalURL_Call {
+func (_c *MockURLShorterService_GetOriginalURL_Call) RunAndReturn(run func(ctx context.Context, id string) (model.Redirect, error)) *MockURLShorterService_GetOriginalURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserURL provides a mock function for the type MockURLShorterService
+func (_mock *MockURLShorterService) GetUserURL(ctx context.Context, userID string, shortCode string) (model.URLRecord, error) {
+	ret := _mock.Called(ctx, userID, shortCode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserURL")
+	}
+
+	var r0 model.URLRecord
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (model.URLRecord, error)); ok {
+		return returnFunc(ctx, userID, shortCode)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) model.URLRecord); ok {
+		r0 = returnFunc(ctx, userID, shortCode)
+	} else {
+		r0 = ret.Get(0).(model.URLRecord)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, userID, shortCode)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockURLShorterService_GetUserURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserURL'
+type MockURLShorterService_GetUserURL_Call struct {
+	*mock.Call
+}
+
+// GetUserURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - shortCode string
+func (_e *MockURLShorterService_Expecter) GetUserURL(ctx interface{}, userID interface{}, shortCode interface{}) *MockURLShorterService_GetUserURL_Call {
+	return &MockURLShorterService_GetUserURL_Call{Call: _e.mock.On("GetUserURL", ctx, userID, shortCode)}
+}
+
+func (_c *MockURLShorterService_GetUserURL_Call) Run(run func(ctx context.Context, userID string, shortCode string)) *MockURLShorterService_GetUserURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLShorterService_GetUserURL_Call) Return(uRLRecord model.URLRecord, err error) *MockURLShorterService_GetUserURL_Call {
+	_c.Call.Return(uRLRecord, err)
+	return _c
+}
+
+func (_c *MockURLShorterService_GetUserURL_Call) RunAndReturn(run func(ctx context.Context, userID string, shortCode string) (model.URLRecord, error)) *MockURLShorterService_GetUserURL_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // GetUserURLs provides a mock function for the type MockURLShorterService
-func (_mock *MockURLShorterService) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
-	ret := _mock.Called(ctx, userID)
+func (_mock *MockURLShorterService) GetUserURLs(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error) {
+	ret := _mock.Called(ctx, userID, filter)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetUserURLs")
@@ -369,18 +391,18 @@ func (_mock *MockURLShorterService) GetUserURLs(ctx context.Context, userID stri
 
 	var r0 []model.URLRecord
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]model.URLRecord, error)); ok {
-		return returnFunc(ctx, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, model.URLFilter) ([]model.URLRecord, error)); ok {
+		return returnFunc(ctx, userID, filter)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []model.URLRecord); ok {
-		r0 = returnFunc(ctx, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, model.URLFilter) []model.URLRecord); ok {
+		r0 = returnFunc(ctx, userID, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]model.URLRecord)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, userID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, model.URLFilter) error); ok {
+		r1 = returnFunc(ctx, userID, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -395,11 +417,12 @@ type MockURLShorterService_GetUserURLs_Call struct {
 // GetUserURLs is a helper method to define mock.On call
 //   - ctx context.Context
 //   - userID string
-func (_e *MockURLShorterService_Expecter) GetUserURLs(ctx interface{}, userID interface{}) *MockURLShorterService_GetUserURLs_Call {
-	return &MockURLShorterService_GetUserURLs_Call{Call: _e.mock.On("GetUserURLs", ctx, userID)}
+//   - filter model.URLFilter
+func (_e *MockURLShorterService_Expecter) GetUserURLs(ctx interface{}, userID interface{}, filter interface{}) *MockURLShorterService_GetUserURLs_Call {
+	return &MockURLShorterService_GetUserURLs_Call{Call: _e.mock.On("GetUserURLs", ctx, userID, filter)}
 }
 
-func (_c *MockURLShorterService_GetUserURLs_Call) Run(run func(ctx context.Context, userID string)) *MockURLShorterService_GetUserURLs_Call {
+func (_c *MockURLShorterService_GetUserURLs_Call) Run(run func(ctx context.Context, userID string, filter model.URLFilter)) *MockURLShorterService_GetUserURLs_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -409,9 +432,14 @@ func (_c *MockURLShorterService_GetUserURLs_Call) Run(run func(ctx context.Conte
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 model.URLFilter
+		if args[2] != nil {
+			arg2 = args[2].(model.URLFilter)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
@@ -422,7 +450,213 @@ func (_c *MockURLShorterService_GetUserURLs_Call) Return(uRLRecords []model.URLR
 	return _c
 }
 
-func (_c *MockURLShorterService_GetUserURLs_Call) RunAndReturn(run func(ctx context.Context, userID string) ([]model.URLRecord, error)) *MockURLShorterService_GetUserURLs_Call {
+func (_c *MockURLShorterService_GetUserURLs_Call) RunAndReturn(run func(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error)) *MockURLShorterService_GetUserURLs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordClick provides a mock function for the type MockURLShorterService
+func (_mock *MockURLShorterService) RecordClick(ctx context.Context, shortCode string) error {
+	ret := _mock.Called(ctx, shortCode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordClick")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, shortCode)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockURLShorterService_RecordClick_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordClick'
+type MockURLShorterService_RecordClick_Call struct {
+	*mock.Call
+}
+
+// RecordClick is a helper method to define mock.On call
+//   - ctx context.Context
+//   - shortCode string
+func (_e *MockURLShorterService_Expecter) RecordClick(ctx interface{}, shortCode interface{}) *MockURLShorterService_RecordClick_Call {
+	return &MockURLShorterService_RecordClick_Call{Call: _e.mock.On("RecordClick", ctx, shortCode)}
+}
+
+func (_c *MockURLShorterService_RecordClick_Call) Run(run func(ctx context.Context, shortCode string)) *MockURLShorterService_RecordClick_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLShorterService_RecordClick_Call) Return(err error) *MockURLShorterService_RecordClick_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockURLShorterService_RecordClick_Call) RunAndReturn(run func(ctx context.Context, shortCode string) error) *MockURLShorterService_RecordClick_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateUserURL provides a mock function for the type MockURLShorterService
+func (_mock *MockURLShorterService) UpdateUserURL(ctx context.Context, userID string, shortCode string, update model.LinkUpdate) (model.URLRecord, error) {
+	ret := _mock.Called(ctx, userID, shortCode, update)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserURL")
+	}
+
+	var r0 model.URLRecord
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, model.LinkUpdate) (model.URLRecord, error)); ok {
+		return returnFunc(ctx, userID, shortCode, update)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, model.LinkUpdate) model.URLRecord); ok {
+		r0 = returnFunc(ctx, userID, shortCode, update)
+	} else {
+		r0 = ret.Get(0).(model.URLRecord)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, model.LinkUpdate) error); ok {
+		r1 = returnFunc(ctx, userID, shortCode, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockURLShorterService_UpdateUserURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateUserURL'
+type MockURLShorterService_UpdateUserURL_Call struct {
+	*mock.Call
+}
+
+// UpdateUserURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - shortCode string
+//   - update model.LinkUpdate
+func (_e *MockURLShorterService_Expecter) UpdateUserURL(ctx interface{}, userID interface{}, shortCode interface{}, update interface{}) *MockURLShorterService_UpdateUserURL_Call {
+	return &MockURLShorterService_UpdateUserURL_Call{Call: _e.mock.On("UpdateUserURL", ctx, userID, shortCode, update)}
+}
+
+func (_c *MockURLShorterService_UpdateUserURL_Call) Run(run func(ctx context.Context, userID string, shortCode string, update model.LinkUpdate)) *MockURLShorterService_UpdateUserURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 model.LinkUpdate
+		if args[3] != nil {
+			arg3 = args[3].(model.LinkUpdate)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLShorterService_UpdateUserURL_Call) Return(uRLRecord model.URLRecord, err error) *MockURLShorterService_UpdateUserURL_Call {
+	_c.Call.Return(uRLRecord, err)
+	return _c
+}
+
+func (_c *MockURLShorterService_UpdateUserURL_Call) RunAndReturn(run func(ctx context.Context, userID string, shortCode string, update model.LinkUpdate) (model.URLRecord, error)) *MockURLShorterService_UpdateUserURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockClock creates a new instance of MockClock. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockClock(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockClock {
+	mock := &MockClock{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockClock is an autogenerated mock type for the Clock type
+type MockClock struct {
+	mock.Mock
+}
+
+type MockClock_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockClock) EXPECT() *MockClock_Expecter {
+	return &MockClock_Expecter{mock: &_m.Mock}
+}
+
+// Now provides a mock function for the type MockClock
+func (_mock *MockClock) Now() time.Time {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Now")
+	}
+
+	var r0 time.Time
+	if returnFunc, ok := ret.Get(0).(func() time.Time); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+	return r0
+}
+
+// MockClock_Now_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Now'
+type MockClock_Now_Call struct {
+	*mock.Call
+}
+
+// Now is a helper method to define mock.On call
+func (_e *MockClock_Expecter) Now() *MockClock_Now_Call {
+	return &MockClock_Now_Call{Call: _e.mock.On("Now")}
+}
+
+func (_c *MockClock_Now_Call) Run(run func()) *MockClock_Now_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockClock_Now_Call) Return(time1 time.Time) *MockClock_Now_Call {
+	_c.Call.Return(time1)
+	return _c
+}
+
+func (_c *MockClock_Now_Call) RunAndReturn(run func() time.Time) *MockClock_Now_Call {
 	_c.Call.Return(run)
 	return _c
 }
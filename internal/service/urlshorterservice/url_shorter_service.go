@@ -8,14 +8,21 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/model"
 	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/repository/campaignrepository"
 	"github.com/MarkelovSergey/url-shorter/internal/repository/healthrepository"
 	"github.com/MarkelovSergey/url-shorter/internal/repository/urlshorterrepository"
+	"github.com/MarkelovSergey/url-shorter/internal/requestid"
 	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/linkpassword"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlnorm"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlpolicy"
+	"github.com/MarkelovSergey/url-shorter/internal/service/utmtemplate"
 	"go.uber.org/zap"
 )
 
@@ -37,67 +44,229 @@ var shortCodePool = sync.Pool{
 // Предоставляет методы для генерации коротких кодов, получения оригинальных URL
 // и управления URL пользователя.
 type URLShorterService interface {
-	GetOriginalURL(ctx context.Context, id string) (string, error)
-	Generate(ctx context.Context, url, userID string) (string, error)
-	GenerateBatch(ctx context.Context, urls []string, userID string) ([]string, error)
-	GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error)
-	DeleteURLsAsync(shortURLs []string, userID string)
+	GetOriginalURL(ctx context.Context, id string) (model.Redirect, error)
+	Generate(ctx context.Context, url, userID string, attrs model.LinkAttributes) (string, error)
+	GenerateBatch(ctx context.Context, links []model.Request, userID string) ([]string, error)
+	GetUserURLs(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error)
+	GetUserURL(ctx context.Context, userID, shortCode string) (model.URLRecord, error)
+	UpdateUserURL(ctx context.Context, userID, shortCode string, update model.LinkUpdate) (model.URLRecord, error)
+	RecordClick(ctx context.Context, shortCode string) error
+	DeleteURLsAsync(ctx context.Context, shortURLs []string, userID string)
+}
+
+// Clock возвращает текущее время. Подменяется в тестах.
+// Тот же источник времени передается обработчику, чтобы заголовки ответа
+// совпадали с решением о сроке действия ссылки.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock - источник системного времени.
+type SystemClock struct{}
+
+// Now возвращает текущее системное время.
+func (SystemClock) Now() time.Time {
+	return time.Now()
 }
 
 type urlShorterService struct {
 	urlShorterRepo urlshorterrepository.URLShorterRepository
+	campaignRepo   campaignrepository.CampaignRepository
 	healthRepo     healthrepository.HealthRepository
 	logger         *zap.Logger
+	urlPolicy      urlpolicy.Policy
+	redirectPolicy urlpolicy.Policy
+	normalizer     *urlnorm.Normalizer
+	clock          Clock
 	rng            *rand.Rand
 	mu             *sync.Mutex
 }
 
+// Option настраивает необязательные зависимости сервиса.
+type Option func(*urlShorterService)
+
+// WithURLPolicy задает политику проверки URL назначения перед сокращением.
+func WithURLPolicy(policy urlpolicy.Policy) Option {
+	return func(s *urlShorterService) {
+		s.urlPolicy = policy
+	}
+}
+
+// WithRedirectPolicy задает политику, которой URL проверяется повторно при переходе.
+// Позволяет перестать перенаправлять по ссылкам, созданным до изменения правил.
+func WithRedirectPolicy(policy urlpolicy.Policy) Option {
+	return func(s *urlShorterService) {
+		s.redirectPolicy = policy
+	}
+}
+
+// WithNormalizer задает нормализатор URL для поиска дубликатов.
+// По умолчанию используется нормализация без сортировки запроса и удаления параметров отслеживания.
+func WithNormalizer(normalizer *urlnorm.Normalizer) Option {
+	return func(s *urlShorterService) {
+		s.normalizer = normalizer
+	}
+}
+
+// WithCampaigns подключает кампании: ссылки можно добавлять только в кампании их владельца.
+// Без кампаний ссылка с CampaignID отклоняется.
+func WithCampaigns(campaignRepo campaignrepository.CampaignRepository) Option {
+	return func(s *urlShorterService) {
+		s.campaignRepo = campaignRepo
+	}
+}
+
+// WithClock задает источник времени для проверки срока действия ссылок.
+func WithClock(clock Clock) Option {
+	return func(s *urlShorterService) {
+		s.clock = clock
+	}
+}
+
 // New создает новый экземпляр URLShorterService.
 func New(
 	urlShorterRepo urlshorterrepository.URLShorterRepository,
 	healthRepo healthrepository.HealthRepository,
 	logger *zap.Logger,
+	opts ...Option,
 ) URLShorterService {
 	var seed int64
 	if err := binary.Read(cryptorand.Reader, binary.BigEndian, &seed); err != nil {
 		seed = time.Now().UnixNano()
 	}
 
-	return &urlShorterService{
+	s := &urlShorterService{
 		urlShorterRepo: urlShorterRepo,
 		healthRepo:     healthRepo,
 		logger:         logger,
+		normalizer:     urlnorm.New(urlnorm.Options{}),
+		clock:          SystemClock{},
 		rng:            rand.New(rand.NewSource(seed)),
 		mu:             &sync.Mutex{},
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-// GetOriginalURL возвращает оригинальный URL по короткому коду.
-// Возвращает service.ErrURLDeleted, если URL был удален.
-// Возвращает service.ErrFindShortCode, если код не найден.
-func (s *urlShorterService) GetOriginalURL(ctx context.Context, shortCode string) (string, error) {
-	url, err := s.urlShorterRepo.Find(ctx, shortCode)
+// GetOriginalURL возвращает оригинальный URL по короткому коду вместе с параметрами перехода:
+// шаблоном UTM-параметров ссылки, дополненным шаблоном ее кампании.
+// Возвращает service.ErrURLDeleted, если URL был удален,
+// service.ErrURLExhausted, если переходы по ссылке исчерпаны, service.ErrURLExpired
+// после окончания срока действия и *service.NotActiveError до его начала.
+// Возвращает service.ErrFindShortCode, если код не найден,
+// и service.ErrURLBlocked, если URL запрещен политикой перехода.
+// Прочие ошибки хранилища не оборачиваются в service.ErrFindShortCode,
+// чтобы обработчики не выдавали сбой хранилища за отсутствующую ссылку.
+func (s *urlShorterService) GetOriginalURL(ctx context.Context, shortCode string) (model.Redirect, error) {
+	record, err := s.urlShorterRepo.Find(ctx, shortCode)
 	if err != nil {
 		if errors.Is(err, repository.ErrDeleted) {
-			return "", service.ErrURLDeleted
+			return model.Redirect{}, service.ErrURLDeleted
 		}
 		if errors.Is(err, repository.ErrNotFound) {
-			return "", fmt.Errorf("%w: %s", service.ErrFindShortCode, shortCode)
+			return model.Redirect{}, fmt.Errorf("%w: %s", service.ErrFindShortCode, shortCode)
+		}
+
+		return model.Redirect{}, fmt.Errorf("failed to look up short code %s: %w", shortCode, err)
+	}
+
+	now := s.clock.Now()
+	if record.ExpiresAt != nil && !now.Before(*record.ExpiresAt) {
+		return model.Redirect{}, service.ErrURLExpired
+	}
+
+	if record.ActiveFrom != nil && now.Before(*record.ActiveFrom) {
+		return model.Redirect{}, &service.NotActiveError{ActiveFrom: *record.ActiveFrom}
+	}
+
+	if record.MaxClicks > 0 && record.ClickCount >= record.MaxClicks {
+		return model.Redirect{}, service.ErrURLExhausted
+	}
+
+	if s.redirectPolicy != nil {
+		if err := s.redirectPolicy.Check(ctx, record.OriginalURL); err != nil {
+			var validationErr *service.ValidationError
+			if !errors.As(err, &validationErr) {
+				return model.Redirect{}, err
+			}
+
+			requestid.Logger(ctx, s.logger).Warn("redirect to blocked URL refused",
+				zap.String("short_code", shortCode),
+				zap.String("code", validationErr.Code),
+			)
+
+			return model.Redirect{}, fmt.Errorf("%w: %s", service.ErrURLBlocked, validationErr.Reason)
+		}
+	}
+
+	return s.redirect(ctx, record), nil
+}
+
+// redirect собирает параметры перехода по записи.
+// Недоступная кампания не мешает переходу: ссылка открывается с собственным шаблоном.
+func (s *urlShorterService) redirect(ctx context.Context, record model.URLRecord) model.Redirect {
+	redirect := model.Redirect{
+		ShortURL:     record.ShortURL,
+		OriginalURL:  record.OriginalURL,
+		UTMParams:    record.UTMParams,
+		UTMOverride:  record.UTMOverride,
+		Passthrough:  record.Passthrough,
+		RedirectType: record.RedirectType,
+		ExpiresAt:    record.ExpiresAt,
+		MaxClicks:    record.MaxClicks,
+		PasswordHash: record.PasswordHash,
+	}
+
+	if record.CampaignID == "" || s.campaignRepo == nil {
+		return redirect
+	}
+
+	campaign, err := s.campaignRepo.Get(ctx, record.UserID, record.CampaignID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			requestid.Logger(ctx, s.logger).Warn("failed to load campaign for redirect",
+				zap.String("short_code", record.ShortURL),
+				zap.String("campaign_id", record.CampaignID),
+				zap.Error(err),
+			)
 		}
 
-		return "", fmt.Errorf("%w: %w", service.ErrFindShortCode, err)
+		return redirect
 	}
 
-	return url, nil
+	redirect.CampaignName = campaign.Name
+	redirect.UTMParams = utmtemplate.Merge(campaign.UTMParams, record.UTMParams)
+
+	return redirect
 }
 
-// Generate генерирует короткий код для URL.
+// Generate генерирует короткий код для URL со свойствами attrs.
 // Выполняет до maxGenerateAttempts попыток генерации уникального кода.
-// Возвращает service.ErrURLConflict, если URL уже существует в базе.
-func (s *urlShorterService) Generate(ctx context.Context, url, userID string) (string, error) {
+// Дубликаты ищутся по нормализованному URL, а сохраняется исходная строка.
+// Возвращает service.ErrURLConflict, если URL уже существует в базе,
+// и *service.ValidationError, если URL нарушает политику или свойства некорректны.
+func (s *urlShorterService) Generate(ctx context.Context, url, userID string, attrs model.LinkAttributes) (string, error) {
+	if err := s.checkURL(ctx, url); err != nil {
+		return "", err
+	}
+
+	attrs, err := s.prepareAttributes(ctx, userID, attrs)
+	if err != nil {
+		return "", err
+	}
+
+	record, err := s.newRecord(url, userID, attrs)
+	if err != nil {
+		return "", err
+	}
+
 	for i := 0; i < maxGenerateAttempts; i++ {
-		candidate := s.generateRandomShortCode()
-		resultCode, err := s.urlShorterRepo.Add(ctx, candidate, url, userID)
+		record.ShortURL = s.generateRandomShortCode()
+		resultCode, err := s.urlShorterRepo.Add(ctx, record)
 		if err == nil {
 			return resultCode, nil
 		}
@@ -113,31 +282,67 @@ func (s *urlShorterService) Generate(ctx context.Context, url, userID string) (s
 		return "", fmt.Errorf("%w: %w", service.ErrSaveShortCode, err)
 	}
 
+	requestid.Logger(ctx, s.logger).Warn("short code space exhausted",
+		zap.Int("attempts", maxGenerateAttempts),
+		zap.String("user_id", userID),
+	)
+
 	return "",
 		fmt.Errorf("%w after %d attempts", service.ErrGenerateShortCode, maxGenerateAttempts)
 }
 
-// GenerateBatch генерирует короткие коды для нескольких URL.
+// GenerateBatch генерирует короткие коды для нескольких URL со свойствами.
 // Оптимизирована для пакетной обработки - все URL сохраняются за один запрос.
 // Возвращает срез коротких кодов в том же порядке, что и входные URL.
-func (s *urlShorterService) GenerateBatch(ctx context.Context, urls []string, userID string) ([]string, error) {
-	if len(urls) == 0 {
+// Если хотя бы один URL нарушает политику или имеет некорректные свойства, ничего не сохраняется.
+func (s *urlShorterService) GenerateBatch(ctx context.Context, links []model.Request, userID string) ([]string, error) {
+	if len(links) == 0 {
 		return nil, nil
 	}
 
-	urlMap := make(map[string]string, len(urls))
-	for _, url := range urls {
-		for i := 0; i < maxGenerateAttempts; i++ {
-			candidate := s.generateRandomShortCode()
-			if _, exists := urlMap[candidate]; !exists {
-				urlMap[candidate] = url
+	attrs := make([]model.LinkAttributes, len(links))
+	for i, link := range links {
+		err := s.checkURL(ctx, link.URL)
+		if err == nil {
+			attrs[i], err = s.prepareAttributes(ctx, userID, link.LinkAttributes)
+		}
+
+		if err != nil {
+			var validationErr *service.ValidationError
+			if errors.As(err, &validationErr) {
+				return nil, service.NewValidationError(
+					fmt.Sprintf("urls[%d]", i),
+					validationErr.Code,
+					fmt.Sprintf("urls[%d]: %s", i, validationErr.Reason),
+				)
+			}
 
+			return nil, err
+		}
+	}
+
+	records := make([]model.URLRecord, len(links))
+	candidates := make(map[string]struct{}, len(links))
+	for i, link := range links {
+		candidate := s.generateRandomShortCode()
+		for attempt := 1; attempt < maxGenerateAttempts; attempt++ {
+			if _, exists := candidates[candidate]; !exists {
 				break
 			}
+			candidate = s.generateRandomShortCode()
 		}
+		candidates[candidate] = struct{}{}
+
+		record, err := s.newRecord(link.URL, userID, attrs[i])
+		if err != nil {
+			return nil, err
+		}
+
+		record.ShortURL = candidate
+		records[i] = record
 	}
 
-	shortCodes, err := s.urlShorterRepo.AddBatch(ctx, urlMap, userID)
+	shortCodes, err := s.urlShorterRepo.AddBatch(ctx, records)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", service.ErrSaveShortCode, err)
 	}
@@ -145,23 +350,95 @@ func (s *urlShorterService) GenerateBatch(ctx context.Context, urls []string, us
 	return shortCodes, nil
 }
 
-// GetUserURLs возвращает все URL пользователя.
-// Включает как активные, так и удаленные URL.
-func (s *urlShorterService) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
-	return s.urlShorterRepo.GetUserURLs(ctx, userID)
+// GetUserURLs возвращает URL пользователя, удовлетворяющие фильтру.
+// Включает как активные, так и удаленные URL. Теги фильтра приводятся к виду,
+// в котором они хранятся.
+func (s *urlShorterService) GetUserURLs(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error) {
+	filter.Tags = normalizeTags(filter.Tags)
+	filter.Query = strings.TrimSpace(filter.Query)
+
+	return s.urlShorterRepo.GetUserURLs(ctx, userID, filter)
+}
+
+// UpdateUserURL изменяет заголовок, заметки и теги ссылки пользователя
+// и возвращает обновленную запись.
+// Возвращает service.ErrFindShortCode для чужой или отсутствующей ссылки,
+// service.ErrURLDeleted для удаленной и *service.ValidationError для некорректных свойств.
+func (s *urlShorterService) UpdateUserURL(
+	ctx context.Context,
+	userID, shortCode string,
+	update model.LinkUpdate,
+) (model.URLRecord, error) {
+	record, err := s.GetUserURL(ctx, userID, shortCode)
+	if err != nil {
+		return model.URLRecord{}, err
+	}
+
+	attrs, err := s.prepareAttributes(ctx, userID, applyUpdate(record.LinkAttributes, update))
+	if err != nil {
+		return model.URLRecord{}, err
+	}
+
+	if err := s.urlShorterRepo.UpdateAttributes(ctx, userID, shortCode, attrs); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDeleted):
+			return model.URLRecord{}, service.ErrURLDeleted
+		case errors.Is(err, repository.ErrNotFound):
+			return model.URLRecord{}, fmt.Errorf("%w: %s", service.ErrFindShortCode, shortCode)
+		}
+
+		return model.URLRecord{}, fmt.Errorf("failed to update short code %s: %w", shortCode, err)
+	}
+
+	return s.GetUserURL(ctx, userID, shortCode)
+}
+
+// GetUserURL возвращает ссылку пользователя по короткому коду вместе с метаданными.
+// Удаленные ссылки возвращаются с признаком IsDeleted.
+// Возвращает service.ErrFindShortCode, если кода нет или он принадлежит другому пользователю.
+func (s *urlShorterService) GetUserURL(ctx context.Context, userID, shortCode string) (model.URLRecord, error) {
+	record, err := s.urlShorterRepo.GetUserURL(ctx, userID, shortCode)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.URLRecord{}, fmt.Errorf("%w: %s", service.ErrFindShortCode, shortCode)
+		}
+
+		return model.URLRecord{}, fmt.Errorf("failed to look up short code %s: %w", shortCode, err)
+	}
+
+	return record, nil
+}
+
+// RecordClick учитывает переход по короткой ссылке.
+// Для ссылок с ограничением числа переходов проверка и учет выполняются атомарно:
+// service.ErrURLExhausted означает, что переходы закончились и перенаправлять нельзя.
+func (s *urlShorterService) RecordClick(ctx context.Context, shortCode string) error {
+	if err := s.urlShorterRepo.RecordClick(ctx, shortCode); err != nil {
+		if errors.Is(err, repository.ErrExhausted) {
+			return service.ErrURLExhausted
+		}
+
+		return fmt.Errorf("failed to record click on %s: %w", shortCode, err)
+	}
+
+	return nil
 }
 
 // DeleteURLsAsync асинхронно удаляет URL.
 // Запускает удаление в отдельной горутине и немедленно возвращает управление.
 // URL не удаляются физически, а помечаются как удаленные.
-func (s *urlShorterService) DeleteURLsAsync(shortURLs []string, userID string) {
-	go s.deleteURLsAsyncWorker(shortURLs, userID)
+// Значения контекста (например, идентификатор запроса) сохраняются в фоновой горутине,
+// но отмена исходного контекста на удаление не влияет.
+func (s *urlShorterService) DeleteURLsAsync(ctx context.Context, shortURLs []string, userID string) {
+	go s.deleteURLsAsyncWorker(context.WithoutCancel(ctx), shortURLs, userID)
 }
 
-func (s *urlShorterService) deleteURLsAsyncWorker(shortURLs []string, userID string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *urlShorterService) deleteURLsAsyncWorker(ctx context.Context, shortURLs []string, userID string) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
+	logger := requestid.Logger(ctx, s.logger)
+
 	const batchSize = 10
 	var wg sync.WaitGroup
 
@@ -173,7 +450,11 @@ func (s *urlShorterService) deleteURLsAsyncWorker(shortURLs []string, userID str
 		go func(urls []string) {
 			defer wg.Done()
 			if err := s.urlShorterRepo.DeleteBatch(ctx, urls, userID); err != nil {
-				s.logger.Error("Failed to delete URLs batch", zap.Error(err))
+				logger.Error("Failed to delete URLs batch",
+					zap.Error(err),
+					zap.String("user_id", userID),
+					zap.Strings("short_urls", urls),
+				)
 			}
 		}(batch)
 	}
@@ -181,6 +462,70 @@ func (s *urlShorterService) deleteURLsAsyncWorker(shortURLs []string, userID str
 	wg.Wait()
 }
 
+// newRecord создает запись новой ссылки. Пароль ссылки заменяется его хешем.
+func (s *urlShorterService) newRecord(url, userID string, attrs model.LinkAttributes) (model.URLRecord, error) {
+	passwordHash, err := linkpassword.Hash(attrs.Password)
+	if err != nil {
+		return model.URLRecord{}, err
+	}
+
+	attrs.Password = ""
+
+	return model.URLRecord{
+		OriginalURL:    url,
+		NormalizedURL:  s.normalize(url),
+		UserID:         userID,
+		PasswordHash:   passwordHash,
+		LinkAttributes: attrs,
+	}, nil
+}
+
+// normalize возвращает нормализованный URL. Если URL не удается разобрать,
+// для поиска дубликатов используется исходная строка.
+func (s *urlShorterService) normalize(url string) string {
+	normalized, err := s.normalizer.Normalize(url)
+	if err != nil {
+		return url
+	}
+
+	return normalized
+}
+
+// prepareAttributes проверяет свойства ссылки и принадлежность кампании пользователю.
+func (s *urlShorterService) prepareAttributes(
+	ctx context.Context,
+	userID string,
+	attrs model.LinkAttributes,
+) (model.LinkAttributes, error) {
+	attrs, err := normalizeAttributes(attrs)
+	if err != nil || attrs.CampaignID == "" {
+		return attrs, err
+	}
+
+	if s.campaignRepo == nil {
+		return attrs, errUnknownCampaign
+	}
+
+	if _, err := s.campaignRepo.Get(ctx, userID, attrs.CampaignID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return attrs, errUnknownCampaign
+		}
+
+		return attrs, fmt.Errorf("failed to look up campaign %s: %w", attrs.CampaignID, err)
+	}
+
+	return attrs, nil
+}
+
+// checkURL проверяет URL политикой, если она задана.
+func (s *urlShorterService) checkURL(ctx context.Context, url string) error {
+	if s.urlPolicy == nil {
+		return nil
+	}
+
+	return s.urlPolicy.Check(ctx, url)
+}
+
 func (s *urlShorterService) generateRandomShortCode() string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -33,7 +33,7 @@ func BenchmarkGenerate(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = service.Generate(ctx, "https://example.com/test", userID)
+		_, _ = service.Generate(ctx, "https://example.com/test", userID, model.LinkAttributes{})
 	}
 }
 
@@ -46,14 +46,14 @@ func BenchmarkGenerateBatch(b *testing.B) {
 	ctx := context.Background()
 	userID := "test-user"
 
-	urls := make([]string, 100)
+	links := make([]model.Request, 100)
 	for i := 0; i < 100; i++ {
-		urls[i] = "https://example.com/test"
+		links[i] = model.Request{URL: "https://example.com/test"}
 	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = service.GenerateBatch(ctx, urls, userID)
+		_, _ = service.GenerateBatch(ctx, links, userID)
 	}
 }
 
@@ -67,7 +67,7 @@ func BenchmarkGetOriginalURL(b *testing.B) {
 	userID := "test-user"
 
 	// Подготовим данные
-	shortCode, _ := service.Generate(ctx, "https://example.com/test", userID)
+	shortCode, _ := service.Generate(ctx, "https://example.com/test", userID, model.LinkAttributes{})
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -86,12 +86,12 @@ func BenchmarkGetUserURLs(b *testing.B) {
 
 	// Подготовим данные - добавим 1000 URL
 	for i := 0; i < 1000; i++ {
-		_, _ = service.Generate(ctx, "https://example.com/test", userID)
+		_, _ = service.Generate(ctx, "https://example.com/test", userID, model.LinkAttributes{})
 	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = service.GetUserURLs(ctx, userID)
+		_, _ = service.GetUserURLs(ctx, userID, model.URLFilter{})
 	}
 }
 
@@ -108,7 +108,7 @@ func BenchmarkGetOriginalURLWithManyRecords(b *testing.B) {
 	// Создаём много записей
 	var targetShortCode string
 	for i := 0; i < 10000; i++ {
-		shortCode, _ := service.Generate(ctx, "https://example.com/test", userID)
+		shortCode, _ := service.Generate(ctx, "https://example.com/test", userID, model.LinkAttributes{})
 		if i == 5000 {
 			targetShortCode = shortCode
 		}
@@ -135,7 +135,7 @@ func (m *mockStorage) AppendBatch(ctx context.Context, records []model.URLRecord
 	return nil
 }
 
-func (m *mockStorage) FindByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+func (m *mockStorage) FindByDedupKey(ctx context.Context, dedupKey string) (string, error) {
 	return "", nil
 }
 
@@ -143,10 +143,22 @@ func (m *mockStorage) FindByShortURL(ctx context.Context, shortURL string) (stri
 	return "https://example.com", nil
 }
 
-func (m *mockStorage) FindByUserID(ctx context.Context, userID string) ([]model.URLRecord, error) {
+func (m *mockStorage) FindRecord(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	return model.URLRecord{ShortURL: shortURL, OriginalURL: "https://example.com"}, nil
+}
+
+func (m *mockStorage) FindByUserID(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error) {
 	return nil, nil
 }
 
+func (m *mockStorage) UpdateAttributes(ctx context.Context, shortURL, userID string, attrs model.LinkAttributes) error {
+	return nil
+}
+
+func (m *mockStorage) IncrementClicks(ctx context.Context, shortURL string) error {
+	return nil
+}
+
 func (m *mockStorage) DeleteBatch(ctx context.Context, shortURLs []string, userID string) error {
 	return nil
 }
@@ -0,0 +1,327 @@
+package urlshorterservice
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository/campaignrepository"
+	"github.com/MarkelovSergey/url-shorter/internal/repository/urlshorterrepository"
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/MarkelovSergey/url-shorter/internal/service/linkpassword"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlnorm"
+	"github.com/MarkelovSergey/url-shorter/internal/service/urlpolicy"
+	"github.com/MarkelovSergey/url-shorter/internal/service/utmtemplate"
+	"github.com/MarkelovSergey/url-shorter/internal/storage/memorystorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestService(opts ...Option) URLShorterService {
+	repo := urlshorterrepository.New(memorystorage.New())
+
+	return New(repo, nil, zap.NewNop(), opts...)
+}
+
+func TestGenerateDeduplicatesNormalizedURLs(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(WithNormalizer(urlnorm.New(urlnorm.Options{StripTracking: true})))
+
+	code, err := s.Generate(ctx, "HTTP://Example.com:80/a/./b?utm_source=mail", "user1", model.LinkAttributes{})
+	require.NoError(t, err)
+
+	for _, variant := range []string{"http://example.com/a/b", "http://EXAMPLE.com/a/b?fbclid=x"} {
+		duplicate, err := s.Generate(ctx, variant, "user2", model.LinkAttributes{})
+		require.ErrorIs(t, err, service.ErrURLConflict, variant)
+		assert.Equal(t, code, duplicate, variant)
+	}
+
+	redirect, err := s.GetOriginalURL(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP://Example.com:80/a/./b?utm_source=mail", redirect.OriginalURL, "original string is kept for display")
+}
+
+func TestGenerateBatchKeepsOrderAndDeduplicates(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService()
+
+	existing, err := s.Generate(ctx, "https://example.com/existing", "user1", model.LinkAttributes{})
+	require.NoError(t, err)
+
+	links := []model.Request{
+		{URL: "https://example.com/1"},
+		{URL: "https://EXAMPLE.com:443/existing"},
+		{URL: "https://example.com/2"},
+		{URL: "https://Example.com/1"},
+	}
+
+	codes, err := s.GenerateBatch(ctx, links, "user1")
+	require.NoError(t, err)
+	require.Len(t, codes, len(links))
+
+	assert.Equal(t, existing, codes[1])
+	assert.Equal(t, codes[0], codes[3])
+	assert.NotEqual(t, codes[0], codes[2])
+
+	for i, want := range []string{"https://example.com/1", "https://example.com/2"} {
+		got, err := s.GetOriginalURL(ctx, codes[i*2])
+		require.NoError(t, err)
+		assert.Equal(t, want, got.OriginalURL)
+	}
+}
+
+func TestGenerateBatchPolicyViolation(t *testing.T) {
+	s := newTestService(WithURLPolicy(urlpolicy.New(urlpolicy.Options{})))
+
+	_, err := s.GenerateBatch(context.Background(),
+		[]model.Request{{URL: "https://example.com/"}, {URL: "http://127.0.0.1/"}}, "user1")
+
+	var validationErr *service.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "urls[1]", validationErr.Field)
+	assert.Equal(t, urlpolicy.CodePrivateAddress, validationErr.Code)
+
+	userURLs, err := s.GetUserURLs(context.Background(), "user1", model.URLFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, userURLs, "nothing is saved when a batch is rejected")
+}
+
+func TestGenerateValidatesAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrs    model.LinkAttributes
+		wantCode string
+	}{
+		{name: "title too long", attrs: model.LinkAttributes{Title: strings.Repeat("я", 256)}, wantCode: CodeTitleTooLong},
+		{name: "notes too long", attrs: model.LinkAttributes{Notes: strings.Repeat("a", 4097)}, wantCode: CodeNotesTooLong},
+		{name: "tag too long", attrs: model.LinkAttributes{Tags: []string{strings.Repeat("a", 65)}}, wantCode: CodeTagTooLong},
+		{name: "too many tags", attrs: model.LinkAttributes{Tags: strings.Split("a,b,c,d,e,f,g,h,i,j,k,l,m,n,o,p,q,r,s,t,u", ",")}, wantCode: CodeTooManyTags},
+		{name: "comma in tag", attrs: model.LinkAttributes{Tags: []string{"a,b"}}, wantCode: CodeInvalidTag},
+		{name: "unknown UTM placeholder", attrs: model.LinkAttributes{UTMParams: map[string]string{"utm_source": "{user}"}}, wantCode: utmtemplate.CodeUnknownPlaceholder},
+		{name: "unknown UTM override", attrs: model.LinkAttributes{UTMOverride: "merge"}, wantCode: utmtemplate.CodeInvalidOverride},
+		{name: "unsupported redirect type", attrs: model.LinkAttributes{RedirectType: 303}, wantCode: CodeInvalidRedirectType},
+		{name: "negative max clicks", attrs: model.LinkAttributes{MaxClicks: -1}, wantCode: CodeInvalidMaxClicks},
+		{name: "password too long", attrs: model.LinkAttributes{Password: strings.Repeat("p", 73)}, wantCode: linkpassword.CodePasswordTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestService().Generate(context.Background(), "https://example.com/", "user1", tt.attrs)
+
+			var validationErr *service.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.wantCode, validationErr.Code)
+		})
+	}
+}
+
+func TestGenerateStoresPasswordHash(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService()
+
+	code, err := s.Generate(ctx, "https://example.com/internal", "user1", model.LinkAttributes{Password: "s3cret"})
+	require.NoError(t, err)
+
+	record, err := s.GetUserURL(ctx, "user1", code)
+	require.NoError(t, err)
+	assert.Empty(t, record.Password, "plain password is not stored")
+	assert.True(t, linkpassword.Compare(record.PasswordHash, "s3cret"))
+
+	redirect, err := s.GetOriginalURL(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, record.PasswordHash, redirect.PasswordHash)
+
+	codes, err := s.GenerateBatch(ctx, []model.Request{
+		{URL: "https://example.com/open"},
+		{URL: "https://example.com/closed", LinkAttributes: model.LinkAttributes{Password: "other"}},
+	}, "user1")
+	require.NoError(t, err)
+
+	open, err := s.GetOriginalURL(ctx, codes[0])
+	require.NoError(t, err)
+	assert.Empty(t, open.PasswordHash)
+
+	closed, err := s.GetOriginalURL(ctx, codes[1])
+	require.NoError(t, err)
+	assert.True(t, linkpassword.Compare(closed.PasswordHash, "other"))
+}
+
+func TestRecordClickExhaustsLimitedLink(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService()
+
+	code, err := s.Generate(ctx, "https://example.com/invite", "user1", model.LinkAttributes{MaxClicks: 5})
+	require.NoError(t, err)
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+	)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := s.RecordClick(ctx, code); err == nil {
+				succeeded.Add(1)
+			} else {
+				assert.ErrorIs(t, err, service.ErrURLExhausted)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(5), succeeded.Load())
+
+	_, err = s.GetOriginalURL(ctx, code)
+	assert.ErrorIs(t, err, service.ErrURLExhausted)
+
+	record, err := s.GetUserURL(ctx, "user1", code)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), record.ClickCount)
+
+	more := int64(6)
+	_, err = s.UpdateUserURL(ctx, "user1", code, model.LinkUpdate{MaxClicks: &more})
+	require.NoError(t, err)
+
+	redirect, err := s.GetOriginalURL(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), redirect.MaxClicks)
+	require.NoError(t, s.RecordClick(ctx, code))
+	assert.ErrorIs(t, s.RecordClick(ctx, code), service.ErrURLExhausted)
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestGetOriginalURLActiveWindow(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start.Add(-time.Hour)}
+	s := newTestService(WithClock(clock))
+
+	activeFrom, expiresAt := start, start.Add(24*time.Hour)
+	code, err := s.Generate(ctx, "https://example.com/sale", "user1", model.LinkAttributes{
+		ActiveFrom: &activeFrom,
+		ExpiresAt:  &expiresAt,
+	})
+	require.NoError(t, err)
+
+	_, err = s.GetOriginalURL(ctx, code)
+	var notActive *service.NotActiveError
+	require.ErrorAs(t, err, &notActive)
+	assert.ErrorIs(t, err, service.ErrURLNotActive)
+	assert.True(t, start.Equal(notActive.ActiveFrom))
+
+	clock.now = start
+	redirect, err := s.GetOriginalURL(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/sale", redirect.OriginalURL)
+
+	clock.now = expiresAt
+	_, err = s.GetOriginalURL(ctx, code)
+	assert.ErrorIs(t, err, service.ErrURLExpired)
+
+	reopen := time.Time{}
+	_, err = s.UpdateUserURL(ctx, "user1", code, model.LinkUpdate{ExpiresAt: &reopen})
+	require.NoError(t, err)
+	_, err = s.GetOriginalURL(ctx, code)
+	assert.NoError(t, err)
+
+	before := start.Add(-time.Minute)
+	_, err = s.Generate(ctx, "https://example.com/other", "user1", model.LinkAttributes{
+		ActiveFrom: &activeFrom,
+		ExpiresAt:  &before,
+	})
+	var validationErr *service.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, CodeInvalidActiveWindow, validationErr.Code)
+}
+
+func TestGetOriginalURLMergesCampaignTemplate(t *testing.T) {
+	ctx := context.Background()
+	storage := memorystorage.New()
+	campaignRepo := campaignrepository.New(storage)
+	s := New(urlshorterrepository.New(storage), nil, zap.NewNop(), WithCampaigns(campaignRepo))
+
+	campaign, err := campaignRepo.Create(ctx, model.Campaign{
+		UserID:    "user1",
+		Name:      "Spring",
+		UTMParams: map[string]string{"utm_source": "newsletter", "utm_campaign": "{campaign}"},
+	})
+	require.NoError(t, err)
+
+	code, err := s.Generate(ctx, "https://example.com/?utm_source=site", "user1", model.LinkAttributes{
+		CampaignID:  campaign.ID,
+		UTMParams:   map[string]string{"utm_source": "mail", "utm_content": "{short_code}"},
+		UTMOverride: "Replace",
+	})
+	require.NoError(t, err)
+
+	redirect, err := s.GetOriginalURL(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, model.Redirect{
+		ShortURL:    code,
+		OriginalURL: "https://example.com/?utm_source=site",
+		UTMParams: map[string]string{
+			"utm_source":   "mail",
+			"utm_campaign": "{campaign}",
+			"utm_content":  "{short_code}",
+		},
+		UTMOverride:  utmtemplate.OverrideReplace,
+		CampaignName: "Spring",
+	}, redirect)
+
+	record, err := s.GetUserURL(ctx, "user1", code)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"utm_source": "mail", "utm_content": "{short_code}"}, record.UTMParams,
+		"campaign template is not copied into the link")
+}
+
+func TestUpdateUserURL(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService()
+
+	code, err := s.Generate(ctx, "https://example.com/", "user1", model.LinkAttributes{
+		Title: "  Docs ",
+		Tags:  []string{"Work", "docs", "work", " "},
+	})
+	require.NoError(t, err)
+
+	record, err := s.GetUserURL(ctx, "user1", code)
+	require.NoError(t, err)
+	assert.Equal(t, "Docs", record.Title)
+	assert.Equal(t, []string{"docs", "work"}, record.Tags)
+
+	notes := "shared with the team"
+	record, err = s.UpdateUserURL(ctx, "user1", code, model.LinkUpdate{Notes: &notes})
+	require.NoError(t, err)
+	assert.Equal(t, "Docs", record.Title, "fields not in the update are kept")
+	assert.Equal(t, notes, record.Notes)
+	assert.Equal(t, []string{"docs", "work"}, record.Tags)
+
+	passthrough := true
+	_, err = s.UpdateUserURL(ctx, "user1", code, model.LinkUpdate{Passthrough: &passthrough})
+	require.NoError(t, err)
+
+	redirect, err := s.GetOriginalURL(ctx, code)
+	require.NoError(t, err)
+	assert.True(t, redirect.Passthrough)
+
+	found, err := s.GetUserURLs(ctx, "user1", model.URLFilter{Tags: []string{" WORK "}})
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	_, err = s.UpdateUserURL(ctx, "user2", code, model.LinkUpdate{Notes: &notes})
+	assert.ErrorIs(t, err, service.ErrFindShortCode)
+}
@@ -0,0 +1,166 @@
+// Package utmtemplate добавляет параметры отслеживания (UTM) к URL назначения при переходе.
+// Шаблон задается у ссылки или кампании; сохраненный URL назначения не меняется.
+package utmtemplate
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+)
+
+// Правила разрешения конфликта с параметром, уже указанным в URL назначения.
+const (
+	// OverrideKeep - параметр URL назначения сохраняется, значение шаблона отбрасывается.
+	// Используется по умолчанию.
+	OverrideKeep = "keep"
+	// OverrideReplace - значение шаблона заменяет одноименные параметры URL назначения.
+	OverrideReplace = "replace"
+)
+
+// Подстановки в значениях шаблона.
+const (
+	PlaceholderShortCode = "{short_code}"
+	PlaceholderDate      = "{date}"
+	PlaceholderCampaign  = "{campaign}"
+)
+
+// Коды нарушений в шаблоне.
+const (
+	CodeTooManyParams      = "utm_too_many_params"
+	CodeInvalidParam       = "utm_invalid_param"
+	CodeUnknownPlaceholder = "utm_unknown_placeholder"
+	CodeInvalidOverride    = "utm_invalid_override"
+)
+
+// Ограничения шаблона.
+const (
+	maxParams      = 20
+	maxKeyLength   = 64
+	maxValueLength = 256
+)
+
+var (
+	keyPattern         = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+	placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+	knownPlaceholders  = []string{PlaceholderShortCode, PlaceholderDate, PlaceholderCampaign}
+)
+
+// Vars - значения подстановок для конкретного перехода.
+type Vars struct {
+	ShortCode string
+	Date      time.Time
+	Campaign  string
+}
+
+// Validate проверяет шаблон параметров. field - имя поля в ошибке валидации.
+func Validate(field string, params map[string]string) error {
+	if len(params) > maxParams {
+		return service.NewValidationError(field, CodeTooManyParams,
+			fmt.Sprintf("%s: more than %d parameters", field, maxParams))
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		if len(key) > maxKeyLength || !keyPattern.MatchString(key) {
+			return service.NewValidationError(field, CodeInvalidParam,
+				fmt.Sprintf("%s: parameter name %q is not allowed", field, key))
+		}
+
+		value := params[key]
+		if utf8.RuneCountInString(value) > maxValueLength {
+			return service.NewValidationError(field, CodeInvalidParam,
+				fmt.Sprintf("%s: value of %q is longer than %d characters", field, key, maxValueLength))
+		}
+
+		for _, placeholder := range placeholderPattern.FindAllString(value, -1) {
+			if !slices.Contains(knownPlaceholders, placeholder) {
+				return service.NewValidationError(field, CodeUnknownPlaceholder,
+					fmt.Sprintf("%s: unknown placeholder %s in %q", field, placeholder, key))
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateOverride проверяет правило разрешения конфликтов; пустое значение означает OverrideKeep.
+func ValidateOverride(field, override string) error {
+	switch override {
+	case "", OverrideKeep, OverrideReplace:
+		return nil
+	default:
+		return service.NewValidationError(field, CodeInvalidOverride,
+			fmt.Sprintf("%s must be %q or %q", field, OverrideKeep, OverrideReplace))
+	}
+}
+
+// Merge объединяет шаблоны: значения overlay заменяют одноименные значения base.
+func Merge(base, overlay map[string]string) map[string]string {
+	if len(base) == 0 {
+		return overlay
+	}
+	if len(overlay) == 0 {
+		return base
+	}
+
+	merged := maps.Clone(base)
+	maps.Copy(merged, overlay)
+
+	return merged
+}
+
+// Apply добавляет параметры шаблона к запросу destination.
+// Имеющиеся параметры сохраняются в исходном виде и порядке, параметры шаблона
+// добавляются в конце по алфавиту. При совпадении имен решает override.
+func Apply(destination string, params map[string]string, override string, vars Vars) (string, error) {
+	if len(params) == 0 {
+		return destination, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	present := make(map[string]bool)
+	if u.RawQuery != "" {
+		for _, part := range strings.Split(u.RawQuery, "&") {
+			key, _, _ := strings.Cut(part, "=")
+			if unescaped, err := url.QueryUnescape(key); err == nil {
+				key = unescaped
+			}
+
+			if _, ok := params[key]; ok && override == OverrideReplace {
+				continue
+			}
+
+			present[key] = true
+			parts = append(parts, part)
+		}
+	}
+
+	replacer := strings.NewReplacer(
+		PlaceholderShortCode, vars.ShortCode,
+		PlaceholderDate, vars.Date.UTC().Format(time.DateOnly),
+		PlaceholderCampaign, vars.Campaign,
+	)
+
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		if present[key] {
+			continue
+		}
+
+		parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(replacer.Replace(params[key])))
+	}
+
+	u.RawQuery = strings.Join(parts, "&")
+
+	return u.String(), nil
+}
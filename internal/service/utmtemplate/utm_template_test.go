@@ -0,0 +1,127 @@
+package utmtemplate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MarkelovSergey/url-shorter/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	vars := Vars{
+		ShortCode: "abc123",
+		Date:      time.Date(2025, 3, 1, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60)),
+		Campaign:  "Spring Sale",
+	}
+
+	tests := []struct {
+		name        string
+		destination string
+		params      map[string]string
+		override    string
+		want        string
+	}{
+		{
+			name:        "no template",
+			destination: "https://example.com/a?b=1",
+			want:        "https://example.com/a?b=1",
+		},
+		{
+			name:        "params are appended in alphabetical order",
+			destination: "https://example.com/a",
+			params:      map[string]string{"utm_source": "mail", "utm_medium": "email"},
+			want:        "https://example.com/a?utm_medium=email&utm_source=mail",
+		},
+		{
+			name:        "placeholders",
+			destination: "https://example.com/",
+			params: map[string]string{
+				"utm_campaign": "{campaign}",
+				"utm_content":  "{short_code}-{date}",
+			},
+			want: "https://example.com/?utm_campaign=Spring+Sale&utm_content=abc123-2025-03-02",
+		},
+		{
+			name:        "existing params are kept verbatim",
+			destination: "https://example.com/?b=%7E2&a=1&utm_source=site#top",
+			params:      map[string]string{"utm_source": "mail", "utm_medium": "email"},
+			want:        "https://example.com/?b=%7E2&a=1&utm_source=site&utm_medium=email#top",
+		},
+		{
+			name:        "keep is the default",
+			destination: "https://example.com/?utm_source=site",
+			params:      map[string]string{"utm_source": "mail"},
+			override:    OverrideKeep,
+			want:        "https://example.com/?utm_source=site",
+		},
+		{
+			name:        "replace drops every conflicting param",
+			destination: "https://example.com/?utm_source=a&x=1&utm_source=b",
+			params:      map[string]string{"utm_source": "mail"},
+			override:    OverrideReplace,
+			want:        "https://example.com/?x=1&utm_source=mail",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.destination, tt.params, tt.override, vars)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := range 21 {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		code   string
+	}{
+		{name: "valid", params: map[string]string{"utm_source": "mail", "utm_content": "{short_code}"}},
+		{name: "empty", params: nil},
+		{name: "too many", params: tooMany, code: CodeTooManyParams},
+		{name: "bad name", params: map[string]string{"utm source": "mail"}, code: CodeInvalidParam},
+		{name: "empty name", params: map[string]string{"": "mail"}, code: CodeInvalidParam},
+		{name: "long value", params: map[string]string{"utm_source": strings.Repeat("v", 257)}, code: CodeInvalidParam},
+		{name: "unknown placeholder", params: map[string]string{"utm_source": "{user}"}, code: CodeUnknownPlaceholder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate("utm_params", tt.params)
+			if tt.code == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			var validationErr *service.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.code, validationErr.Code)
+			assert.Equal(t, "utm_params", validationErr.Field)
+		})
+	}
+
+	assert.NoError(t, ValidateOverride("utm_override", ""))
+	assert.NoError(t, ValidateOverride("utm_override", OverrideReplace))
+	assert.Error(t, ValidateOverride("utm_override", "merge"))
+}
+
+func TestMerge(t *testing.T) {
+	campaign := map[string]string{"utm_source": "campaign", "utm_medium": "email"}
+	link := map[string]string{"utm_source": "link"}
+
+	assert.Equal(t, map[string]string{"utm_source": "link", "utm_medium": "email"}, Merge(campaign, link))
+	assert.Equal(t, map[string]string{"utm_source": "campaign", "utm_medium": "email"}, campaign)
+	assert.Equal(t, link, Merge(nil, link))
+	assert.Equal(t, campaign, Merge(campaign, nil))
+}
@@ -0,0 +1,145 @@
+package filestorage
+
+import (
+	"context"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/storage"
+)
+
+// CreateCampaign сохраняет кампанию.
+func (fs *FileStorage) CreateCampaign(ctx context.Context, campaign model.Campaign) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	campaigns, err := fs.loadCampaigns()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(fs.campaignsFilePath, append(campaigns, campaign))
+}
+
+// FindCampaign находит кампанию по идентификатору.
+func (fs *FileStorage) FindCampaign(ctx context.Context, id string) (model.Campaign, error) {
+	campaigns, err := fs.loadCampaigns()
+	if err != nil {
+		return model.Campaign{}, err
+	}
+
+	for _, campaign := range campaigns {
+		if campaign.ID == id {
+			return campaign, nil
+		}
+	}
+
+	return model.Campaign{}, repository.ErrNotFound
+}
+
+// FindCampaignsByUserID находит кампании пользователя в порядке создания.
+func (fs *FileStorage) FindCampaignsByUserID(ctx context.Context, userID string) ([]model.Campaign, error) {
+	campaigns, err := fs.loadCampaigns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.Campaign, 0)
+	for _, campaign := range campaigns {
+		if campaign.UserID == userID {
+			result = append(result, campaign)
+		}
+	}
+
+	return result, nil
+}
+
+// UpdateCampaign заменяет сохраненную кампанию.
+func (fs *FileStorage) UpdateCampaign(ctx context.Context, campaign model.Campaign) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	campaigns, err := fs.loadCampaigns()
+	if err != nil {
+		return err
+	}
+
+	for i := range campaigns {
+		if campaigns[i].ID == campaign.ID {
+			campaigns[i] = campaign
+
+			return writeJSON(fs.campaignsFilePath, campaigns)
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
+// DeleteCampaign удаляет кампанию и исключает из нее ссылки.
+func (fs *FileStorage) DeleteCampaign(ctx context.Context, id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	campaigns, err := fs.loadCampaigns()
+	if err != nil {
+		return err
+	}
+
+	kept := campaigns[:0]
+	for _, campaign := range campaigns {
+		if campaign.ID != id {
+			kept = append(kept, campaign)
+		}
+	}
+
+	if len(kept) == len(campaigns) {
+		return repository.ErrNotFound
+	}
+
+	records, err := fs.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for i := range records {
+		if records[i].CampaignID == id {
+			records[i].CampaignID = ""
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := fs.save(records); err != nil {
+			return err
+		}
+	}
+
+	return writeJSON(fs.campaignsFilePath, kept)
+}
+
+// CampaignStats считает ссылки и переходы кампании.
+func (fs *FileStorage) CampaignStats(ctx context.Context, id string) (model.CampaignStats, error) {
+	records, err := fs.Load(ctx)
+	if err != nil {
+		return model.CampaignStats{}, err
+	}
+
+	var stats model.CampaignStats
+	for _, record := range records {
+		if record.CampaignID == id {
+			storage.AddCampaignStats(&stats, record)
+		}
+	}
+
+	return stats, nil
+}
+
+func (fs *FileStorage) loadCampaigns() ([]model.Campaign, error) {
+	campaigns := []model.Campaign{}
+	if err := readJSON(fs.campaignsFilePath, &campaigns); err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
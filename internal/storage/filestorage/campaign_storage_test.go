@@ -0,0 +1,79 @@
+package filestorage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStorageCampaigns(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	storage := New(path)
+
+	campaign := model.Campaign{ID: "c1", UserID: "user1", Name: "Spring"}
+	require.NoError(t, storage.CreateCampaign(ctx, campaign))
+	require.NoError(t, storage.CreateCampaign(ctx, model.Campaign{ID: "c2", UserID: "user2", Name: "Other"}))
+
+	require.NoError(t, storage.AppendBatch(ctx, []model.URLRecord{
+		{UUID: "1", ShortURL: "a", OriginalURL: "https://example.com/a", UserID: "user1",
+			LinkAttributes: model.LinkAttributes{CampaignID: "c1"}},
+		{UUID: "2", ShortURL: "b", OriginalURL: "https://example.com/b", UserID: "user1",
+			LinkAttributes: model.LinkAttributes{CampaignID: "c1"}},
+		{UUID: "3", ShortURL: "c", OriginalURL: "https://example.com/c", UserID: "user1"},
+	}))
+	require.NoError(t, storage.IncrementClicks(ctx, "a"))
+	require.NoError(t, storage.IncrementClicks(ctx, "a"))
+	require.NoError(t, storage.IncrementClicks(ctx, "c"))
+	require.NoError(t, storage.DeleteBatch(ctx, []string{"b"}, "user1"))
+
+	t.Run("campaigns are kept in a separate file", func(t *testing.T) {
+		reopened := New(path)
+
+		found, err := reopened.FindCampaign(ctx, "c1")
+		require.NoError(t, err)
+		assert.Equal(t, campaign, found)
+
+		campaigns, err := reopened.FindCampaignsByUserID(ctx, "user1")
+		require.NoError(t, err)
+		assert.Equal(t, []model.Campaign{campaign}, campaigns)
+
+		assert.FileExists(t, filepath.Join(filepath.Dir(path), "storage.campaigns.json"))
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		stats, err := storage.CampaignStats(ctx, "c1")
+		require.NoError(t, err)
+		assert.Equal(t, model.CampaignStats{Links: 2, ActiveLinks: 1, DeletedLinks: 1, Clicks: 2}, stats)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		campaign.Description = "Seasonal sale"
+		require.NoError(t, storage.UpdateCampaign(ctx, campaign))
+
+		found, err := storage.FindCampaign(ctx, "c1")
+		require.NoError(t, err)
+		assert.Equal(t, "Seasonal sale", found.Description)
+
+		assert.ErrorIs(t, storage.UpdateCampaign(ctx, model.Campaign{ID: "missing"}), repository.ErrNotFound)
+	})
+
+	t.Run("delete unassigns links", func(t *testing.T) {
+		require.NoError(t, storage.DeleteCampaign(ctx, "c1"))
+
+		_, err := storage.FindCampaign(ctx, "c1")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		record, err := storage.FindRecord(ctx, "a")
+		require.NoError(t, err)
+		assert.Empty(t, record.CampaignID)
+		assert.Equal(t, int64(2), record.ClickCount)
+
+		assert.ErrorIs(t, storage.DeleteCampaign(ctx, "c1"), repository.ErrNotFound)
+	})
+}
@@ -6,24 +6,40 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/model"
 	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/storage"
 )
 
 // FileStorage представляет файловое хранилище.
+// Кампании хранятся в отдельном файле рядом с файлом ссылок,
+// поэтому формат файла ссылок не меняется.
+// Изменения файлов выполняются последовательно: каждое читает файл,
+// меняет записи и сохраняет их под общей блокировкой.
+// Файл заменяется целиком, поэтому чтение выполняется без блокировки.
 type FileStorage struct {
-	filePath string
+	filePath          string
+	campaignsFilePath string
+	mu                *sync.Mutex
 }
 
 // New создает новое файловое хранилище.
 func New(filePath string) *FileStorage {
+	ext := filepath.Ext(filePath)
+
 	return &FileStorage{
-		filePath: filePath,
+		filePath:          filePath,
+		campaignsFilePath: strings.TrimSuffix(filePath, ext) + ".campaigns" + ext,
+		mu:                &sync.Mutex{},
 	}
 }
 
 // Load загружает все записи из файла.
+// Записи, сохраненные до появления метаданных, загружаются с нулевым временем создания.
 func (fs *FileStorage) Load(ctx context.Context) ([]model.URLRecord, error) {
 	data, err := os.ReadFile(fs.filePath)
 	if err != nil {
@@ -48,6 +64,9 @@ func (fs *FileStorage) Load(ctx context.Context) ([]model.URLRecord, error) {
 
 // Append добавляет запись в файл.
 func (fs *FileStorage) Append(ctx context.Context, record model.URLRecord) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
 	records, err := fs.Load(ctx)
 	if err != nil {
 		return err
@@ -64,6 +83,9 @@ func (fs *FileStorage) AppendBatch(ctx context.Context, newRecords []model.URLRe
 		return nil
 	}
 
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
 	records, err := fs.Load(ctx)
 	if err != nil {
 		return err
@@ -74,15 +96,15 @@ func (fs *FileStorage) AppendBatch(ctx context.Context, newRecords []model.URLRe
 	return fs.save(records)
 }
 
-// FindByOriginalURL находит короткий URL по оригинальному.
-func (fs *FileStorage) FindByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+// FindByDedupKey находит короткий URL по ключу поиска дубликатов.
+func (fs *FileStorage) FindByDedupKey(ctx context.Context, dedupKey string) (string, error) {
 	records, err := fs.Load(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	for _, record := range records {
-		if record.OriginalURL == originalURL {
+		if storage.DedupKey(record) == dedupKey {
 			return record.ShortURL, nil
 		}
 	}
@@ -109,8 +131,24 @@ func (fs *FileStorage) FindByShortURL(ctx context.Context, shortURL string) (str
 	return "", nil
 }
 
-// FindByUserID находит все URL пользователя.
-func (fs *FileStorage) FindByUserID(ctx context.Context, userID string) ([]model.URLRecord, error) {
+// FindRecord находит запись по короткому URL.
+func (fs *FileStorage) FindRecord(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	records, err := fs.Load(ctx)
+	if err != nil {
+		return model.URLRecord{}, err
+	}
+
+	for _, record := range records {
+		if record.ShortURL == shortURL {
+			return record, nil
+		}
+	}
+
+	return model.URLRecord{}, repository.ErrNotFound
+}
+
+// FindByUserID находит URL пользователя, удовлетворяющие фильтру.
+func (fs *FileStorage) FindByUserID(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error) {
 	records, err := fs.Load(ctx)
 	if err != nil {
 		return nil, err
@@ -118,7 +156,7 @@ func (fs *FileStorage) FindByUserID(ctx context.Context, userID string) ([]model
 
 	result := make([]model.URLRecord, 0)
 	for _, record := range records {
-		if record.UserID == userID {
+		if record.UserID == userID && storage.MatchFilter(record, filter) {
 			result = append(result, record)
 		}
 	}
@@ -126,8 +164,68 @@ func (fs *FileStorage) FindByUserID(ctx context.Context, userID string) ([]model
 	return result, nil
 }
 
+// UpdateAttributes заменяет свойства ссылки.
+func (fs *FileStorage) UpdateAttributes(ctx context.Context, shortURL, userID string, attrs model.LinkAttributes) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	records, err := fs.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range records {
+		if records[i].ShortURL != shortURL {
+			continue
+		}
+
+		if records[i].UserID != userID {
+			return repository.ErrNotFound
+		}
+
+		if records[i].IsDeleted {
+			return repository.ErrDeleted
+		}
+
+		records[i].LinkAttributes = attrs
+		records[i].UpdatedAt = time.Now().UTC()
+
+		return fs.save(records)
+	}
+
+	return repository.ErrNotFound
+}
+
+// IncrementClicks увеличивает счетчик переходов по ссылке, если лимит переходов не исчерпан.
+func (fs *FileStorage) IncrementClicks(ctx context.Context, shortURL string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	records, err := fs.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range records {
+		if records[i].ShortURL == shortURL {
+			if records[i].MaxClicks > 0 && records[i].ClickCount >= records[i].MaxClicks {
+				return repository.ErrExhausted
+			}
+
+			records[i].ClickCount++
+
+			return fs.save(records)
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
 // DeleteBatch удаляет несколько URL пакетно.
 func (fs *FileStorage) DeleteBatch(ctx context.Context, shortURLs []string, userID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
 	records, err := fs.Load(ctx)
 	if err != nil {
 		return err
@@ -138,9 +236,11 @@ func (fs *FileStorage) DeleteBatch(ctx context.Context, shortURLs []string, user
 		urlsMap[url] = true
 	}
 
+	now := time.Now().UTC()
 	for i := range records {
-		if records[i].UserID == userID && urlsMap[records[i].ShortURL] {
+		if records[i].UserID == userID && urlsMap[records[i].ShortURL] && !records[i].IsDeleted {
 			records[i].IsDeleted = true
+			records[i].UpdatedAt = now
 		}
 	}
 
@@ -148,15 +248,64 @@ func (fs *FileStorage) DeleteBatch(ctx context.Context, shortURLs []string, user
 }
 
 func (fs *FileStorage) save(records []model.URLRecord) error {
-	dir := filepath.Dir(fs.filePath)
+	return writeJSON(fs.filePath, records)
+}
+
+// readJSON читает значение из файла. Отсутствующий или пустой файл оставляет v без изменений.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON записывает значение в файл, создавая каталог при необходимости.
+// Данные пишутся во временный файл, который затем заменяет исходный,
+// поэтому читатели без блокировки видят либо старое, либо новое содержимое целиком.
+func writeJSON(path string, v any) (err error) {
+	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(records, "", "  ")
+	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(fs.filePath, data, 0644)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(0644); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
 }
@@ -4,9 +4,12 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -53,6 +56,43 @@ func TestFileStorageLoad(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "load record with metadata",
+			setupFile: func(path string) error {
+				dir := filepath.Dir(path)
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return err
+				}
+				data := []byte(`[
+  {
+    "uuid": "uuid-1",
+    "short_url": "abc123",
+    "original_url": "https://practicum.yandex.ru",
+    "is_deleted": true,
+    "created_at": "2025-01-02T03:04:05Z",
+    "updated_at": "2025-02-03T04:05:06Z",
+    "click_count": 7,
+    "expires_at": "2026-01-01T00:00:00Z"
+  }
+]`)
+				return os.WriteFile(path, data, 0644)
+			},
+			expectedRecords: []model.URLRecord{
+				{
+					UUID:        "uuid-1",
+					ShortURL:    "abc123",
+					OriginalURL: "https://practicum.yandex.ru",
+					IsDeleted:   true,
+					CreatedAt:   time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+					UpdatedAt:   time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC),
+					ClickCount:  7,
+					LinkAttributes: model.LinkAttributes{
+						ExpiresAt: func() *time.Time { t := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC); return &t }(),
+					},
+				},
+			},
+			expectedError: false,
+		},
 		{
 			name: "load from non-existent file returns empty slice",
 			setupFile: func(path string) error {
@@ -383,3 +423,76 @@ func TestFileStorageAppendBatch(t *testing.T) {
 		})
 	}
 }
+
+func TestFileStorageIncrementClicksRespectsLimit(t *testing.T) {
+	ctx := context.Background()
+	storage := New(filepath.Join(t.TempDir(), "test-storage.json"))
+
+	require.NoError(t, storage.AppendBatch(ctx, []model.URLRecord{
+		{UUID: "1", ShortURL: "once", OriginalURL: "https://example.com/1", LinkAttributes: model.LinkAttributes{MaxClicks: 3}},
+		{UUID: "2", ShortURL: "open", OriginalURL: "https://example.com/2"},
+	}))
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := storage.IncrementClicks(ctx, "once")
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+
+				return
+			}
+
+			assert.ErrorIs(t, err, repository.ErrExhausted)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, succeeded)
+
+	record, err := storage.FindRecord(ctx, "once")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), record.ClickCount)
+
+	for range 5 {
+		require.NoError(t, storage.IncrementClicks(ctx, "open"))
+	}
+	assert.ErrorIs(t, storage.IncrementClicks(ctx, "missing"), repository.ErrNotFound)
+}
+
+func TestFileStorageReadsDuringWrites(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	storage := New(filepath.Join(dir, "test-storage.json"))
+
+	require.NoError(t, storage.Append(ctx, model.URLRecord{UUID: "1", ShortURL: "abc", OriginalURL: "https://example.com"}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for range 50 {
+			assert.NoError(t, storage.IncrementClicks(ctx, "abc"))
+		}
+	}()
+
+	for range 200 {
+		_, err := storage.FindRecord(ctx, "abc")
+		require.NoError(t, err, "readers never see a partially written file")
+	}
+	wg.Wait()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are not left behind")
+}
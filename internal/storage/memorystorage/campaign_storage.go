@@ -0,0 +1,108 @@
+package memorystorage
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/storage"
+)
+
+// CreateCampaign сохраняет кампанию.
+func (ms *MemoryStorage) CreateCampaign(ctx context.Context, campaign model.Campaign) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.campaigns[campaign.ID] = campaign
+
+	return nil
+}
+
+// FindCampaign находит кампанию по идентификатору.
+func (ms *MemoryStorage) FindCampaign(ctx context.Context, id string) (model.Campaign, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	campaign, ok := ms.campaigns[id]
+	if !ok {
+		return model.Campaign{}, repository.ErrNotFound
+	}
+
+	return campaign, nil
+}
+
+// FindCampaignsByUserID находит кампании пользователя в порядке создания.
+func (ms *MemoryStorage) FindCampaignsByUserID(ctx context.Context, userID string) ([]model.Campaign, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	result := make([]model.Campaign, 0)
+	for _, campaign := range ms.campaigns {
+		if campaign.UserID == userID {
+			result = append(result, campaign)
+		}
+	}
+
+	slices.SortFunc(result, compareCampaigns)
+
+	return result, nil
+}
+
+// UpdateCampaign заменяет сохраненную кампанию.
+func (ms *MemoryStorage) UpdateCampaign(ctx context.Context, campaign model.Campaign) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.campaigns[campaign.ID]; !ok {
+		return repository.ErrNotFound
+	}
+
+	ms.campaigns[campaign.ID] = campaign
+
+	return nil
+}
+
+// DeleteCampaign удаляет кампанию и исключает из нее ссылки.
+func (ms *MemoryStorage) DeleteCampaign(ctx context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.campaigns[id]; !ok {
+		return repository.ErrNotFound
+	}
+
+	delete(ms.campaigns, id)
+
+	for i := range ms.records {
+		if ms.records[i].CampaignID == id {
+			ms.records[i].CampaignID = ""
+		}
+	}
+
+	return nil
+}
+
+// CampaignStats считает ссылки и переходы кампании.
+func (ms *MemoryStorage) CampaignStats(ctx context.Context, id string) (model.CampaignStats, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var stats model.CampaignStats
+	for idx, record := range ms.records {
+		if record.CampaignID == id {
+			storage.AddCampaignStats(&stats, ms.recordAt(idx))
+		}
+	}
+
+	return stats, nil
+}
+
+func compareCampaigns(a, b model.Campaign) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a.ID, b.ID)
+}
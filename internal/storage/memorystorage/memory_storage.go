@@ -3,27 +3,39 @@ package memorystorage
 
 import (
 	"context"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/model"
 	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/storage"
 )
 
 // MemoryStorage представляет хранилище в памяти.
+// Для поиска по словам поддерживается инвертированный индекс: слово - номера записей.
+// Счетчики переходов хранятся отдельно от записей и меняются сравнением с обменом
+// под блокировкой на чтение, поэтому переходы не ждут друг друга.
 type MemoryStorage struct {
-	mu               *sync.RWMutex
-	records          []model.URLRecord
-	shortURLIndex    map[string]int
-	originalURLIndex map[string]int
+	mu            *sync.RWMutex
+	records       []model.URLRecord
+	clicks        []*atomic.Int64
+	shortURLIndex map[string]int
+	dedupIndex    map[string]int
+	tokenIndex    map[string]map[int]struct{}
+	campaigns     map[string]model.Campaign
 }
 
 // New создает новое хранилище в памяти.
 func New() *MemoryStorage {
 	return &MemoryStorage{
-		mu:               &sync.RWMutex{},
-		records:          make([]model.URLRecord, 0),
-		shortURLIndex:    make(map[string]int),
-		originalURLIndex: make(map[string]int),
+		mu:            &sync.RWMutex{},
+		records:       make([]model.URLRecord, 0),
+		shortURLIndex: make(map[string]int),
+		dedupIndex:    make(map[string]int),
+		tokenIndex:    make(map[string]map[int]struct{}),
+		campaigns:     make(map[string]model.Campaign),
 	}
 }
 
@@ -33,7 +45,9 @@ func (ms *MemoryStorage) Load(ctx context.Context) ([]model.URLRecord, error) {
 	defer ms.mu.RUnlock()
 
 	result := make([]model.URLRecord, len(ms.records))
-	copy(result, ms.records)
+	for idx := range ms.records {
+		result[idx] = ms.recordAt(idx)
+	}
 
 	return result, nil
 }
@@ -43,10 +57,13 @@ func (ms *MemoryStorage) Append(ctx context.Context, record model.URLRecord) err
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	idx := len(ms.records)
-	ms.records = append(ms.records, record)
-	ms.shortURLIndex[record.ShortURL] = idx
-	ms.originalURLIndex[record.OriginalURL] = idx
+	if key := storage.DedupKey(record); key != "" {
+		if _, exists := ms.dedupIndex[key]; exists {
+			return repository.ErrURLAlreadyExists
+		}
+	}
+
+	ms.index(record)
 
 	return nil
 }
@@ -60,24 +77,99 @@ func (ms *MemoryStorage) AppendBatch(ctx context.Context, records []model.URLRec
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	startIdx := len(ms.records)
-	ms.records = append(ms.records, records...)
-
-	for i, record := range records {
-		idx := startIdx + i
-		ms.shortURLIndex[record.ShortURL] = idx
-		ms.originalURLIndex[record.OriginalURL] = idx
+	for _, record := range records {
+		ms.index(record)
 	}
 
 	return nil
 }
 
-// FindByOriginalURL находит короткий URL по оригинальному.
-func (ms *MemoryStorage) FindByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+// index добавляет запись и обновляет индексы. Вызывается под блокировкой.
+func (ms *MemoryStorage) index(record model.URLRecord) {
+	idx := len(ms.records)
+	clicks := &atomic.Int64{}
+	clicks.Store(record.ClickCount)
+	ms.records = append(ms.records, record)
+	ms.clicks = append(ms.clicks, clicks)
+	ms.shortURLIndex[record.ShortURL] = idx
+
+	if key := storage.DedupKey(record); key != "" {
+		ms.dedupIndex[key] = idx
+	}
+
+	ms.indexTokens(idx, record)
+}
+
+// recordAt возвращает запись с текущим счетчиком переходов. Вызывается под блокировкой.
+func (ms *MemoryStorage) recordAt(idx int) model.URLRecord {
+	record := ms.records[idx]
+	record.ClickCount = ms.clicks[idx].Load()
+
+	return record
+}
+
+// indexTokens добавляет слова записи в инвертированный индекс. Вызывается под блокировкой.
+func (ms *MemoryStorage) indexTokens(idx int, record model.URLRecord) {
+	for _, token := range storage.RecordTokens(record) {
+		postings, ok := ms.tokenIndex[token]
+		if !ok {
+			postings = make(map[int]struct{})
+			ms.tokenIndex[token] = postings
+		}
+		postings[idx] = struct{}{}
+	}
+}
+
+// unindexTokens удаляет слова записи из инвертированного индекса. Вызывается под блокировкой.
+func (ms *MemoryStorage) unindexTokens(idx int, record model.URLRecord) {
+	for _, token := range storage.RecordTokens(record) {
+		delete(ms.tokenIndex[token], idx)
+		if len(ms.tokenIndex[token]) == 0 {
+			delete(ms.tokenIndex, token)
+		}
+	}
+}
+
+// searchTokens возвращает номера записей, содержащих все слова, по возрастанию.
+// Вызывается под блокировкой.
+func (ms *MemoryStorage) searchTokens(words []string) []int {
+	postings := make([]map[int]struct{}, 0, len(words))
+	for _, word := range words {
+		found, ok := ms.tokenIndex[word]
+		if !ok {
+			return nil
+		}
+		postings = append(postings, found)
+	}
+
+	slices.SortFunc(postings, func(a, b map[int]struct{}) int { return len(a) - len(b) })
+
+	result := make([]int, 0, len(postings[0]))
+	for idx := range postings[0] {
+		matched := true
+		for _, other := range postings[1:] {
+			if _, ok := other[idx]; !ok {
+				matched = false
+
+				break
+			}
+		}
+		if matched {
+			result = append(result, idx)
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+// FindByDedupKey находит короткий URL по ключу поиска дубликатов.
+func (ms *MemoryStorage) FindByDedupKey(ctx context.Context, dedupKey string) (string, error) {
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
 
-	if idx, ok := ms.originalURLIndex[originalURL]; ok {
+	if idx, ok := ms.dedupIndex[dedupKey]; ok {
 		return ms.records[idx].ShortURL, nil
 	}
 
@@ -101,11 +193,38 @@ func (ms *MemoryStorage) FindByShortURL(ctx context.Context, shortURL string) (s
 	return "", nil
 }
 
-// FindByUserID находит все URL пользователя.
-func (ms *MemoryStorage) FindByUserID(ctx context.Context, userID string) ([]model.URLRecord, error) {
+// FindRecord находит запись по короткому URL.
+func (ms *MemoryStorage) FindRecord(ctx context.Context, shortURL string) (model.URLRecord, error) {
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
 
+	if idx, ok := ms.shortURLIndex[shortURL]; ok {
+		return ms.recordAt(idx), nil
+	}
+
+	return model.URLRecord{}, repository.ErrNotFound
+}
+
+// FindByUserID находит URL пользователя, удовлетворяющие фильтру.
+// Слова запроса ищутся по инвертированному индексу, теги проверяются у найденных записей.
+func (ms *MemoryStorage) FindByUserID(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if words := storage.SearchTokens(filter.Query); len(words) > 0 {
+		filter.Query = ""
+
+		result := make([]model.URLRecord, 0)
+		for _, idx := range ms.searchTokens(words) {
+			record := ms.records[idx]
+			if record.UserID == userID && storage.MatchFilter(record, filter) {
+				result = append(result, ms.recordAt(idx))
+			}
+		}
+
+		return result, nil
+	}
+
 	count := 0
 	for _, record := range ms.records {
 		if record.UserID == userID {
@@ -114,24 +233,77 @@ func (ms *MemoryStorage) FindByUserID(ctx context.Context, userID string) ([]mod
 	}
 
 	result := make([]model.URLRecord, 0, count)
-	for _, record := range ms.records {
-		if record.UserID == userID {
-			result = append(result, record)
+	for idx, record := range ms.records {
+		if record.UserID == userID && storage.MatchFilter(record, filter) {
+			result = append(result, ms.recordAt(idx))
 		}
 	}
 
 	return result, nil
 }
 
+// UpdateAttributes заменяет свойства ссылки и обновляет индекс слов.
+func (ms *MemoryStorage) UpdateAttributes(ctx context.Context, shortURL, userID string, attrs model.LinkAttributes) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	idx, ok := ms.shortURLIndex[shortURL]
+	if !ok || ms.records[idx].UserID != userID {
+		return repository.ErrNotFound
+	}
+
+	record := ms.records[idx]
+	if record.IsDeleted {
+		return repository.ErrDeleted
+	}
+
+	ms.unindexTokens(idx, record)
+
+	record.LinkAttributes = attrs
+	record.UpdatedAt = time.Now().UTC()
+	ms.records[idx] = record
+
+	ms.indexTokens(idx, record)
+
+	return nil
+}
+
+// IncrementClicks увеличивает счетчик переходов по ссылке сравнением с обменом:
+// значение увеличивается, только если с момента проверки лимита его никто не изменил.
+func (ms *MemoryStorage) IncrementClicks(ctx context.Context, shortURL string) error {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	idx, ok := ms.shortURLIndex[shortURL]
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	maxClicks := ms.records[idx].MaxClicks
+	clicks := ms.clicks[idx]
+	for {
+		current := clicks.Load()
+		if maxClicks > 0 && current >= maxClicks {
+			return repository.ErrExhausted
+		}
+
+		if clicks.CompareAndSwap(current, current+1) {
+			return nil
+		}
+	}
+}
+
 // DeleteBatch удаляет несколько URL пакетно.
 func (ms *MemoryStorage) DeleteBatch(ctx context.Context, shortURLs []string, userID string) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
+	now := time.Now().UTC()
 	for _, url := range shortURLs {
 		if idx, ok := ms.shortURLIndex[url]; ok {
-			if ms.records[idx].UserID == userID {
+			if ms.records[idx].UserID == userID && !ms.records[idx].IsDeleted {
 				ms.records[idx].IsDeleted = true
+				ms.records[idx].UpdatedAt = now
 			}
 		}
 	}
@@ -46,7 +46,7 @@ func BenchmarkMemoryStorageFindByShortURL(b *testing.B) {
 	}
 }
 
-func BenchmarkMemoryStorageFindByOriginalURL(b *testing.B) {
+func BenchmarkMemoryStorageFindByDedupKey(b *testing.B) {
 	benchmarks := []struct {
 		name  string
 		count int
@@ -78,7 +78,7 @@ func BenchmarkMemoryStorageFindByOriginalURL(b *testing.B) {
 			b.ResetTimer()
 			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
-				_, _ = storage.FindByOriginalURL(ctx, targetOriginalURL)
+				_, _ = storage.FindByDedupKey(ctx, targetOriginalURL)
 			}
 		})
 	}
@@ -164,7 +164,7 @@ func BenchmarkMemoryStorageFindByUserID(b *testing.B) {
 			b.ResetTimer()
 			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
-				_, _ = storage.FindByUserID(ctx, "user1")
+				_, _ = storage.FindByUserID(ctx, "user1", model.URLFilter{})
 			}
 		})
 	}
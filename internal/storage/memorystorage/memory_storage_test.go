@@ -0,0 +1,112 @@
+package memorystorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func shortURLs(records []model.URLRecord) []string {
+	result := make([]string, 0, len(records))
+	for _, record := range records {
+		result = append(result, record.ShortURL)
+	}
+
+	return result
+}
+
+func TestMemoryStorageSearch(t *testing.T) {
+	ctx := context.Background()
+	ms := New()
+
+	require.NoError(t, ms.Append(ctx, model.URLRecord{
+		UUID: "1", ShortURL: "docs", OriginalURL: "https://example.com/docs", UserID: "u1",
+		LinkAttributes: model.LinkAttributes{Title: "Go docs", Notes: "Weekly reading", Tags: []string{"work"}},
+	}))
+	require.NoError(t, ms.AppendBatch(ctx, []model.URLRecord{
+		{
+			UUID: "2", ShortURL: "blog", OriginalURL: "https://example.com/blog", UserID: "u1",
+			LinkAttributes: model.LinkAttributes{Title: "Go blog", Notes: "weekly"},
+		},
+		{
+			UUID: "3", ShortURL: "news", OriginalURL: "https://example.com/news", UserID: "u1",
+			LinkAttributes: model.LinkAttributes{Title: "Daily news"},
+		},
+		{
+			UUID: "4", ShortURL: "other", OriginalURL: "https://example.com/other", UserID: "u2",
+			LinkAttributes: model.LinkAttributes{Title: "Go docs"},
+		},
+	}))
+
+	tests := []struct {
+		name   string
+		filter model.URLFilter
+		want   []string
+	}{
+		{
+			name:   "single word",
+			filter: model.URLFilter{Query: "go"},
+			want:   []string{"docs", "blog"},
+		},
+		{
+			name:   "words from title and notes intersect",
+			filter: model.URLFilter{Query: "go weekly"},
+			want:   []string{"docs", "blog"},
+		},
+		{
+			name:   "all words must match",
+			filter: model.URLFilter{Query: "Go READING"},
+			want:   []string{"docs"},
+		},
+		{
+			name:   "unknown word",
+			filter: model.URLFilter{Query: "go rust"},
+			want:   []string{},
+		},
+		{
+			name:   "query with tag",
+			filter: model.URLFilter{Query: "go", Tags: []string{"work"}},
+			want:   []string{"docs"},
+		},
+		{
+			name:   "punctuation only lists all links",
+			filter: model.URLFilter{Query: "--"},
+			want:   []string{"docs", "blog", "news"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := ms.FindByUserID(ctx, "u1", tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, shortURLs(records))
+		})
+	}
+}
+
+func TestMemoryStorageSearchAfterUpdate(t *testing.T) {
+	ctx := context.Background()
+	ms := New()
+
+	require.NoError(t, ms.Append(ctx, model.URLRecord{
+		UUID: "1", ShortURL: "abc", OriginalURL: "https://example.com", UserID: "u1",
+		LinkAttributes: model.LinkAttributes{Title: "Old title", Notes: "draft"},
+	}))
+
+	require.NoError(t, ms.UpdateAttributes(ctx, "abc", "u1", model.LinkAttributes{Title: "New title"}))
+
+	records, err := ms.FindByUserID(ctx, "u1", model.URLFilter{Query: "old"})
+	require.NoError(t, err)
+	assert.Empty(t, records, "old words are removed from the index")
+
+	records, err = ms.FindByUserID(ctx, "u1", model.URLFilter{Query: "new title"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc"}, shortURLs(records))
+
+	assert.NotContains(t, ms.tokenIndex, "old")
+	assert.NotContains(t, ms.tokenIndex, "draft")
+	assert.Contains(t, ms.tokenIndex, "title")
+}
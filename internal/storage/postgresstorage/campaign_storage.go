@@ -0,0 +1,114 @@
+package postgresstorage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+const selectCampaignColumns = "id, user_id, name, description, utm_params, created_at, updated_at"
+
+// CreateCampaign сохраняет кампанию.
+func (ps *PostgresStorage) CreateCampaign(ctx context.Context, campaign model.Campaign) error {
+	_, err := ps.pool.Exec(ctx,
+		`INSERT INTO campaigns (id, user_id, name, description, utm_params, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, now()), COALESCE($7, now()))`,
+		campaign.ID, campaign.UserID, campaign.Name, campaign.Description, paramsArg(campaign.UTMParams),
+		nullTime(campaign.CreatedAt), nullTime(campaign.UpdatedAt),
+	)
+
+	return err
+}
+
+// FindCampaign находит кампанию по идентификатору.
+func (ps *PostgresStorage) FindCampaign(ctx context.Context, id string) (model.Campaign, error) {
+	campaign, err := scanCampaign(ps.pool.QueryRow(ctx,
+		"SELECT "+selectCampaignColumns+" FROM campaigns WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Campaign{}, repository.ErrNotFound
+		}
+
+		return model.Campaign{}, err
+	}
+
+	return campaign, nil
+}
+
+// FindCampaignsByUserID находит кампании пользователя в порядке создания.
+func (ps *PostgresStorage) FindCampaignsByUserID(ctx context.Context, userID string) ([]model.Campaign, error) {
+	rows, err := ps.pool.Query(ctx,
+		"SELECT "+selectCampaignColumns+" FROM campaigns WHERE user_id = $1 ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaigns := make([]model.Campaign, 0)
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, rows.Err()
+}
+
+// UpdateCampaign заменяет название, описание и шаблон параметров кампании.
+func (ps *PostgresStorage) UpdateCampaign(ctx context.Context, campaign model.Campaign) error {
+	tag, err := ps.pool.Exec(ctx,
+		"UPDATE campaigns SET name = $2, description = $3, utm_params = $4, updated_at = COALESCE($5, now()) WHERE id = $1",
+		campaign.ID, campaign.Name, campaign.Description, paramsArg(campaign.UTMParams), nullTime(campaign.UpdatedAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteCampaign удаляет кампанию; ссылки исключаются из нее внешним ключом.
+func (ps *PostgresStorage) DeleteCampaign(ctx context.Context, id string) error {
+	tag, err := ps.pool.Exec(ctx, "DELETE FROM campaigns WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// CampaignStats считает ссылки и переходы кампании одним запросом.
+func (ps *PostgresStorage) CampaignStats(ctx context.Context, id string) (model.CampaignStats, error) {
+	var stats model.CampaignStats
+	err := ps.pool.QueryRow(ctx,
+		`SELECT count(*),
+			count(*) FILTER (WHERE is_deleted IS NOT TRUE),
+			count(*) FILTER (WHERE is_deleted),
+			COALESCE(sum(click_count), 0)
+		FROM urls WHERE campaign_id = $1`,
+		id,
+	).Scan(&stats.Links, &stats.ActiveLinks, &stats.DeletedLinks, &stats.Clicks)
+
+	return stats, err
+}
+
+func scanCampaign(row pgx.Row) (model.Campaign, error) {
+	var campaign model.Campaign
+	err := row.Scan(&campaign.ID, &campaign.UserID, &campaign.Name, &campaign.Description, &campaign.UTMParams,
+		&campaign.CreatedAt, &campaign.UpdatedAt)
+
+	return campaign, err
+}
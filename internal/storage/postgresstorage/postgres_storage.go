@@ -4,15 +4,42 @@ package postgresstorage
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/MarkelovSergey/url-shorter/internal/model"
 	"github.com/MarkelovSergey/url-shorter/internal/repository"
+	"github.com/MarkelovSergey/url-shorter/internal/storage"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Ограничения уникальности таблицы urls.
+const (
+	dedupKeyIndex      = "idx_unique_dedup_key"
+	shortURLConstraint = "urls_short_url_key"
+)
+
+// insertURLQuery добавляет ссылку вместе с тегами одним запросом.
+const insertURLQuery = `WITH inserted AS (
+		INSERT INTO urls (uuid, short_url, original_url, normalized_url, dedup_key, user_id,
+			created_at, updated_at, click_count, expires_at, title, notes, campaign_id, utm_params, utm_override,
+			passthrough, redirect_type, password_hash, max_clicks, active_from)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, COALESCE($7, now()), COALESCE($8, now()), $9, $10, $11, $12,
+			NULLIF($13, ''), $14, $15, $16, $17, $18, $19, $20)
+		RETURNING uuid
+	)
+	INSERT INTO url_tags (url_uuid, tag) SELECT inserted.uuid, unnest($21::text[]) FROM inserted`
+
+// selectURLColumns - столбцы записи в порядке, который ожидает scanRecord.
+const selectURLColumns = `uuid, short_url, original_url, COALESCE(normalized_url, ''), COALESCE(user_id, ''),
+	COALESCE(is_deleted, false), created_at, updated_at, click_count, expires_at, title, notes,
+	COALESCE(campaign_id, ''), utm_params, utm_override, passthrough, redirect_type, password_hash, max_clicks, active_from,
+	COALESCE((SELECT array_agg(tag ORDER BY tag) FROM url_tags WHERE url_uuid = urls.uuid), '{}')`
+
 // PostgresStorage представляет PostgreSQL-хранилище.
 type PostgresStorage struct {
 	pool *pgxpool.Pool
@@ -25,41 +52,19 @@ func New(pool *pgxpool.Pool) *PostgresStorage {
 
 // Load загружает все записи из базы данных.
 func (ps *PostgresStorage) Load(ctx context.Context) ([]model.URLRecord, error) {
-	rows, err := ps.pool.Query(ctx,
-		"SELECT uuid, short_url, original_url, COALESCE(user_id, ''), COALESCE(is_deleted, false) FROM urls")
+	rows, err := ps.pool.Query(ctx, "SELECT "+selectURLColumns+" FROM urls")
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var records []model.URLRecord
-	for rows.Next() {
-		var record model.URLRecord
-		if err := rows.Scan(&record.UUID, &record.ShortURL, &record.OriginalURL, &record.UserID, &record.IsDeleted); err != nil {
-			return nil, err
-		}
-		records = append(records, record)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
 
-	return records, nil
+	return collectRecords(rows)
 }
 
 // Append добавляет запись в базу данных.
 func (ps *PostgresStorage) Append(ctx context.Context, record model.URLRecord) error {
-	_, err := ps.pool.Exec(ctx,
-		"INSERT INTO urls (uuid, short_url, original_url, user_id) VALUES ($1, $2, $3, $4)",
-		record.UUID, record.ShortURL, record.OriginalURL, record.UserID)
-
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-		return repository.ErrURLAlreadyExists
-	}
+	_, err := ps.pool.Exec(ctx, insertURLQuery, insertArgs(record)...)
 
-	return err
+	return uniqueViolation(err)
 }
 
 // AppendBatch добавляет несколько записей в базу данных.
@@ -70,10 +75,7 @@ func (ps *PostgresStorage) AppendBatch(ctx context.Context, records []model.URLR
 
 	batch := &pgx.Batch{}
 	for _, record := range records {
-		batch.Queue(
-			"INSERT INTO urls (uuid, short_url, original_url, user_id) VALUES ($1, $2, $3, $4)",
-			record.UUID, record.ShortURL, record.OriginalURL, record.UserID,
-		)
+		batch.Queue(insertURLQuery, insertArgs(record)...)
 	}
 
 	br := ps.pool.SendBatch(ctx, batch)
@@ -81,20 +83,21 @@ func (ps *PostgresStorage) AppendBatch(ctx context.Context, records []model.URLR
 
 	for range records {
 		if _, err := br.Exec(); err != nil {
-			return err
+			return uniqueViolation(err)
 		}
 	}
 
 	return nil
 }
 
-// FindByOriginalURL находит короткий URL по оригинальному.
-func (ps *PostgresStorage) FindByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+// FindByDedupKey находит короткий URL по ключу поиска дубликатов.
+// Условие по md5 позволяет использовать уникальный индекс по хешу ключа.
+func (ps *PostgresStorage) FindByDedupKey(ctx context.Context, dedupKey string) (string, error) {
 	var shortURL string
 	err := ps.pool.QueryRow(
 		ctx,
-		"SELECT short_url FROM urls WHERE original_url = $1",
-		originalURL,
+		"SELECT short_url FROM urls WHERE md5(dedup_key) = md5($1) AND dedup_key = $1",
+		dedupKey,
 	).Scan(&shortURL)
 
 	if err != nil {
@@ -136,30 +139,121 @@ func (ps *PostgresStorage) FindByShortURL(ctx context.Context, shortURL string)
 	return originalURL, nil
 }
 
-// FindByUserID находит все URL пользователя.
-func (ps *PostgresStorage) FindByUserID(ctx context.Context, userID string) ([]model.URLRecord, error) {
-	rows, err := ps.pool.Query(ctx,
-		"SELECT uuid, short_url, original_url, COALESCE(user_id, ''), COALESCE(is_deleted, false) FROM urls WHERE user_id = $1",
-		userID)
+// FindRecord находит запись по короткому URL.
+func (ps *PostgresStorage) FindRecord(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	record, err := scanRecord(ps.pool.QueryRow(ctx,
+		"SELECT "+selectURLColumns+" FROM urls WHERE short_url = $1",
+		shortURL))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.URLRecord{}, repository.ErrNotFound
+		}
+
+		return model.URLRecord{}, err
+	}
+
+	return record, nil
+}
+
+// FindByUserID находит URL пользователя, удовлетворяющие фильтру.
+// Слова ищутся по search_vector, теги - по таблице url_tags.
+func (ps *PostgresStorage) FindByUserID(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error) {
+	query := "SELECT " + selectURLColumns + " FROM urls WHERE user_id = $1"
+	args := []any{userID}
+
+	if words := storage.SearchTokens(filter.Query); len(words) > 0 {
+		args = append(args, strings.Join(words, " "))
+		query += fmt.Sprintf(" AND search_vector @@ plainto_tsquery('simple', $%d)", len(args))
+	}
+
+	if filter.CampaignID != "" {
+		args = append(args, filter.CampaignID)
+		query += fmt.Sprintf(" AND campaign_id = $%d", len(args))
+	}
+
+	if len(filter.Tags) > 0 {
+		args = append(args, filter.Tags)
+		query += fmt.Sprintf(
+			" AND (SELECT count(*) FROM url_tags WHERE url_uuid = urls.uuid AND tag = ANY($%d)) = %d",
+			len(args), len(filter.Tags))
+	}
+
+	rows, err := ps.pool.Query(ctx, query+" ORDER BY created_at, uuid", args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var records []model.URLRecord
-	for rows.Next() {
-		var record model.URLRecord
-		if err := rows.Scan(&record.UUID, &record.ShortURL, &record.OriginalURL, &record.UserID, &record.IsDeleted); err != nil {
-			return nil, err
+	return collectRecords(rows)
+}
+
+// UpdateAttributes заменяет свойства ссылки и ее теги в одной транзакции.
+func (ps *PostgresStorage) UpdateAttributes(ctx context.Context, shortURL, userID string, attrs model.LinkAttributes) error {
+	return pgx.BeginFunc(ctx, ps.pool, func(tx pgx.Tx) error {
+		var (
+			uuid      string
+			isDeleted bool
+		)
+
+		err := tx.QueryRow(ctx,
+			"SELECT uuid, COALESCE(is_deleted, false) FROM urls WHERE short_url = $1 AND user_id = $2 FOR UPDATE",
+			shortURL, userID,
+		).Scan(&uuid, &isDeleted)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return repository.ErrNotFound
+			}
+
+			return err
 		}
-		records = append(records, record)
+
+		if isDeleted {
+			return repository.ErrDeleted
+		}
+
+		batch := &pgx.Batch{}
+		batch.Queue(
+			`UPDATE urls SET title = $2, notes = $3, campaign_id = NULLIF($4, ''), utm_params = $5, utm_override = $6,
+				passthrough = $7, redirect_type = $8, max_clicks = $9, active_from = $10, expires_at = $11,
+				updated_at = now() WHERE uuid = $1`,
+			uuid, attrs.Title, attrs.Notes, attrs.CampaignID, paramsArg(attrs.UTMParams), attrs.UTMOverride,
+			attrs.Passthrough, attrs.RedirectType, attrs.MaxClicks, attrs.ActiveFrom, attrs.ExpiresAt)
+		batch.Queue("DELETE FROM url_tags WHERE url_uuid = $1", uuid)
+		batch.Queue("INSERT INTO url_tags (url_uuid, tag) SELECT $1, unnest($2::text[])", uuid, tagsArg(attrs.Tags))
+
+		return tx.SendBatch(ctx, batch).Close()
+	})
+}
+
+// IncrementClicks увеличивает счетчик переходов по ссылке.
+// Лимит проверяется в том же условном UPDATE, поэтому параллельные переходы
+// не могут израсходовать больше MaxClicks. Если строка не изменилась,
+// отдельный запрос определяет, исчерпан лимит или ссылки нет.
+func (ps *PostgresStorage) IncrementClicks(ctx context.Context, shortURL string) error {
+	var clicks int64
+	err := ps.pool.QueryRow(ctx,
+		`UPDATE urls SET click_count = click_count + 1
+			WHERE short_url = $1 AND (max_clicks = 0 OR click_count < max_clicks)
+			RETURNING click_count`,
+		shortURL,
+	).Scan(&clicks)
+	if err == nil {
+		return nil
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
 	}
 
-	return records, nil
+	var exists bool
+	if err := ps.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM urls WHERE short_url = $1)", shortURL).Scan(&exists); err != nil {
+		return err
+	}
+
+	if exists {
+		return repository.ErrExhausted
+	}
+
+	return repository.ErrNotFound
 }
 
 // DeleteBatch удаляет несколько URL пакетно.
@@ -167,7 +261,7 @@ func (ps *PostgresStorage) DeleteBatch(ctx context.Context, shortURLs []string,
 	batch := &pgx.Batch{}
 	for _, shortURL := range shortURLs {
 		batch.Queue(
-			"UPDATE urls SET is_deleted = true WHERE short_url = $1 AND user_id = $2",
+			"UPDATE urls SET is_deleted = true, updated_at = now() WHERE short_url = $1 AND user_id = $2 AND is_deleted IS NOT TRUE",
 			shortURL, userID,
 		)
 	}
@@ -183,3 +277,95 @@ func (ps *PostgresStorage) DeleteBatch(ctx context.Context, shortURLs []string,
 
 	return nil
 }
+
+func insertArgs(record model.URLRecord) []any {
+	return []any{
+		record.UUID, record.ShortURL, record.OriginalURL,
+		storage.NormalizedURL(record), storage.DedupKey(record), record.UserID,
+		nullTime(record.CreatedAt), nullTime(record.UpdatedAt), record.ClickCount, record.ExpiresAt,
+		record.Title, record.Notes, record.CampaignID, paramsArg(record.UTMParams), record.UTMOverride,
+		record.Passthrough, record.RedirectType, record.PasswordHash, record.MaxClicks, record.ActiveFrom,
+		tagsArg(record.Tags),
+	}
+}
+
+// paramsArg передает пустой шаблон параметров как NULL.
+func paramsArg(params map[string]string) any {
+	if len(params) == 0 {
+		return nil
+	}
+
+	return params
+}
+
+// tagsArg передает отсутствующие теги пустым массивом, а не NULL.
+func tagsArg(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+
+	return tags
+}
+
+// nullTime передает нулевое время как NULL, чтобы база подставила текущее.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// scanRecord читает запись из строки, выбранной со столбцами selectURLColumns.
+func scanRecord(row pgx.Row) (model.URLRecord, error) {
+	var record model.URLRecord
+	err := row.Scan(
+		&record.UUID, &record.ShortURL, &record.OriginalURL, &record.NormalizedURL, &record.UserID,
+		&record.IsDeleted, &record.CreatedAt, &record.UpdatedAt, &record.ClickCount, &record.ExpiresAt,
+		&record.Title, &record.Notes, &record.CampaignID, &record.UTMParams, &record.UTMOverride,
+		&record.Passthrough, &record.RedirectType, &record.PasswordHash, &record.MaxClicks, &record.ActiveFrom,
+		&record.Tags,
+	)
+	if len(record.Tags) == 0 {
+		record.Tags = nil
+	}
+
+	return record, err
+}
+
+// collectRecords читает все записи выборки и закрывает ее.
+func collectRecords(rows pgx.Rows) ([]model.URLRecord, error) {
+	defer rows.Close()
+
+	var records []model.URLRecord
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// uniqueViolation сопоставляет нарушение уникальности с ошибкой репозитория.
+func uniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.UniqueViolation {
+		return err
+	}
+
+	switch pgErr.ConstraintName {
+	case dedupKeyIndex:
+		return repository.ErrURLAlreadyExists
+	case shortURLConstraint:
+		return repository.ErrShortCodeAlreadyExist
+	default:
+		return err
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/MarkelovSergey/url-shorter/internal/model"
+)
+
+// SearchTokens разбивает текст на слова для полнотекстового поиска:
+// последовательности букв и цифр в нижнем регистре без повторов.
+// Так же словарь simple разбирает текст в PostgreSQL.
+func SearchTokens(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	slices.Sort(fields)
+
+	return slices.Compact(fields)
+}
+
+// RecordTokens возвращает слова заголовка и заметок записи.
+func RecordTokens(record model.URLRecord) []string {
+	return SearchTokens(record.Title + " " + record.Notes)
+}
+
+// MatchFilter проверяет, что запись удовлетворяет фильтру: входит в кампанию,
+// содержит все теги и все слова запроса. Используется хранилищами без собственного поиска.
+func MatchFilter(record model.URLRecord, filter model.URLFilter) bool {
+	if filter.CampaignID != "" && record.CampaignID != filter.CampaignID {
+		return false
+	}
+
+	for _, tag := range filter.Tags {
+		if !slices.Contains(record.Tags, tag) {
+			return false
+		}
+	}
+
+	if filter.Query == "" {
+		return true
+	}
+
+	tokens := RecordTokens(record)
+	for _, word := range SearchTokens(filter.Query) {
+		if _, found := slices.BinarySearch(tokens, word); !found {
+			return false
+		}
+	}
+
+	return true
+}
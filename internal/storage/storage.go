@@ -7,13 +7,75 @@ import (
 	"github.com/MarkelovSergey/url-shorter/internal/model"
 )
 
+// DedupKey возвращает ключ, по которому запись сравнивается с дубликатами,
+// или пустую строку, если запись в поиске дубликатов не участвует.
+// Для записей, сохраненных до появления нормализации, ключом служит OriginalURL.
+func DedupKey(record model.URLRecord) string {
+	if record.DedupKey != "" {
+		return record.DedupKey
+	}
+
+	if record.NormalizedURL == "" {
+		return record.OriginalURL
+	}
+
+	return ""
+}
+
+// NormalizedURL возвращает нормализованный URL записи или OriginalURL,
+// если запись сохранена до появления нормализации.
+func NormalizedURL(record model.URLRecord) string {
+	if record.NormalizedURL != "" {
+		return record.NormalizedURL
+	}
+
+	return record.OriginalURL
+}
+
 // Storage определяет интерфейс хранилища URL.
+// Дубликаты ищутся по ключу, который назначает репозиторий (см. DedupKey):
+// хранилище обеспечивает его уникальность среди записей с непустым ключом.
+// FindRecord возвращает запись целиком, включая удаленную,
+// или repository.ErrNotFound, если кода нет.
+// FindByUserID отбирает записи по фильтру с уже нормализованными тегами и словами запроса.
+// UpdateAttributes заменяет свойства ссылки владельца и возвращает repository.ErrNotFound
+// для чужой или отсутствующей ссылки и repository.ErrDeleted для удаленной.
+// IncrementClicks увеличивает счетчик переходов по ссылке. Если у ссылки задан MaxClicks,
+// проверка лимита и увеличение выполняются атомарно, а при исчерпанном лимите
+// счетчик не меняется и возвращается repository.ErrExhausted.
 type Storage interface {
 	Load(ctx context.Context) ([]model.URLRecord, error)
 	Append(ctx context.Context, record model.URLRecord) error
 	AppendBatch(ctx context.Context, records []model.URLRecord) error
-	FindByOriginalURL(ctx context.Context, originalURL string) (string, error)
+	FindByDedupKey(ctx context.Context, dedupKey string) (string, error)
 	FindByShortURL(ctx context.Context, shortURL string) (string, error)
-	FindByUserID(ctx context.Context, userID string) ([]model.URLRecord, error)
+	FindRecord(ctx context.Context, shortURL string) (model.URLRecord, error)
+	FindByUserID(ctx context.Context, userID string, filter model.URLFilter) ([]model.URLRecord, error)
+	UpdateAttributes(ctx context.Context, shortURL, userID string, attrs model.LinkAttributes) error
+	IncrementClicks(ctx context.Context, shortURL string) error
 	DeleteBatch(ctx context.Context, shortURLs []string, userID string) error
 }
+
+// CampaignStorage определяет интерфейс хранилища кампаний.
+// Отсутствующая кампания обозначается repository.ErrNotFound.
+// DeleteCampaign исключает из кампании ее ссылки, но не удаляет их.
+// CampaignStats считает все ссылки кампании, включая удаленные.
+type CampaignStorage interface {
+	CreateCampaign(ctx context.Context, campaign model.Campaign) error
+	FindCampaign(ctx context.Context, id string) (model.Campaign, error)
+	FindCampaignsByUserID(ctx context.Context, userID string) ([]model.Campaign, error)
+	UpdateCampaign(ctx context.Context, campaign model.Campaign) error
+	DeleteCampaign(ctx context.Context, id string) error
+	CampaignStats(ctx context.Context, id string) (model.CampaignStats, error)
+}
+
+// AddCampaignStats учитывает запись в статистике кампании.
+func AddCampaignStats(stats *model.CampaignStats, record model.URLRecord) {
+	stats.Links++
+	if record.IsDeleted {
+		stats.DeletedLinks++
+	} else {
+		stats.ActiveLinks++
+	}
+	stats.Clicks += record.ClickCount
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    ts TIMESTAMPTZ NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    user_id VARCHAR(255),
+    url TEXT NOT NULL,
+    short_code VARCHAR(255),
+    request_id VARCHAR(128),
+    payload JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_ts ON audit_events (ts);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action_id ON audit_events (action, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_user_id_id ON audit_events (user_id, id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_unique_normalized_url;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_original_url ON urls(original_url);
+ALTER TABLE urls DROP COLUMN IF EXISTS normalized_url;
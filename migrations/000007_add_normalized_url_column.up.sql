@@ -0,0 +1,44 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS normalized_url TEXT;
+
+-- Заполняем нормализованный URL для существующих записей: схема и хост в нижнем
+-- регистре, без порта по умолчанию, "/" вместо пустого пути. Остальные шаги
+-- нормализации (Punycode, сегменты . и .., параметры запроса) выполняет сервис
+-- для новых ссылок.
+WITH parts AS (
+    SELECT uuid,
+           lower(substring(original_url FROM '^([A-Za-z][A-Za-z0-9+.-]*)://')) AS scheme,
+           lower(substring(original_url FROM '^[A-Za-z][A-Za-z0-9+.-]*://([^/?#]*)')) AS authority,
+           substring(original_url FROM '^[A-Za-z][A-Za-z0-9+.-]*://[^/?#]*(.*)$') AS rest
+    FROM urls
+    WHERE normalized_url IS NULL
+)
+UPDATE urls
+SET normalized_url = CASE
+    WHEN parts.scheme IS NULL THEN urls.original_url
+    ELSE parts.scheme || '://'
+        || CASE parts.scheme
+            WHEN 'http' THEN regexp_replace(parts.authority, ':80$', '')
+            WHEN 'https' THEN regexp_replace(parts.authority, ':443$', '')
+            ELSE parts.authority
+        END
+        || CASE
+            WHEN parts.rest = '' OR parts.rest LIKE '?%' OR parts.rest LIKE '#%' THEN '/' || parts.rest
+            ELSE parts.rest
+        END
+END
+FROM parts
+WHERE urls.uuid = parts.uuid;
+
+-- Если после нормализации совпало несколько ссылок, ключ остается только у самой ранней:
+-- остальные продолжают открываться, но не участвуют в поиске дубликатов.
+UPDATE urls
+SET normalized_url = NULL
+FROM (
+    SELECT uuid, row_number() OVER (PARTITION BY normalized_url ORDER BY created_at, uuid) AS rn
+    FROM urls
+    WHERE normalized_url IS NOT NULL
+) ranked
+WHERE urls.uuid = ranked.uuid AND ranked.rn > 1;
+
+DROP INDEX IF EXISTS idx_unique_original_url;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_normalized_url ON urls(normalized_url);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_unique_dedup_key;
+
+-- В областях per_user и none один URL может быть сокращен несколько раз:
+-- нормализованный URL сохраняется только у самой ранней записи.
+UPDATE urls
+SET normalized_url = NULL
+FROM (
+    SELECT uuid, row_number() OVER (PARTITION BY normalized_url ORDER BY created_at, uuid) AS rn
+    FROM urls
+    WHERE normalized_url IS NOT NULL
+) ranked
+WHERE urls.uuid = ranked.uuid AND ranked.rn > 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_normalized_url ON urls(normalized_url);
+ALTER TABLE urls DROP COLUMN IF EXISTS dedup_key;
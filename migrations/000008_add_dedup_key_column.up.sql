@@ -0,0 +1,9 @@
+-- Ключ поиска дубликатов назначает приложение в зависимости от области
+-- (global, per_user, none). NULL означает, что запись в поиске не участвует.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS dedup_key TEXT;
+
+-- Существующие записи получают ключ области global, как до появления настройки.
+UPDATE urls SET dedup_key = normalized_url WHERE dedup_key IS NULL;
+
+DROP INDEX IF EXISTS idx_unique_normalized_url;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_dedup_key ON urls(dedup_key);
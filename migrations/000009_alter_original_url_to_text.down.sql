@@ -0,0 +1,17 @@
+-- Откат возвращает ограничение VARCHAR(255). Обрезать URL нельзя: ссылка вела бы
+-- по другому адресу, поэтому при наличии более длинных URL откат прерывается.
+-- Такие ссылки нужно удалить вручную перед повторным откатом; их данные будут потеряны.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM urls WHERE length(original_url) > 255) THEN
+        RAISE EXCEPTION 'cannot roll back: % URLs are longer than 255 characters',
+            (SELECT count(*) FROM urls WHERE length(original_url) > 255);
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS idx_unique_dedup_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_dedup_key ON urls(dedup_key);
+
+ALTER TABLE urls ALTER COLUMN original_url TYPE VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_original_url ON urls(original_url);
@@ -0,0 +1,8 @@
+-- Длина URL ограничивается приложением (-url-max-length), а не схемой.
+ALTER TABLE urls ALTER COLUMN original_url TYPE TEXT;
+DROP INDEX IF EXISTS idx_original_url;
+
+-- Размер записи B-tree индекса ограничен, поэтому уникальность длинных ключей
+-- проверяется по их хешу. Поиск по ключу использует тот же индекс.
+DROP INDEX IF EXISTS idx_unique_dedup_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_dedup_key ON urls(md5(dedup_key));
@@ -0,0 +1,7 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE urls DROP COLUMN IF EXISTS click_count;
+ALTER TABLE urls DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE urls ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE urls ALTER COLUMN created_at SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE urls ALTER COLUMN created_at TYPE TIMESTAMP;
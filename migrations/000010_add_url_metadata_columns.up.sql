@@ -0,0 +1,13 @@
+-- Время создания хранилось без часового пояса в поясе сессии сервера.
+ALTER TABLE urls ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+UPDATE urls SET created_at = now() WHERE created_at IS NULL;
+ALTER TABLE urls ALTER COLUMN created_at SET DEFAULT now();
+ALTER TABLE urls ALTER COLUMN created_at SET NOT NULL;
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+UPDATE urls SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE urls ALTER COLUMN updated_at SET DEFAULT now();
+ALTER TABLE urls ALTER COLUMN updated_at SET NOT NULL;
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS click_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
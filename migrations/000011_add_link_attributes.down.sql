@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS url_tags;
+
+DROP INDEX IF EXISTS idx_urls_search_vector;
+ALTER TABLE urls DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE urls DROP COLUMN IF EXISTS notes;
+ALTER TABLE urls DROP COLUMN IF EXISTS title;
@@ -0,0 +1,16 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS title TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+
+-- Словарь simple не отбрасывает стоп-слова и не приводит слова к основе,
+-- так же ищет хранилище в памяти.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple', title || ' ' || notes)) STORED;
+CREATE INDEX IF NOT EXISTS idx_urls_search_vector ON urls USING GIN (search_vector);
+
+CREATE TABLE IF NOT EXISTS url_tags (
+    url_uuid VARCHAR(255) NOT NULL REFERENCES urls(uuid) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (url_uuid, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_tags_tag ON url_tags(tag);
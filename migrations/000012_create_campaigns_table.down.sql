@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_urls_campaign_id;
+ALTER TABLE urls DROP COLUMN IF EXISTS campaign_id;
+
+DROP TABLE IF EXISTS campaigns;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_user_id ON campaigns(user_id);
+
+-- При удалении кампании ссылки остаются, но исключаются из нее.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS campaign_id VARCHAR(36) REFERENCES campaigns(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_urls_campaign_id ON urls(campaign_id);
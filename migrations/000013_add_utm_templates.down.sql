@@ -0,0 +1,4 @@
+ALTER TABLE campaigns DROP COLUMN IF EXISTS utm_params;
+
+ALTER TABLE urls DROP COLUMN IF EXISTS utm_override;
+ALTER TABLE urls DROP COLUMN IF EXISTS utm_params;
@@ -0,0 +1,4 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm_params JSONB;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm_override TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS utm_params JSONB;
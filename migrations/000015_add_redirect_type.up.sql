@@ -0,0 +1,2 @@
+-- 0 - код перенаправления по умолчанию из конфигурации сервиса.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS redirect_type SMALLINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Пустая строка - ссылка без пароля.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS password_hash TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- 0 - число переходов не ограничено.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks BIGINT NOT NULL DEFAULT 0;
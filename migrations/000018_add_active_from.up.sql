@@ -0,0 +1,2 @@
+-- NULL - ссылка действует сразу после создания.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS active_from TIMESTAMPTZ;